@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
@@ -18,6 +19,8 @@ import (
 	"modeld/internal/httpapi"
 	"modeld/internal/manager"
 	"modeld/internal/registry"
+	"modeld/internal/usage"
+	"modeld/pkg/types"
 
 	"github.com/rs/zerolog"
 )
@@ -40,6 +43,11 @@ func (p logFilePublisher) Publish(e manager.Event) {
 }
 
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 && os.Args[1] == "usage" {
+		os.Exit(runUsage(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	// Flags with environment variable defaults
 	defaultAddr := ":8080"
 	if v := os.Getenv("MODELD_ADDR"); v != "" {
@@ -48,16 +56,26 @@ func main() {
 
 	addr := flag.String("addr", defaultAddr, "HTTP listen address, e.g. :8080")
 	configPath := flag.String("config", "", "Optional path to config file (yaml|yml|json|toml)")
-	modelsDir := flag.String("models-dir", "~/models/llm", "Directory to scan for *.gguf model files")
+	modelsDir := flag.String("models-dir", "~/models/llm", "Comma-separated directories to scan for *.gguf model files")
+	modelsRecursive := flag.Bool("models-recursive", false, "Scan models-dir subdirectories; model IDs are paths relative to their directory")
+	modelsInclude := flag.String("models-include", "", "Comma-separated globs a model path (relative to its directory) must match, e.g. 'vendor/**/*.gguf'")
+	modelsExclude := flag.String("models-exclude", "", "Comma-separated globs of model files and directories to skip, e.g. 'archive,*-f32.gguf'")
+	modelsFollowSymlinks := flag.Bool("models-follow-symlinks", false, "Descend into symlinked directories when scanning recursively")
+	registryWatch := flag.Duration("registry-watch", 0, "Poll models-dir and the manifest for changes and reload the registry at this interval (0 disables)")
+	modelsManifest := flag.String("models-manifest", "", "Model manifest (YAML/JSON/TOML) declaring IDs, aliases and metadata; merged with the models-dir scan")
 	vramBudgetMB := flag.Int("vram-budget-mb", 0, "VRAM budget in MB for all instances (0=unlimited)")
 	vramMarginMB := flag.Int("vram-margin-mb", 0, "Reserved VRAM margin in MB to keep free")
 	defaultModel := flag.String("default-model", "", "Default model id when request omits model")
+	lruStateFile := flag.String("lru-state-file", "", "If set, keep the last-use history of models in this file across restarts")
+	prewarm := flag.Int("prewarm", 0, "At startup, load up to this many of the most recently used models from --lru-state-file that fit the VRAM budget (0 disables)")
 	shutdownTimeout := flag.Duration("shutdown-timeout", 5*time.Second, "Graceful shutdown timeout (e.g., 5s, 30s)")
 	maxBodyBytes := flag.Int64("max-body-bytes", 1<<20, "Maximum request body size in bytes for JSON endpoints (default 1MiB)")
 	logLevel := flag.String("log-level", os.Getenv("MODELD_LOG_LEVEL"), "Log level: off|error|info|debug (default from MODELD_LOG_LEVEL)")
 	// Backpressure knobs
 	maxQueueDepth := flag.Int("max-queue-depth", 0, "Max queued requests per model instance (0=default)")
 	maxWait := flag.Duration("max-wait", 0, "Max time a request may wait in an instance queue (e.g., 30s; 0=default)")
+	slots := flag.Int("slots", 0, "Parallel generation slots per model instance; passed as --parallel in spawn mode (0=default 1)")
+	priorityAging := flag.Duration("priority-aging", 0, "Promote a waiting request one priority class per interval (e.g., 5s; 0=default, negative disables)")
 	// Infer handler timeout (separate from server timeouts)
 	inferTimeout := flag.Duration("infer-timeout", 0, "Max duration for /infer request before cancellation (0=disabled)")
 	// CORS
@@ -79,9 +97,29 @@ func main() {
 	llamaCtx := flag.Int("llama-ctx", 0, "Context size for spawned llama-server (-c)")
 	llamaNGL := flag.Int("llama-ngl", 0, "NGL (GPU layers) for spawned llama-server (-ngl)")
 	llamaPortRange := flag.String("llama-port-range", "", "Port range for spawned llama-server processes, e.g., 30000-30100")
+	spawnRestartLimit := flag.Int("spawn-restart-limit", 5, "Consecutive crashes of a spawned llama-server before restarts stop (negative disables restarts)")
+	spawnRestartBackoff := flag.Duration("spawn-restart-backoff", time.Second, "Initial delay before restarting a crashed llama-server (doubles up to 30s)")
+	llamaLogLines := flag.Int("llama-log-lines", 1000, "Lines of spawned llama-server output kept in memory per model")
+	llamaLogDir := flag.String("llama-log-dir", "", "If set, also write spawned llama-server output to <dir>/<model>.log")
+	llamaLogMaxMB := flag.Int("llama-log-max-mb", 10, "Rotate a runtime log file once it reaches this size in MB")
+	llamaLogBackups := flag.Int("llama-log-backups", 3, "Rotated runtime log files to keep per model (negative keeps none)")
+	spawnStateFile := flag.String("spawn-state-file", "", "If set, record spawned llama-server processes here, leave them running on shutdown and adopt them on the next start")
 	// Events
 	eventsEnable := flag.Bool("events-enable", false, "Enable manager event publishing to stdout or a file")
 	eventsFile := flag.String("events-file", "", "If set, write events as lines of JSON to this file; otherwise stdout")
+	// Chat templating for /v1/chat/completions and /infer messages
+	chatTemplate := flag.String("chat-template", "", "Fallback chat template when a model's family is unknown: chatml|llama2|llama3|mistral|gemma|phi3|zephyr|plain (default plain)")
+	chatTemplateFromGGUF := flag.Bool("chat-template-from-gguf", false, "Read tokenizer.chat_template from GGUF files whose metadata the registry did not parse")
+	var chatTemplates map[string]string
+	var modelSlots map[string]int
+	var aliases map[string][]config.AliasTarget
+	var modelProfiles map[string]types.RuntimeProfile
+	var priorityQueueDepth map[string]int
+	var tenantWeights map[string]float64
+	var apiKeys []httpapi.APIKey
+	var perKeyLimit, perIPLimit httpapi.RateLimit
+	// Usage accounting
+	usageLedger := flag.String("usage-ledger", "", "Append per-request token usage to this JSONL file and serve GET /admin/usage (empty=disabled)")
 	// Drain timeout for graceful unload
 	drainTimeout := flag.Duration("drain-timeout", 0, "Graceful drain timeout for Unload() (e.g., 2s; 0=default)")
 
@@ -99,8 +137,28 @@ func main() {
 			if !setFlags["addr"] && cfg.Addr != "" {
 				*addr = cfg.Addr
 			}
-			if !setFlags["models-dir"] && cfg.ModelsDir != "" {
-				*modelsDir = cfg.ModelsDir
+			if !setFlags["models-dir"] && (cfg.ModelsDir != "" || len(cfg.ModelsDirs) > 0) {
+				*modelsDir = strings.Join(append(splitCSV(cfg.ModelsDir), cfg.ModelsDirs...), ",")
+			}
+			if !setFlags["models-recursive"] && cfg.ModelsRecursive {
+				*modelsRecursive = true
+			}
+			if !setFlags["models-include"] && len(cfg.ModelsInclude) > 0 {
+				*modelsInclude = strings.Join(cfg.ModelsInclude, ",")
+			}
+			if !setFlags["models-exclude"] && len(cfg.ModelsExclude) > 0 {
+				*modelsExclude = strings.Join(cfg.ModelsExclude, ",")
+			}
+			if !setFlags["models-follow-symlinks"] && cfg.ModelsFollowSymlinks {
+				*modelsFollowSymlinks = true
+			}
+			if !setFlags["models-manifest"] && cfg.ModelsManifest != "" {
+				*modelsManifest = cfg.ModelsManifest
+			}
+			if !setFlags["registry-watch"] && cfg.RegistryWatch != "" {
+				if d, err := time.ParseDuration(cfg.RegistryWatch); err == nil {
+					*registryWatch = d
+				}
 			}
 			if !setFlags["vram-budget-mb"] && cfg.VRAMBudgetMB != 0 {
 				*vramBudgetMB = cfg.VRAMBudgetMB
@@ -111,6 +169,12 @@ func main() {
 			if !setFlags["default-model"] && cfg.DefaultModel != "" {
 				*defaultModel = cfg.DefaultModel
 			}
+			if !setFlags["lru-state-file"] && cfg.LRUStateFile != "" {
+				*lruStateFile = cfg.LRUStateFile
+			}
+			if !setFlags["prewarm"] && cfg.Prewarm != 0 {
+				*prewarm = cfg.Prewarm
+			}
 			if !setFlags["log-level"] && cfg.LogLevel != "" {
 				*logLevel = cfg.LogLevel
 			}
@@ -142,6 +206,31 @@ func main() {
 					*maxWait = d
 				}
 			}
+			if !setFlags["slots"] && cfg.Slots > 0 {
+				*slots = cfg.Slots
+			}
+			modelSlots = cfg.ModelSlots
+			aliases = cfg.Aliases
+			if !setFlags["priority-aging"] && cfg.PriorityAging != "" {
+				if d, err := time.ParseDuration(cfg.PriorityAging); err == nil {
+					*priorityAging = d
+				}
+			}
+			priorityQueueDepth = cfg.PriorityQueueDepth
+			tenantWeights = cfg.TenantWeights
+			for _, k := range cfg.APIKeys {
+				key := httpapi.APIKey{ID: k.ID, SHA256: k.SHA256, Scopes: k.Scopes, Models: k.Models}
+				if k.RateLimit != nil {
+					rl := httpRateLimit(*k.RateLimit)
+					key.RateLimit = &rl
+				}
+				apiKeys = append(apiKeys, key)
+			}
+			if !setFlags["usage-ledger"] && cfg.UsageLedger != "" {
+				*usageLedger = cfg.UsageLedger
+			}
+			perKeyLimit = httpRateLimit(cfg.RateLimits.PerKey)
+			perIPLimit = httpRateLimit(cfg.RateLimits.PerIP)
 			// Inference / llama.cpp server
 			if !setFlags["llama-url"] && cfg.LlamaServerURL != "" {
 				*llamaURL = cfg.LlamaServerURL
@@ -162,24 +251,83 @@ func main() {
 			if !setFlags["llama-use-openai"] {
 				*llamaUseOpenAI = cfg.LlamaUseOpenAI
 			}
+			modelProfiles = cfg.ModelProfiles
+			if !setFlags["spawn-restart-limit"] && cfg.SpawnRestartLimit != 0 {
+				*spawnRestartLimit = cfg.SpawnRestartLimit
+			}
+			if !setFlags["spawn-restart-backoff"] && cfg.SpawnRestartBackoff != "" {
+				if d, err := time.ParseDuration(cfg.SpawnRestartBackoff); err == nil {
+					*spawnRestartBackoff = d
+				}
+			}
+			if !setFlags["llama-log-lines"] && cfg.LlamaLogLines > 0 {
+				*llamaLogLines = cfg.LlamaLogLines
+			}
+			if !setFlags["llama-log-dir"] && cfg.LlamaLogDir != "" {
+				*llamaLogDir = cfg.LlamaLogDir
+			}
+			if !setFlags["llama-log-max-mb"] && cfg.LlamaLogMaxMB > 0 {
+				*llamaLogMaxMB = cfg.LlamaLogMaxMB
+			}
+			if !setFlags["llama-log-backups"] && cfg.LlamaLogBackups != 0 {
+				*llamaLogBackups = cfg.LlamaLogBackups
+			}
+			if !setFlags["spawn-state-file"] && cfg.SpawnStateFile != "" {
+				*spawnStateFile = cfg.SpawnStateFile
+			}
+			// Chat templating
+			if !setFlags["chat-template"] && cfg.ChatTemplate != "" {
+				*chatTemplate = cfg.ChatTemplate
+			}
+			if !setFlags["chat-template-from-gguf"] && cfg.ChatTemplateFromGGUF {
+				*chatTemplateFromGGUF = true
+			}
+			chatTemplates = cfg.ChatTemplates
+		}
+	}
+
+	if *chatTemplate != "" && !manager.IsKnownChatTemplate(*chatTemplate) {
+		log.Fatalf("unknown chat template: %s", *chatTemplate)
+	}
+	for id, name := range chatTemplates {
+		if !manager.IsKnownChatTemplate(name) {
+			log.Fatalf("unknown chat template %q for model %s", name, id)
+		}
+	}
+	for id, p := range modelProfiles {
+		if err := registry.ValidateProfile(p); err != nil {
+			log.Fatalf("invalid model profile for %s: %v", id, err)
 		}
 	}
 
-	// Expand home directory in modelsDir if prefixed with ~
-	if strings.HasPrefix(*modelsDir, "~") {
-		if home, err := os.UserHomeDir(); err == nil {
-			// Support cases like ~/models/llm and bare ~
-			if *modelsDir == "~" {
-				*modelsDir = home
-			} else if strings.HasPrefix(*modelsDir, "~/") {
-				*modelsDir = filepath.Join(home, (*modelsDir)[2:])
+	// Expand home directory in each models dir if prefixed with ~
+	modelDirs := splitCSV(*modelsDir)
+	for i, dir := range modelDirs {
+		if strings.HasPrefix(dir, "~") {
+			if home, err := os.UserHomeDir(); err == nil {
+				// Support cases like ~/models/llm and bare ~
+				if dir == "~" {
+					modelDirs[i] = home
+				} else if strings.HasPrefix(dir, "~/") {
+					modelDirs[i] = filepath.Join(home, dir[2:])
+				}
 			}
 		}
 	}
 
-	// Load registry by scanning modelsDir for *.gguf
-	scanner := registry.NewGGUFScanner()
-	reg, err := scanner.Scan(*modelsDir)
+	// Load registry by scanning the models dirs for *.gguf, merged with the
+	// manifest when one is configured
+	scanOpts := registry.ScanOptions{
+		Recursive:      *modelsRecursive,
+		Include:        splitCSV(*modelsInclude),
+		Exclude:        splitCSV(*modelsExclude),
+		FollowSymlinks: *modelsFollowSymlinks,
+	}
+	var scanner registry.MultiDirScanner = registry.NewGGUFScannerWithOptions(scanOpts)
+	if *modelsManifest != "" {
+		scanner = registry.NewManifestScannerWithOptions(*modelsManifest, scanOpts)
+	}
+	reg, err := scanner.ScanDirs(modelDirs)
 	if err != nil {
 		log.Fatalf("failed to load models: %v", err)
 	}
@@ -207,12 +355,22 @@ func main() {
 
 	mgr := manager.NewWithConfig(manager.ManagerConfig{
 		Registry:      reg,
+		RegistryLoader: func() ([]types.Model, error) {
+			return scanner.ScanDirs(modelDirs)
+		},
+		RegistryDuplicates: scanner.Duplicates,
 		BudgetMB:      *vramBudgetMB,
 		MarginMB:      *vramMarginMB,
 		DefaultModel:  *defaultModel,
 		MaxQueueDepth: *maxQueueDepth,
 		MaxWait:       *maxWait,
 		DrainTimeout:  *drainTimeout,
+		Slots:         *slots,
+		ModelSlots:    modelSlots,
+		// Admission scheduling
+		PriorityQueueDepth: priorityQueueDepth,
+		PriorityAging:      *priorityAging,
+		TenantWeights:      tenantWeights,
 		// Server adapter config
 		LlamaServerURL:      *llamaURL,
 		LlamaAPIKey:         *llamaAPIKey,
@@ -228,6 +386,22 @@ func main() {
 		LlamaThreads:   *llamaThreads,
 		LlamaCtxSize:   *llamaCtx,
 		LlamaNGL:       *llamaNGL,
+		ModelProfiles:  modelProfiles,
+		// Crash supervision
+		SpawnRestartLimit:   *spawnRestartLimit,
+		SpawnRestartBackoff: *spawnRestartBackoff,
+		// Runtime output capture
+		LlamaLogLines:   *llamaLogLines,
+		LlamaLogDir:     *llamaLogDir,
+		LlamaLogMaxMB:   *llamaLogMaxMB,
+		LlamaLogBackups: *llamaLogBackups,
+		// Runtime adoption and usage history across restarts
+		SpawnStateFile: *spawnStateFile,
+		LRUStateFile:   *lruStateFile,
+		// Chat templating
+		ChatTemplates:        chatTemplates,
+		DefaultChatTemplate:  *chatTemplate,
+		ChatTemplateFromGGUF: *chatTemplateFromGGUF,
 	})
 
 	if *eventsEnable {
@@ -244,6 +418,27 @@ func main() {
         }
     }
 
+	// Alias table from config; admin API changes are not persisted
+	aliasNames := make([]string, 0, len(aliases))
+	for name := range aliases {
+		aliasNames = append(aliasNames, name)
+	}
+	sort.Strings(aliasNames)
+	for _, name := range aliasNames {
+		a := types.ModelAlias{Name: name}
+		for _, t := range aliases[name] {
+			a.Targets = append(a.Targets, types.AliasTarget{Model: t.Model, Weight: t.Weight})
+		}
+		if _, err := mgr.SetAlias(a); err != nil {
+			log.Fatalf("invalid alias %q: %v", name, err)
+		}
+	}
+
+	// Take over runtimes a previous modeld left running (spawn state file)
+	if ids := mgr.AdoptRuntimes(); len(ids) > 0 {
+		log.Printf("adopted running llama-server for %s", strings.Join(ids, ", "))
+	}
+
 	// Preflight: validate adapter presence and default model path.
 	checks := mgr.Preflight()
 	preflightOK := true
@@ -251,6 +446,8 @@ func main() {
 		if !c.OK {
 			preflightOK = false
 			log.Printf("[preflight] %s: NOT OK - %s", c.Name, c.Message)
+		} else if c.Message != "" {
+			log.Printf("[preflight] %s: OK - %s", c.Name, c.Message)
 		} else {
 			log.Printf("[preflight] %s: OK", c.Name)
 		}
@@ -264,6 +461,25 @@ func main() {
 	defer baseCancel()
 	httpapi.SetBaseContext(baseCtx)
 
+	// Reload the registry when model files or the manifest change
+	if *registryWatch > 0 {
+		watcher := registry.NewWatcher(*registryWatch, scanOpts, func() {
+			if _, err := mgr.ReloadRegistry(); err != nil {
+				log.Printf("registry reload failed: %v", err)
+			}
+		}, append(modelDirs, *modelsManifest)...)
+		go watcher.Run(baseCtx)
+	}
+
+	// Load the most recently used models in the background
+	if *prewarm > 0 {
+		go func() {
+			if ids := mgr.Prewarm(baseCtx, *prewarm); len(ids) > 0 {
+				log.Printf("pre-warmed %s", strings.Join(ids, ", "))
+			}
+		}()
+	}
+
 	// Configure structured logging
 	// Set global level based on flag/env
 	switch strings.ToLower(strings.TrimSpace(*logLevel)) {
@@ -280,6 +496,7 @@ func main() {
 	cw := zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339}
 	logger := zerolog.New(cw).With().Timestamp().Str("service", "modeld").Logger()
 	httpapi.SetLogger(logger)
+	mgr.SetRuntimeLogger(logger.With().Str("component", "llama-server").Logger())
 	// Apply HTTP settings
 	httpapi.SetMaxBodyBytes(*maxBodyBytes)
 	if *inferTimeout != 0 {
@@ -298,9 +515,27 @@ func main() {
 	if *corsHeaders != "" {
 		headers = splitCSV(*corsHeaders)
 	} else {
-		headers = []string{"Accept", "Authorization", "Content-Type", "X-Requested-With", "X-Log-Level"}
+		headers = []string{"Accept", "Authorization", "Content-Type", "X-Requested-With", "X-Log-Level", "X-Priority", "X-API-Key"}
 	}
 	httpapi.SetCORSOptions(*corsEnabled, origins, methods, headers)
+	// API key authentication (enabled when keys are configured)
+	if err := httpapi.SetAPIKeys(apiKeys); err != nil {
+		log.Fatalf("%v", err)
+	}
+	if len(apiKeys) > 0 {
+		log.Printf("api key authentication enabled (%d keys)", len(apiKeys))
+	}
+	httpapi.SetRateLimits(perKeyLimit, perIPLimit)
+	// Usage accounting ledger
+	if *usageLedger != "" {
+		ledger, err := usage.Open(*usageLedger)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		defer ledger.Close()
+		httpapi.SetUsageLedger(ledger)
+		log.Printf("usage ledger: %s", ledger.Path())
+	}
 	// NewMux registers: /models, /status, /infer, /healthz, /readyz, /metrics
 	mux := httpapi.NewMux(mgr)
 	srv := &http.Server{
@@ -314,9 +549,9 @@ func main() {
 
 	go func() {
 		if *configPath != "" {
-			log.Printf("modeld listening on %s (models dir: %s, config: %s)", *addr, *modelsDir, *configPath)
+			log.Printf("modeld listening on %s (models dir: %s, config: %s)", *addr, strings.Join(modelDirs, ","), *configPath)
 		} else {
-			log.Printf("modeld listening on %s (models dir: %s)", *addr, *modelsDir)
+			log.Printf("modeld listening on %s (models dir: %s)", *addr, strings.Join(modelDirs, ","))
 		}
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("server error: %v", err)
@@ -329,8 +564,8 @@ func main() {
 	<-stop
 	// Cancel base context to stop in-flight handler work
 	baseCancel()
-	// Stop spawned runtimes (best-effort)
-	mgr.StopAllInstances()
+	// Cancel background ops and stop spawned runtimes (best-effort)
+	_ = mgr.Close()
 	ctx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
@@ -350,3 +585,13 @@ func splitCSV(s string) []string {
 	}
 	return out
 }
+
+// httpRateLimit converts a config rate limit to the HTTP layer's type.
+func httpRateLimit(rl config.RateLimit) httpapi.RateLimit {
+	return httpapi.RateLimit{
+		RequestsPerMinute: rl.RequestsPerMinute,
+		RequestBurst:      rl.RequestBurst,
+		TokensPerMinute:   rl.TokensPerMinute,
+		TokenBurst:        rl.TokenBurst,
+	}
+}
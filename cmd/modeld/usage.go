@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"modeld/internal/config"
+	"modeld/internal/usage"
+)
+
+// runUsage implements `modeld usage <subcommand>`. It returns the process
+// exit code.
+func runUsage(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] != "export" {
+		fmt.Fprintln(stderr, "usage: modeld usage export [--ledger path | --config file] [--key id] [--model id] [--from date] [--to date] [--out file.csv]")
+		return 2
+	}
+	fs := flag.NewFlagSet("usage export", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	ledger := fs.String("ledger", "", "Path to the usage ledger (JSONL); defaults to usage_ledger from --config")
+	configPath := fs.String("config", "", "Config file to read usage_ledger from")
+	key := fs.String("key", "", "Only this API key ID")
+	model := fs.String("model", "", "Only this model ID")
+	from := fs.String("from", "", "Start: YYYY-MM-DD or RFC 3339")
+	to := fs.String("to", "", "End: YYYY-MM-DD (inclusive) or RFC 3339 (exclusive)")
+	out := fs.String("out", "", "Write CSV to this file instead of stdout")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if *ledger == "" && *configPath != "" {
+		cfg, err := config.Load(*configPath)
+		if err != nil {
+			fmt.Fprintf(stderr, "load config: %v\n", err)
+			return 1
+		}
+		*ledger = cfg.UsageLedger
+	}
+	if *ledger == "" {
+		fmt.Fprintln(stderr, "no ledger: pass --ledger or a --config with usage_ledger")
+		return 2
+	}
+	fromT, toT, err := usage.ParseRange(*from, *to)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	rows, err := usage.QueryFile(*ledger, usage.Filter{KeyID: *key, ModelID: *model, From: fromT, To: toT})
+	if err != nil {
+		fmt.Fprintf(stderr, "read ledger: %v\n", err)
+		return 1
+	}
+	w := stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			fmt.Fprintf(stderr, "create %s: %v\n", *out, err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := usage.WriteCSV(w, rows); err != nil {
+		fmt.Fprintf(stderr, "write csv: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"modeld/internal/usage"
+)
+
+func TestRunUsageExport(t *testing.T) {
+	dir := t.TempDir()
+	ledgerPath := filepath.Join(dir, "usage.jsonl")
+	l, err := usage.Open(ledgerPath)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	ts := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	_ = l.Record(usage.Record{Time: ts, KeyID: "search", ModelID: "m", PromptTokens: 3, CompletionTokens: 4, GenerationMs: 12})
+	_ = l.Record(usage.Record{Time: ts, KeyID: "chat", ModelID: "m", PromptTokens: 1, CompletionTokens: 1, GenerationMs: 1})
+	_ = l.Close()
+	cfgPath := filepath.Join(dir, "cfg.yaml")
+	if err := os.WriteFile(cfgPath, []byte("usage_ledger: "+ledgerPath+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out, errOut bytes.Buffer
+	if code := runUsage([]string{"export", "--config", cfgPath, "--key", "search", "--from", "2024-05-01", "--to", "2024-05-01"}, &out, &errOut); code != 0 {
+		t.Fatalf("exit %d: %s", code, errOut.String())
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || lines[1] != "2024-05-01,search,m,1,3,4,12" {
+		t.Fatalf("unexpected csv: %q", out.String())
+	}
+
+	if code := runUsage([]string{"export"}, &out, &errOut); code != 2 {
+		t.Fatalf("expected usage error without ledger, got %d", code)
+	}
+	if code := runUsage(nil, &out, &errOut); code != 2 {
+		t.Fatalf("expected usage error without subcommand, got %d", code)
+	}
+}
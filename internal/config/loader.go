@@ -9,16 +9,38 @@ import (
 
 	toml "github.com/pelletier/go-toml/v2"
 	"gopkg.in/yaml.v3"
+
+	"modeld/pkg/types"
 )
 
 // Config holds runtime parameters for the service.
 // Zero values mean "unspecified" and will be replaced by defaults in main.
 type Config struct {
-	Addr         string `json:"addr" yaml:"addr" toml:"addr"`
-	ModelsDir    string `json:"models_dir" yaml:"models_dir" toml:"models_dir"`
-	VRAMBudgetMB int    `json:"vram_budget_mb" yaml:"vram_budget_mb" toml:"vram_budget_mb"`
-	VRAMMarginMB int    `json:"vram_margin_mb" yaml:"vram_margin_mb" toml:"vram_margin_mb"`
-	DefaultModel string `json:"default_model" yaml:"default_model" toml:"default_model"`
+	Addr      string `json:"addr" yaml:"addr" toml:"addr"`
+	ModelsDir string `json:"models_dir" yaml:"models_dir" toml:"models_dir"`
+	// Model discovery: extra directories scanned after models_dir, recursive
+	// scanning, include/exclude globs on the path relative to each directory
+	// and whether to descend into symlinked directories
+	ModelsDirs           []string `json:"models_dirs" yaml:"models_dirs" toml:"models_dirs"`
+	ModelsRecursive      bool     `json:"models_recursive" yaml:"models_recursive" toml:"models_recursive"`
+	ModelsInclude        []string `json:"models_include" yaml:"models_include" toml:"models_include"`
+	ModelsExclude        []string `json:"models_exclude" yaml:"models_exclude" toml:"models_exclude"`
+	ModelsFollowSymlinks bool     `json:"models_follow_symlinks" yaml:"models_follow_symlinks" toml:"models_follow_symlinks"`
+	// Model manifest (YAML/JSON/TOML) with IDs, aliases and metadata; merged
+	// with the models_dir scan
+	ModelsManifest string `json:"models_manifest" yaml:"models_manifest" toml:"models_manifest"`
+	// Registry hot-reload polling interval (e.g. "5s"; "0" disables)
+	RegistryWatch string `json:"registry_watch" yaml:"registry_watch" toml:"registry_watch"`
+	VRAMBudgetMB  int    `json:"vram_budget_mb" yaml:"vram_budget_mb" toml:"vram_budget_mb"`
+	VRAMMarginMB  int    `json:"vram_margin_mb" yaml:"vram_margin_mb" toml:"vram_margin_mb"`
+	DefaultModel  string `json:"default_model" yaml:"default_model" toml:"default_model"`
+	// Last-use history of models kept across restarts, and how many of the
+	// most recently used models to load at startup (within the VRAM budget)
+	LRUStateFile string `json:"lru_state_file" yaml:"lru_state_file" toml:"lru_state_file"`
+	Prewarm      int    `json:"prewarm" yaml:"prewarm" toml:"prewarm"`
+	// Alias table: logical model names routed to registry models, optionally
+	// split by weight between several targets
+	Aliases map[string][]AliasTarget `json:"aliases" yaml:"aliases" toml:"aliases"`
 	// Observability & HTTP
 	LogLevel     string `json:"log_level" yaml:"log_level" toml:"log_level"`
 	MaxBodyBytes int64  `json:"max_body_bytes" yaml:"max_body_bytes" toml:"max_body_bytes"`
@@ -28,19 +50,84 @@ type Config struct {
 	CORSAllowedOrigins []string `json:"cors_allowed_origins" yaml:"cors_allowed_origins" toml:"cors_allowed_origins"`
 	CORSAllowedMethods []string `json:"cors_allowed_methods" yaml:"cors_allowed_methods" toml:"cors_allowed_methods"`
 	CORSAllowedHeaders []string `json:"cors_allowed_headers" yaml:"cors_allowed_headers" toml:"cors_allowed_headers"`
+	// Authentication: API keys stored as SHA-256 digests
+	APIKeys []APIKey `json:"api_keys" yaml:"api_keys" toml:"api_keys"`
+	// Usage accounting ledger (JSONL); empty disables accounting
+	UsageLedger string `json:"usage_ledger" yaml:"usage_ledger" toml:"usage_ledger"`
+	// Rate limits per API key (default for all keys) and per client IP
+	RateLimits RateLimits `json:"rate_limits" yaml:"rate_limits" toml:"rate_limits"`
 	// Backpressure
 	MaxQueueDepth int    `json:"max_queue_depth" yaml:"max_queue_depth" toml:"max_queue_depth"`
 	MaxWait       string `json:"max_wait" yaml:"max_wait" toml:"max_wait"`
+	// Parallel generation slots per instance (global and per model ID)
+	Slots      int            `json:"slots" yaml:"slots" toml:"slots"`
+	ModelSlots map[string]int `json:"model_slots" yaml:"model_slots" toml:"model_slots"`
+	// Admission scheduling: waiting-queue depth per priority class, aging
+	// interval for starvation protection and per-tenant fair-queuing weights
+	PriorityQueueDepth map[string]int     `json:"priority_queue_depth" yaml:"priority_queue_depth" toml:"priority_queue_depth"`
+	PriorityAging      string             `json:"priority_aging" yaml:"priority_aging" toml:"priority_aging"`
+	TenantWeights      map[string]float64 `json:"tenant_weights" yaml:"tenant_weights" toml:"tenant_weights"`
 	// Inference (in-process via llama.cpp)
 	LlamaBin     string `json:"llama_bin" yaml:"llama_bin" toml:"llama_bin"`
 	LlamaCtx     int    `json:"llama_ctx" yaml:"llama_ctx" toml:"llama_ctx"`
 	LlamaThreads int    `json:"llama_threads" yaml:"llama_threads" toml:"llama_threads"`
+	// Per-model llama-server settings keyed by model ID or alias; they take
+	// precedence over the manifest profile and the global llama flags
+	ModelProfiles map[string]types.RuntimeProfile `json:"model_profiles" yaml:"model_profiles" toml:"model_profiles"`
+	// Crash supervision of spawned runtimes: consecutive crashes before
+	// restarts stop (negative disables) and the initial restart backoff
+	SpawnRestartLimit   int    `json:"spawn_restart_limit" yaml:"spawn_restart_limit" toml:"spawn_restart_limit"`
+	SpawnRestartBackoff string `json:"spawn_restart_backoff" yaml:"spawn_restart_backoff" toml:"spawn_restart_backoff"`
+	// Spawned runtime output: lines kept in memory per model and optional
+	// log files under a directory, rotated by size
+	LlamaLogLines   int    `json:"llama_log_lines" yaml:"llama_log_lines" toml:"llama_log_lines"`
+	LlamaLogDir     string `json:"llama_log_dir" yaml:"llama_log_dir" toml:"llama_log_dir"`
+	LlamaLogMaxMB   int    `json:"llama_log_max_mb" yaml:"llama_log_max_mb" toml:"llama_log_max_mb"`
+	LlamaLogBackups int    `json:"llama_log_backups" yaml:"llama_log_backups" toml:"llama_log_backups"`
+	// Record spawned runtimes in this file, leave them running on shutdown
+	// and adopt them on the next start
+	SpawnStateFile string `json:"spawn_state_file" yaml:"spawn_state_file" toml:"spawn_state_file"`
 	// Inference (llama.cpp server)
 	LlamaServerURL      string `json:"llama_url" yaml:"llama_url" toml:"llama_url"`
 	LlamaAPIKey         string `json:"llama_api_key" yaml:"llama_api_key" toml:"llama_api_key"`
 	LlamaRequestTimeout string `json:"llama_timeout" yaml:"llama_timeout" toml:"llama_timeout"`
 	LlamaConnectTimeout string `json:"llama_connect_timeout" yaml:"llama_connect_timeout" toml:"llama_connect_timeout"`
 	LlamaUseOpenAI      bool   `json:"llama_use_openai" yaml:"llama_use_openai" toml:"llama_use_openai"`
+	// Chat templating
+	ChatTemplate         string            `json:"chat_template" yaml:"chat_template" toml:"chat_template"`
+	ChatTemplates        map[string]string `json:"chat_templates" yaml:"chat_templates" toml:"chat_templates"`
+	ChatTemplateFromGGUF bool              `json:"chat_template_from_gguf" yaml:"chat_template_from_gguf" toml:"chat_template_from_gguf"`
+}
+
+// AliasTarget is one weighted destination of an alias.
+type AliasTarget struct {
+	Model  string `json:"model" yaml:"model" toml:"model"`
+	Weight int    `json:"weight" yaml:"weight" toml:"weight"`
+}
+
+// APIKey is a client credential for the HTTP API. SHA256 is the hex digest
+// of the secret (e.g. `printf %s "$KEY" | sha256sum`).
+type APIKey struct {
+	ID     string   `json:"id" yaml:"id" toml:"id"`
+	SHA256 string   `json:"sha256" yaml:"sha256" toml:"sha256"`
+	Scopes []string `json:"scopes" yaml:"scopes" toml:"scopes"`
+	Models []string `json:"models" yaml:"models" toml:"models"`
+	// RateLimit overrides rate_limits.per_key for this key.
+	RateLimit *RateLimit `json:"rate_limit" yaml:"rate_limit" toml:"rate_limit"`
+}
+
+// RateLimits holds the default per-key and per-IP limits.
+type RateLimits struct {
+	PerKey RateLimit `json:"per_key" yaml:"per_key" toml:"per_key"`
+	PerIP  RateLimit `json:"per_ip" yaml:"per_ip" toml:"per_ip"`
+}
+
+// RateLimit configures request and generated-token rates; zero disables.
+type RateLimit struct {
+	RequestsPerMinute float64 `json:"requests_per_minute" yaml:"requests_per_minute" toml:"requests_per_minute"`
+	RequestBurst      int     `json:"request_burst" yaml:"request_burst" toml:"request_burst"`
+	TokensPerMinute   float64 `json:"tokens_per_minute" yaml:"tokens_per_minute" toml:"tokens_per_minute"`
+	TokenBurst        int     `json:"token_burst" yaml:"token_burst" toml:"token_burst"`
 }
 
 // Load reads a configuration file based on its extension.
@@ -33,3 +33,66 @@ func TestLoad_InvalidTOML(t *testing.T) {
 		t.Fatalf("expected TOML unmarshal error")
 	}
 }
+
+func TestLoadYAML_ChatTemplates(t *testing.T) {
+	d := t.TempDir()
+	p := writeTempFile(t, d, "cfg.yaml", "chat_template: chatml\nchat_template_from_gguf: true\nchat_templates:\n  tiny.gguf: zephyr\n")
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.ChatTemplate != "chatml" || !cfg.ChatTemplateFromGGUF || cfg.ChatTemplates["tiny.gguf"] != "zephyr" {
+		t.Fatalf("unexpected cfg: %+v", cfg)
+	}
+}
+
+func TestLoadYAML_Slots(t *testing.T) {
+	d := t.TempDir()
+	p := writeTempFile(t, d, "cfg.yaml", "slots: 2\nmodel_slots:\n  big.gguf: 4\n")
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Slots != 2 || cfg.ModelSlots["big.gguf"] != 4 {
+		t.Fatalf("unexpected cfg: %+v", cfg)
+	}
+}
+
+func TestLoadYAML_Scheduling(t *testing.T) {
+	d := t.TempDir()
+	p := writeTempFile(t, d, "cfg.yaml", "priority_queue_depth:\n  batch: 64\npriority_aging: 10s\ntenant_weights:\n  10.0.0.5: 2.5\n")
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.PriorityQueueDepth["batch"] != 64 || cfg.PriorityAging != "10s" || cfg.TenantWeights["10.0.0.5"] != 2.5 {
+		t.Fatalf("unexpected cfg: %+v", cfg)
+	}
+}
+
+func TestLoadYAML_APIKeys(t *testing.T) {
+	d := t.TempDir()
+	p := writeTempFile(t, d, "cfg.yaml", "api_keys:\n  - id: ci\n    sha256: abc\n    scopes: [infer, read]\n    models: [m.gguf]\n")
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(cfg.APIKeys) != 1 || cfg.APIKeys[0].ID != "ci" || len(cfg.APIKeys[0].Scopes) != 2 || cfg.APIKeys[0].Models[0] != "m.gguf" {
+		t.Fatalf("unexpected cfg: %+v", cfg.APIKeys)
+	}
+}
+
+func TestLoadYAML_RateLimits(t *testing.T) {
+	d := t.TempDir()
+	p := writeTempFile(t, d, "cfg.yaml", "rate_limits:\n  per_ip:\n    requests_per_minute: 30\n    request_burst: 5\n  per_key:\n    tokens_per_minute: 6000\napi_keys:\n  - id: bulk\n    rate_limit:\n      tokens_per_minute: 100000\n")
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.RateLimits.PerIP.RequestsPerMinute != 30 || cfg.RateLimits.PerIP.RequestBurst != 5 || cfg.RateLimits.PerKey.TokensPerMinute != 6000 {
+		t.Fatalf("unexpected rate limits: %+v", cfg.RateLimits)
+	}
+	if rl := cfg.APIKeys[0].RateLimit; rl == nil || rl.TokensPerMinute != 100000 {
+		t.Fatalf("unexpected key rate limit: %+v", rl)
+	}
+}
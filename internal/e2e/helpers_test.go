@@ -153,6 +153,8 @@ func startMockLlamaServer(t *testing.T, modelID string) *httptest.Server {
 		write(mk("hi"))
 		time.Sleep(50 * time.Millisecond)
 		write(mk(" there"))
+		// Final chunk carries llama-server's usage and timings.
+		write(`data: {"choices":[{"delta":{},"finish_reason":"stop"}],"usage":{"prompt_tokens":5,"completion_tokens":2,"total_tokens":7},"timings":{"prompt_n":5,"predicted_n":2}}`)
 		write("data: [DONE]")
 	})
 	ts := httptest.NewServer(mux)
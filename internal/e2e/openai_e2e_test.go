@@ -0,0 +1,70 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"modeld/pkg/types"
+)
+
+func TestE2E_OpenAI_ModelsAndChat(t *testing.T) {
+	dir, ids := createTempModelsDir(t, "alpha.gguf", "beta.gguf")
+	srv, _ := newServerForDir(t, dir, 0, 0, ids[0])
+
+	resp, body := httpGet(t, srv.URL+"/v1/models")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("/v1/models status=%d", resp.StatusCode)
+	}
+	var list types.OpenAIModelList
+	if err := json.Unmarshal(body, &list); err != nil || list.Object != "list" || len(list.Data) != 2 {
+		t.Fatalf("unexpected /v1/models body: %s (err=%v)", body, err)
+	}
+
+	// Non-streaming chat goes through the manager and mock llama server.
+	resp, body = httpPostJSON(t, srv.URL+"/v1/chat/completions", []byte(`{"messages":[{"role":"user","content":"hello"}]}`))
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("chat status=%d body=%s", resp.StatusCode, body)
+	}
+	var chat types.ChatCompletionResponse
+	if err := json.Unmarshal(body, &chat); err != nil {
+		t.Fatalf("chat json: %v", err)
+	}
+	if len(chat.Choices) != 1 || chat.Choices[0].Message.Content != "hi there" {
+		t.Fatalf("unexpected chat response: %s", body)
+	}
+	if u := chat.Usage; u.PromptTokens != 5 || u.CompletionTokens != 2 || u.TotalTokens != 7 {
+		t.Fatalf("unexpected chat usage: %+v", u)
+	}
+
+	resp, body = httpPostJSON(t, srv.URL+"/v1/completions", []byte(`{"prompt":"hello"}`))
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("completions status=%d body=%s", resp.StatusCode, body)
+	}
+	var comp types.CompletionResponse
+	if err := json.Unmarshal(body, &comp); err != nil || comp.Usage == nil || comp.Usage.PromptTokens != 5 || comp.Usage.CompletionTokens != 2 {
+		t.Fatalf("unexpected completion usage: %s (err=%v)", body, err)
+	}
+
+	// Streaming chat returns SSE terminated by [DONE].
+	resp, body = httpPostJSON(t, srv.URL+"/v1/chat/completions", []byte(`{"stream":true,"messages":[{"role":"user","content":"hello"}]}`))
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Fatalf("content-type=%s", ct)
+	}
+	if !strings.HasSuffix(strings.TrimSpace(string(body)), "data: [DONE]") {
+		t.Fatalf("stream not terminated: %s", body)
+	}
+
+	// include_usage adds the backend's counts to the last chunk.
+	_, body = httpPostJSON(t, srv.URL+"/v1/chat/completions", []byte(`{"stream":true,"stream_options":{"include_usage":true},"messages":[{"role":"user","content":"hello"}]}`))
+	if !strings.Contains(string(body), `"prompt_tokens":5,"completion_tokens":2,"total_tokens":7`) {
+		t.Fatalf("stream usage missing: %s", body)
+	}
+
+	// Unknown model maps to 404 like /infer.
+	resp, _ = httpPostJSON(t, srv.URL+"/v1/completions", []byte(`{"model":"nope","prompt":"x"}`))
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown model, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,284 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"modeld/internal/manager"
+	"modeld/pkg/types"
+)
+
+// AdminService is implemented by services that expose model lifecycle
+// operations. It is optional: NewMux only mounts the /admin routes when the
+// Service passed to it also implements AdminService.
+type AdminService interface {
+	LoadModel(modelID string) (types.OperationStatus, error)
+	UnloadModel(modelID string) (types.OperationStatus, error)
+	SwitchModel(modelID string) (types.OperationStatus, error)
+	Operation(id string) (types.OperationStatus, bool)
+	CancelOperation(id string) (types.OperationStatus, error)
+}
+
+// mountAdmin registers the admin routes on r. Load, unload and switch honour
+// the API key's model allow-list.
+func mountAdmin(r chi.Router, svc AdminService) {
+	// Wildcard route: model IDs may contain slashes, so the action is parsed
+	// from the path suffix.
+	r.Post("/admin/models/*", postAdminModel(svc))
+	r.Post("/admin/switch", postAdminSwitch(svc))
+	r.Get("/admin/ops/{id}", getAdminOp(svc))
+	r.Post("/admin/ops/{id}/cancel", postAdminOpCancel(svc))
+}
+
+// writeOperation writes an accepted operation, or maps a start error.
+func writeOperation(w http.ResponseWriter, op types.OperationStatus, err error) {
+	if err != nil {
+		status := http.StatusInternalServerError
+		if manager.IsModelNotFound(err) {
+			status = http.StatusNotFound
+		}
+		writeJSONError(w, status, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/admin/ops/"+op.ID)
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(op)
+}
+
+// postAdminModel starts loading or unloading a model.
+// @Summary Load or unload a model
+// @Description Starts an asynchronous load (POST /admin/models/{id}/load) or graceful unload (POST /admin/models/{id}/unload). Returns the operation to poll via GET /admin/ops/{id}.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Model ID"
+// @Success 202 {object} types.OperationStatus
+// @Failure 404 {object} types.ErrorResponse
+// @Router /admin/models/{id}/load [post]
+// @Router /admin/models/{id}/unload [post]
+func postAdminModel(svc AdminService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rest := chi.URLParam(r, "*")
+		i := strings.LastIndex(rest, "/")
+		if i <= 0 {
+			writeJSONError(w, http.StatusNotFound, "not found")
+			return
+		}
+		id, action := rest[:i], rest[i+1:]
+		if !authorizeModel(w, r, id) {
+			return
+		}
+		switch action {
+		case "load":
+			op, err := svc.LoadModel(id)
+			writeOperation(w, op, err)
+		case "unload":
+			op, err := svc.UnloadModel(id)
+			writeOperation(w, op, err)
+		default:
+			writeJSONError(w, http.StatusNotFound, "unknown action: "+action)
+		}
+	}
+}
+
+// postAdminSwitch starts switching to a model.
+// @Summary Switch model
+// @Description Starts an asynchronous switch to the given model, loading it (and evicting others if the VRAM budget requires). Returns the operation to poll via GET /admin/ops/{id}.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body types.SwitchRequest true "Switch request"
+// @Success 202 {object} types.OperationStatus
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /admin/switch [post]
+func postAdminSwitch(svc AdminService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req types.SwitchRequest
+		if !decodeJSONRequest(w, r, &req) {
+			return
+		}
+		if strings.TrimSpace(req.Model) == "" {
+			writeJSONError(w, http.StatusBadRequest, "model is required")
+			return
+		}
+		if !authorizeModel(w, r, req.Model) {
+			return
+		}
+		op, err := svc.SwitchModel(req.Model)
+		writeOperation(w, op, err)
+	}
+}
+
+// getAdminOp returns the progress or result of an operation.
+// @Summary Operation status
+// @Tags admin
+// @Produce json
+// @Param id path string true "Operation ID"
+// @Success 200 {object} types.OperationStatus
+// @Failure 404 {object} types.ErrorResponse
+// @Router /admin/ops/{id} [get]
+func getAdminOp(svc AdminService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+		op, ok := svc.Operation(id)
+		if !ok {
+			writeJSONError(w, http.StatusNotFound, "operation not found: "+id)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(op)
+	}
+}
+
+// postAdminOpCancel requests cancellation of an operation.
+// @Summary Cancel operation
+// @Description Requests cancellation of a pending or running operation and returns its current status. The operation reaches the canceled state asynchronously; poll GET /admin/ops/{id}. Canceling a finished operation is a no-op.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Operation ID"
+// @Success 200 {object} types.OperationStatus
+// @Failure 404 {object} types.ErrorResponse
+// @Router /admin/ops/{id}/cancel [post]
+func postAdminOpCancel(svc AdminService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		op, err := svc.CancelOperation(chi.URLParam(r, "id"))
+		if err != nil {
+			status := http.StatusInternalServerError
+			if manager.IsOperationNotFound(err) {
+				status = http.StatusNotFound
+			}
+			writeJSONError(w, status, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(op)
+	}
+}
+
+// RegistryReloader is implemented by services whose model registry can be
+// rescanned at runtime. NewMux mounts POST /admin/registry/reload when the
+// Service implements it.
+type RegistryReloader interface {
+	ReloadRegistry() (types.RegistryReloadResponse, error)
+}
+
+// postAdminRegistryReload rescans the model registry.
+// @Summary Reload model registry
+// @Description Rescans the models directory and manifest and atomically swaps the registry. Loaded models whose model was removed or whose file disappeared are drained and unloaded in the background. A failed scan keeps the current registry.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} types.RegistryReloadResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Failure 503 {object} types.ErrorResponse
+// @Router /admin/registry/reload [post]
+func postAdminRegistryReload(svc RegistryReloader) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp, err := svc.ReloadRegistry()
+		if err != nil {
+			status := http.StatusInternalServerError
+			if manager.IsDependencyUnavailable(err) {
+				status = http.StatusServiceUnavailable
+			}
+			writeJSONError(w, status, "registry reload failed: "+err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// AliasService is implemented by services with a runtime alias table that
+// routes logical model names to registry models. NewMux mounts the
+// /admin/aliases routes when the Service implements it.
+type AliasService interface {
+	ListAliases() []types.ModelAlias
+	SetAlias(a types.ModelAlias) (types.ModelAlias, error)
+	DeleteAlias(name string) error
+}
+
+// mountAliases registers the alias table routes on r.
+func mountAliases(r chi.Router, svc AliasService) {
+	r.Get("/admin/aliases", getAdminAliases(svc))
+	r.Put("/admin/aliases/{name}", putAdminAlias(svc))
+	r.Delete("/admin/aliases/{name}", deleteAdminAlias(svc))
+}
+
+// getAdminAliases lists the alias table.
+// @Summary List model aliases
+// @Tags admin
+// @Produce json
+// @Success 200 {object} types.AliasListResponse
+// @Router /admin/aliases [get]
+func getAdminAliases(svc AliasService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(types.AliasListResponse{Aliases: svc.ListAliases()})
+	}
+}
+
+// putAdminAlias creates or replaces an alias.
+// @Summary Set model alias
+// @Description Creates or replaces an alias routing a logical model name to one or more registry models. With several targets each request picks one at random by weight (canary splits). Changes are not persisted across restarts.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param name path string true "Alias name"
+// @Param request body types.ModelAlias true "Alias targets (name is taken from the path)"
+// @Success 200 {object} types.ModelAlias
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 403 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Router /admin/aliases/{name} [put]
+func putAdminAlias(svc AliasService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req types.ModelAlias
+		if !decodeJSONRequest(w, r, &req) {
+			return
+		}
+		req.Name = chi.URLParam(r, "name")
+		for _, t := range req.Targets {
+			if !authorizeModel(w, r, t.Model) {
+				return
+			}
+		}
+		a, err := svc.SetAlias(req)
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case manager.IsInvalidRequest(err):
+				status = http.StatusBadRequest
+			case manager.IsModelNotFound(err):
+				status = http.StatusNotFound
+			}
+			writeJSONError(w, status, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(a)
+	}
+}
+
+// deleteAdminAlias removes an alias.
+// @Summary Delete model alias
+// @Tags admin
+// @Param name path string true "Alias name"
+// @Success 204
+// @Failure 404 {object} types.ErrorResponse
+// @Router /admin/aliases/{name} [delete]
+func deleteAdminAlias(svc AliasService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "name")
+		if err := svc.DeleteAlias(name); err != nil {
+			status := http.StatusInternalServerError
+			if manager.IsModelNotFound(err) {
+				status = http.StatusNotFound
+			}
+			writeJSONError(w, status, err.Error())
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,212 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"modeld/internal/manager"
+	"modeld/pkg/types"
+)
+
+// adminService is a mockService that also implements AdminService.
+type adminService struct {
+	mockService
+	calls []string
+	ops   map[string]types.OperationStatus
+}
+
+func (s *adminService) start(kind, id string) (types.OperationStatus, error) {
+	s.calls = append(s.calls, kind+":"+id)
+	if id == "missing" {
+		return types.OperationStatus{}, manager.ErrModelNotFound(id)
+	}
+	op := types.OperationStatus{ID: "op-1", Kind: kind, ModelID: id, State: "pending"}
+	s.ops = map[string]types.OperationStatus{op.ID: op}
+	return op, nil
+}
+
+func (s *adminService) LoadModel(id string) (types.OperationStatus, error) {
+	return s.start("load", id)
+}
+func (s *adminService) UnloadModel(id string) (types.OperationStatus, error) {
+	return s.start("unload", id)
+}
+func (s *adminService) SwitchModel(id string) (types.OperationStatus, error) {
+	return s.start("switch", id)
+}
+func (s *adminService) Operation(id string) (types.OperationStatus, bool) {
+	op, ok := s.ops[id]
+	return op, ok
+}
+
+func (s *adminService) CancelOperation(id string) (types.OperationStatus, error) {
+	op, ok := s.ops[id]
+	if !ok {
+		return types.OperationStatus{}, manager.ErrOperationNotFound(id)
+	}
+	op.State = "canceled"
+	s.ops[id] = op
+	return op, nil
+}
+
+func TestAdmin_LoadUnloadSwitch(t *testing.T) {
+	svc := &adminService{}
+	h := NewMux(svc)
+
+	rec := postJSON(h, "/admin/models/vendor/m.gguf/load", "")
+	if rec.Code != http.StatusAccepted || rec.Header().Get("Location") != "/admin/ops/op-1" {
+		t.Fatalf("load: %d %s", rec.Code, rec.Body.String())
+	}
+	rec = postJSON(h, "/admin/models/m/unload", "")
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("unload: %d %s", rec.Code, rec.Body.String())
+	}
+	rec = postJSON(h, "/admin/switch", `{"model":"m"}`)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("switch: %d %s", rec.Code, rec.Body.String())
+	}
+	want := []string{"load:vendor/m.gguf", "unload:m", "switch:m"}
+	if len(svc.calls) != len(want) {
+		t.Fatalf("calls=%v", svc.calls)
+	}
+	for i := range want {
+		if svc.calls[i] != want[i] {
+			t.Fatalf("calls=%v", svc.calls)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/ops/op-1", nil))
+	var op types.OperationStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &op); err != nil || op.Kind != "switch" {
+		t.Fatalf("op lookup: %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = postJSON(h, "/admin/ops/op-1/cancel", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"state":"canceled"`) {
+		t.Fatalf("cancel: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec = postJSON(h, "/admin/ops/op-9/cancel", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown op cancel, got %d", rec.Code)
+	}
+}
+
+func TestAdmin_Errors(t *testing.T) {
+	h := NewMux(&adminService{})
+	if rec := postJSON(h, "/admin/models/missing/load", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown model, got %d", rec.Code)
+	}
+	if rec := postJSON(h, "/admin/models/m/reload", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown action, got %d", rec.Code)
+	}
+	if rec := postJSON(h, "/admin/switch", `{}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for missing model, got %d", rec.Code)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/ops/op-9", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown op, got %d", rec.Code)
+	}
+}
+
+func TestAdmin_NotMountedWithoutAdminService(t *testing.T) {
+	rec := postJSON(NewMux(&mockService{}), "/admin/switch", `{"model":"m"}`)
+	if rec.Code != http.StatusNotFound && rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected admin routes to be absent, got %d", rec.Code)
+	}
+}
+
+// reloadService is a mockService that also implements RegistryReloader.
+type reloadService struct {
+	mockService
+	err error
+}
+
+func (s *reloadService) ReloadRegistry() (types.RegistryReloadResponse, error) {
+	if s.err != nil {
+		return types.RegistryReloadResponse{}, s.err
+	}
+	return types.RegistryReloadResponse{Models: 2, Added: []string{"b"}, Removed: []string{}, Unloading: []string{}}, nil
+}
+
+func TestAdmin_RegistryReload(t *testing.T) {
+	rec := postJSON(NewMux(&reloadService{}), "/admin/registry/reload", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp types.RegistryReloadResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Models != 2 || len(resp.Added) != 1 || resp.Added[0] != "b" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+
+	rec = postJSON(NewMux(&reloadService{err: manager.ErrDependencyUnavailable("registry loader not configured")}), "/admin/registry/reload", "")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 without a loader, got %d", rec.Code)
+	}
+	rec = postJSON(NewMux(&reloadService{err: errors.New("bad manifest")}), "/admin/registry/reload", "")
+	if rec.Code != http.StatusInternalServerError || !strings.Contains(rec.Body.String(), "bad manifest") {
+		t.Fatalf("expected 500 with scan error, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := postJSON(NewMux(&mockService{}), "/admin/registry/reload", ""); rec.Code != http.StatusNotFound && rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected reload route to be absent, got %d", rec.Code)
+	}
+}
+
+func TestAdmin_Aliases(t *testing.T) {
+	m := manager.NewWithConfig(manager.ManagerConfig{Registry: []types.Model{{ID: "m1", Path: "m1.gguf"}, {ID: "m2", Path: "m2.gguf"}}})
+	m.SetInferenceAdapter(wordsAdapter{n: 2})
+	h := NewMux(m)
+
+	put := func(name, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/admin/aliases/"+name, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := put("coder", `{"targets":[{"model":"m2"}]}`); rec.Code != http.StatusOK {
+		t.Fatalf("put alias: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := put("bad", `{"targets":[{"model":"missing"}]}`); rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown target: %d", rec.Code)
+	}
+	if rec := put("m1", `{"targets":[{"model":"m2"}]}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("shadowing alias: %d", rec.Code)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/aliases", nil))
+	var list types.AliasListResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || len(list.Aliases) != 1 || list.Aliases[0].Targets[0].Model != "m2" {
+		t.Fatalf("list aliases: %s (%v)", rec.Body.String(), err)
+	}
+
+	// Requests to the alias report the resolved model in a header and in
+	// the final NDJSON line.
+	rec = postJSON(h, "/infer", `{"model":"coder","prompt":"hi"}`)
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Model") != "m2" {
+		t.Fatalf("infer via alias: %d X-Model=%q", rec.Code, rec.Header().Get("X-Model"))
+	}
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if !strings.Contains(lines[len(lines)-1], `"model":"m2"`) {
+		t.Fatalf("final line = %s", lines[len(lines)-1])
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/admin/aliases/coder", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("delete alias: %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/admin/aliases/coder", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("delete missing alias: %d", rec.Code)
+	}
+}
@@ -0,0 +1,213 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"modeld/internal/manager"
+	"modeld/pkg/types"
+)
+
+// API key scopes. Admin implies the other scopes.
+const (
+	ScopeInfer = "infer"
+	ScopeRead  = "read"
+	ScopeAdmin = "admin"
+)
+
+// APIKey is a client credential. Only the hex SHA-256 of the secret is
+// configured; the secret itself is never stored.
+type APIKey struct {
+	// ID names the key in logs, metrics and usage records.
+	ID string
+	// SHA256 is the lowercase hex SHA-256 digest of the secret.
+	SHA256 string
+	// Scopes granted to the key (infer, read, admin).
+	Scopes []string
+	// Models restricts the model IDs the key may use; empty allows all.
+	Models []string
+	// RateLimit overrides the default per-key limits (see SetRateLimits).
+	RateLimit *RateLimit
+}
+
+// allows reports whether the key grants scope.
+func (k *APIKey) allows(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsModel reports whether the key may use modelID.
+func (k *APIKey) allowsModel(modelID string) bool {
+	if k == nil || len(k.Models) == 0 {
+		return true
+	}
+	for _, id := range k.Models {
+		if id == modelID {
+			return true
+		}
+	}
+	return false
+}
+
+// apiKeys maps SHA-256 digests to keys. Authentication is enabled whenever
+// keys were configured, even if some entries were rejected as invalid.
+var (
+	authEnabled bool
+	apiKeys     map[[sha256.Size]byte]*APIKey
+)
+
+// HashAPIKey returns the hex SHA-256 digest of secret, as expected in
+// APIKey.SHA256.
+func HashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// SetAPIKeys installs the API keys and enables authentication when keys is
+// non-empty. Invalid entries (missing ID, malformed digest, unknown scope) are
+// skipped and reported in the returned error; authentication stays enabled so
+// a bad config fails closed.
+func SetAPIKeys(keys []APIKey) error {
+	authEnabled = len(keys) > 0
+	apiKeys = make(map[[sha256.Size]byte]*APIKey, len(keys))
+	var bad []string
+	for i := range keys {
+		k := keys[i]
+		if err := validateAPIKey(k); err != nil {
+			bad = append(bad, err.Error())
+			continue
+		}
+		var digest [sha256.Size]byte
+		_, _ = hex.Decode(digest[:], []byte(strings.ToLower(k.SHA256)))
+		k.Scopes = append([]string(nil), k.Scopes...)
+		k.Models = append([]string(nil), k.Models...)
+		apiKeys[digest] = &k
+	}
+	if len(bad) > 0 {
+		return fmt.Errorf("invalid api keys: %s", strings.Join(bad, "; "))
+	}
+	return nil
+}
+
+func validateAPIKey(k APIKey) error {
+	if strings.TrimSpace(k.ID) == "" {
+		return fmt.Errorf("key without id")
+	}
+	if b, err := hex.DecodeString(k.SHA256); err != nil || len(b) != sha256.Size {
+		return fmt.Errorf("key %q: sha256 must be 64 hex characters", k.ID)
+	}
+	if len(k.Scopes) == 0 {
+		return fmt.Errorf("key %q: no scopes", k.ID)
+	}
+	for _, s := range k.Scopes {
+		switch s {
+		case ScopeInfer, ScopeRead, ScopeAdmin:
+		default:
+			return fmt.Errorf("key %q: unknown scope %q", k.ID, s)
+		}
+	}
+	return nil
+}
+
+type authCtxKey struct{}
+
+// apiKeyFromContext returns the authenticated key, or nil when
+// authentication is disabled.
+func apiKeyFromContext(ctx context.Context) *APIKey {
+	k, _ := ctx.Value(authCtxKey{}).(*APIKey)
+	return k
+}
+
+// requestKeyID returns the authenticated key ID for logging, or "".
+func requestKeyID(r *http.Request) string {
+	if k := apiKeyFromContext(r.Context()); k != nil {
+		return k.ID
+	}
+	return ""
+}
+
+// bearerSecret extracts the API key from "Authorization: Bearer <key>" or
+// the X-API-Key header.
+func bearerSecret(r *http.Request) string {
+	if h := r.Header.Get("Authorization"); len(h) > 7 && strings.EqualFold(h[:7], "bearer ") {
+		return strings.TrimSpace(h[7:])
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
+
+// requireScope authenticates the request and checks that its key grants
+// scope. It responds 401 for a missing or unknown key and 403 for a key
+// without the scope. It is a no-op while no keys are configured.
+func requireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !authEnabled {
+				next.ServeHTTP(w, r)
+				return
+			}
+			secret := bearerSecret(r)
+			key := apiKeys[sha256.Sum256([]byte(secret))]
+			if secret == "" || key == nil {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="modeld"`)
+				writeJSONError(w, http.StatusUnauthorized, "missing or invalid api key")
+				return
+			}
+			setAccessLogKeyID(r.Context(), key.ID)
+			if !key.allows(scope) {
+				log.Printf("auth denied key_id=%s scope=%s path=%s", key.ID, scope, r.URL.Path)
+				writeJSONError(w, http.StatusForbidden, "api key lacks scope: "+scope)
+				return
+			}
+			ctx := context.WithValue(r.Context(), authCtxKey{}, key)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// authorizeModel writes a 403 and returns false when the request's key may
+// not use modelID.
+func authorizeModel(w http.ResponseWriter, r *http.Request, modelID string) bool {
+	if apiKeyFromContext(r.Context()).allowsModel(modelID) {
+		return true
+	}
+	writeJSONError(w, http.StatusForbidden, "model not allowed: "+modelID)
+	return false
+}
+
+// visibleModels filters models to those the request's key may use.
+func visibleModels(r *http.Request, models []types.Model) []types.Model {
+	key := apiKeyFromContext(r.Context())
+	if key == nil || len(key.Models) == 0 {
+		return models
+	}
+	out := models[:0:0]
+	for _, m := range models {
+		if key.allowsModel(m.ID) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// authContext carries the key's identity and model allow-list into the
+// manager: the key ID becomes the scheduling tenant.
+func authContext(ctx context.Context, r *http.Request) context.Context {
+	key := apiKeyFromContext(r.Context())
+	if key == nil {
+		return ctx
+	}
+	ctx = manager.WithTenant(ctx, key.ID)
+	if len(key.Models) > 0 {
+		ctx = manager.WithAllowedModels(ctx, key.Models)
+	}
+	return ctx
+}
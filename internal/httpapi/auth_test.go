@@ -0,0 +1,160 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"modeld/internal/manager"
+	"modeld/pkg/types"
+)
+
+// setTestKeys installs API keys for one test and disables auth afterwards.
+func setTestKeys(t *testing.T, keys ...APIKey) {
+	t.Helper()
+	if err := SetAPIKeys(keys); err != nil {
+		t.Fatalf("SetAPIKeys: %v", err)
+	}
+	t.Cleanup(func() { _ = SetAPIKeys(nil) })
+}
+
+func authRequest(h http.Handler, method, path, secret, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if secret != "" {
+		req.Header.Set("Authorization", "Bearer "+secret)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAuth_DisabledWithoutKeys(t *testing.T) {
+	h := NewMux(&mockService{})
+	if rec := authRequest(h, http.MethodGet, "/status", "", ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected open access without keys, got %d", rec.Code)
+	}
+}
+
+func TestAuth_ScopesAndStatusCodes(t *testing.T) {
+	setTestKeys(t,
+		APIKey{ID: "reader", SHA256: HashAPIKey("r-secret"), Scopes: []string{ScopeRead}},
+		APIKey{ID: "ops", SHA256: HashAPIKey("a-secret"), Scopes: []string{ScopeAdmin}},
+	)
+	h := NewMux(&adminService{})
+
+	rec := authRequest(h, http.MethodGet, "/status", "", "")
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+		t.Fatalf("expected 401 with challenge, got %d", rec.Code)
+	}
+	var er types.ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &er); err != nil || er.Code != http.StatusUnauthorized {
+		t.Fatalf("expected JSON error body, got %s", rec.Body.String())
+	}
+	if rec := authRequest(h, http.MethodGet, "/status", "wrong", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for unknown key, got %d", rec.Code)
+	}
+	if rec := authRequest(h, http.MethodGet, "/status", "r-secret", ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected read access, got %d", rec.Code)
+	}
+	if rec := authRequest(h, http.MethodPost, "/infer", "r-secret", `{"prompt":"hi"}`); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for missing infer scope, got %d", rec.Code)
+	}
+	if rec := authRequest(h, http.MethodPost, "/admin/models/m/load", "r-secret", ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for missing admin scope, got %d", rec.Code)
+	}
+	// Admin implies the other scopes.
+	if rec := authRequest(h, http.MethodPost, "/infer", "a-secret", `{"prompt":"hi"}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected admin key to infer, got %d", rec.Code)
+	}
+	if rec := authRequest(h, http.MethodPost, "/admin/models/m/load", "a-secret", ""); rec.Code != http.StatusAccepted {
+		t.Fatalf("expected admin load, got %d", rec.Code)
+	}
+	// X-API-Key is accepted as an alternative header.
+	req := httptest.NewRequest(http.MethodGet, "/models", nil)
+	req.Header.Set("X-API-Key", "r-secret")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected X-API-Key to authenticate, got %d", rec.Code)
+	}
+	// Probes and metrics stay open.
+	for _, p := range []string{"/healthz", "/metrics"} {
+		if rec := authRequest(h, http.MethodGet, p, "", ""); rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200 without key, got %d", p, rec.Code)
+		}
+	}
+}
+
+func TestAuth_AccessLogIncludesKeyID(t *testing.T) {
+	setTestKeys(t, APIKey{ID: "reader", SHA256: HashAPIKey("r-secret"), Scopes: []string{ScopeRead}})
+	var buf bytes.Buffer
+	prev := zlog
+	SetLogger(zerolog.New(&buf))
+	t.Cleanup(func() { zlog = prev })
+	h := NewMux(&adminService{})
+
+	// A read route, and an admin route the key is denied.
+	for path, method := range map[string]string{"/status": http.MethodGet, "/admin/models/m/load": http.MethodPost} {
+		buf.Reset()
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer r-secret")
+		req.Header.Set("X-Log-Level", "info")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+		if line := buf.String(); !strings.Contains(line, `"message":"request"`) || !strings.Contains(line, `"key_id":"reader"`) {
+			t.Fatalf("%s: access log = %q", path, line)
+		}
+	}
+}
+
+func TestAuth_ModelAllowList(t *testing.T) {
+	setTestKeys(t, APIKey{ID: "team-a", SHA256: HashAPIKey("s"), Scopes: []string{ScopeInfer, ScopeAdmin}, Models: []string{"a"}})
+
+	svc := &adminService{mockService: mockService{models: []types.Model{{ID: "a"}, {ID: "b"}}}}
+	h := NewMux(svc)
+	rec := authRequest(h, http.MethodGet, "/v1/models", "s", "")
+	if !strings.Contains(rec.Body.String(), `"a"`) || strings.Contains(rec.Body.String(), `"b"`) {
+		t.Fatalf("expected only allowed models: %s", rec.Body.String())
+	}
+	if rec := authRequest(h, http.MethodGet, "/v1/models/b", "s", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected hidden model lookup to 404, got %d", rec.Code)
+	}
+	if rec := authRequest(h, http.MethodPost, "/admin/switch", "s", `{"model":"b"}`); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 switching to disallowed model, got %d", rec.Code)
+	}
+	if len(svc.calls) != 0 {
+		t.Fatalf("disallowed switch reached the service: %v", svc.calls)
+	}
+
+	// The manager enforces the list for inference, including default-model
+	// resolution.
+	m := manager.NewWithConfig(manager.ManagerConfig{Registry: []types.Model{{ID: "a", Path: "a.gguf"}, {ID: "b", Path: "b.gguf"}}, DefaultModel: "b"})
+	h = NewMux(m)
+	if rec := authRequest(h, http.MethodPost, "/infer", "s", `{"prompt":"hi"}`); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for default model outside allow-list, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := authRequest(h, http.MethodPost, "/v1/chat/completions", "s", `{"model":"b","messages":[{"role":"user","content":"hi"}]}`); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 on chat completions, got %d", rec.Code)
+	}
+}
+
+func TestSetAPIKeys_InvalidFailsClosed(t *testing.T) {
+	err := SetAPIKeys([]APIKey{{ID: "bad", SHA256: "nothex", Scopes: []string{ScopeRead}}})
+	t.Cleanup(func() { _ = SetAPIKeys(nil) })
+	if err == nil {
+		t.Fatalf("expected error for malformed digest")
+	}
+	if rec := authRequest(NewMux(&mockService{}), http.MethodGet, "/status", "nothex", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected auth to stay enabled, got %d", rec.Code)
+	}
+	if err := SetAPIKeys([]APIKey{{ID: "x", SHA256: HashAPIKey("k"), Scopes: []string{"write"}}}); err == nil {
+		t.Fatalf("expected error for unknown scope")
+	}
+}
@@ -59,6 +59,12 @@ type Options struct {
 	CORSAllowedMethods []string
 	CORSAllowedHeaders []string
 
+	// Authentication (nil leaves the current keys; see SetAPIKeys)
+	APIKeys []APIKey
+	// Rate limits (applied when either is enabled; see SetRateLimits)
+	RateLimitPerKey RateLimit
+	RateLimitPerIP  RateLimit
+
 	// Optional integrations
 	Logger      *zerolog.Logger
 	BaseContext context.Context
@@ -0,0 +1,100 @@
+package httpapi
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"modeld/internal/manager"
+	"modeld/pkg/types"
+)
+
+// EventSource is implemented by services that expose a live manager event
+// stream. It is optional: NewMux only mounts GET /events when the Service
+// passed to it also implements EventSource.
+type EventSource interface {
+	SubscribeEvents(match func(manager.Event) bool) *manager.Subscription
+}
+
+// eventsHeartbeat is how often a comment line is sent on idle streams so
+// proxies and clients keep the connection open.
+var eventsHeartbeat = 15 * time.Second
+
+// splitFilter parses a comma-separated query value into a set; empty means
+// no filtering.
+func splitFilter(v string) map[string]bool {
+	set := map[string]bool{}
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			set[s] = true
+		}
+	}
+	return set
+}
+
+// getEvents streams manager events as Server-Sent Events.
+// @Summary Manager event stream
+// @Description Streams manager lifecycle events (ensure_start, ensure_ready, unload_done, ...) as Server-Sent Events. Each event's data is a ManagerEvent. Optional comma-separated filters: model and event. If the client falls behind, events are dropped and an events_dropped event reports how many.
+// @Tags events
+// @Produce text/event-stream
+// @Param model query string false "Only events for these model IDs (comma-separated)"
+// @Param event query string false "Only these event names (comma-separated)"
+// @Success 200 {object} types.ManagerEvent
+// @Router /events [get]
+func getEvents(src EventSource) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		models := splitFilter(r.URL.Query().Get("model"))
+		names := splitFilter(r.URL.Query().Get("event"))
+		key := apiKeyFromContext(r.Context())
+		sub := src.SubscribeEvents(func(e manager.Event) bool {
+			if e.ModelID != "" && !key.allowsModel(e.ModelID) {
+				return false
+			}
+			return (len(models) == 0 || models[e.ModelID]) && (len(names) == 0 || names[e.Name])
+		})
+		defer sub.Close()
+
+		// Long-lived stream: lift the server's write deadline for this response.
+		_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+		sse := newSSEWriter(w)
+		sse.start()
+		if _, err := fmt.Fprint(w, ": connected\n\n"); err != nil {
+			return
+		}
+		if sse.flush != nil {
+			sse.flush()
+		}
+
+		ctx := r.Context()
+		heartbeat := time.NewTicker(eventsHeartbeat)
+		defer heartbeat.Stop()
+		var reported uint64
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-serverBaseCtx.Done():
+				return
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+					return
+				}
+				if sse.flush != nil {
+					sse.flush()
+				}
+			case e := <-sub.C():
+				if d := sub.Dropped(); d > reported {
+					notice := types.ManagerEvent{Name: "events_dropped", TimeUnixMs: time.Now().UnixMilli(), Fields: map[string]any{"count": d - reported}}
+					reported = d
+					if err := sse.data(notice); err != nil {
+						return
+					}
+				}
+				if err := sse.data(types.ManagerEvent{Name: e.Name, ModelID: e.ModelID, TimeUnixMs: e.Time.UnixMilli(), Fields: e.Fields}); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
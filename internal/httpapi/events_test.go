@@ -0,0 +1,80 @@
+package httpapi
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"modeld/internal/manager"
+	"modeld/pkg/types"
+)
+
+// eventService is a mockService backed by a real Broadcaster.
+type eventService struct {
+	mockService
+	b *manager.Broadcaster
+}
+
+func (s *eventService) SubscribeEvents(match func(manager.Event) bool) *manager.Subscription {
+	return s.b.Subscribe(match)
+}
+
+func TestEvents_SSEWithFilters(t *testing.T) {
+	svc := &eventService{b: manager.NewBroadcaster(8)}
+	srv := httptest.NewServer(NewMux(svc))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/events?model=a&event=ensure_ready,unload_done")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Fatalf("content-type=%s", ct)
+	}
+	rd := bufio.NewReader(resp.Body)
+	// Wait for the connected comment so the subscription is registered.
+	if line, _ := rd.ReadString('\n'); !strings.HasPrefix(line, ": connected") {
+		t.Fatalf("unexpected first line: %q", line)
+	}
+
+	svc.b.Publish(manager.Event{Name: "ensure_start", ModelID: "a"})
+	svc.b.Publish(manager.Event{Name: "ensure_ready", ModelID: "b"})
+	svc.b.Publish(manager.Event{Name: "ensure_ready", ModelID: "a", Fields: map[string]any{"dur_ms": 5}})
+
+	done := make(chan types.ManagerEvent, 1)
+	go func() {
+		for {
+			line, err := rd.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if strings.HasPrefix(line, "data: ") {
+				var ev types.ManagerEvent
+				_ = json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &ev)
+				done <- ev
+				return
+			}
+		}
+	}()
+	select {
+	case ev := <-done:
+		if ev.Name != "ensure_ready" || ev.ModelID != "a" || ev.TimeUnixMs == 0 || ev.Fields["dur_ms"] != float64(5) {
+			t.Fatalf("unexpected event: %+v", ev)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for event")
+	}
+}
+
+func TestEvents_NotMountedWithoutEventSource(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewMux(&mockService{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+}
@@ -2,6 +2,7 @@ package httpapi
 
 import (
 	"bytes"
+	"context"
 	"log"
 	"net/http"
 	"os"
@@ -99,10 +100,13 @@ func logInferStart(lvl LogLevel, r *http.Request, model string) {
 		if rid := middleware.GetReqID(r.Context()); rid != "" {
 			z = z.Str("request_id", rid)
 		}
+		if kid := requestKeyID(r); kid != "" {
+			z = z.Str("key_id", kid)
+		}
 		z.Msg("infer start")
 		return
 	}
-	log.Printf("infer start path=%s model=%s", r.URL.Path, model)
+	log.Printf("infer start path=%s model=%s%s", r.URL.Path, model, keyIDField(r))
 }
 
 // logInferEnd emits a standardized "infer end" message with status and optional error.
@@ -115,15 +119,72 @@ func logInferEnd(lvl LogLevel, start time.Time, r *http.Request, status string,
 		if rid := middleware.GetReqID(r.Context()); rid != "" {
 			z = z.Str("request_id", rid)
 		}
+		if kid := requestKeyID(r); kid != "" {
+			z = z.Str("key_id", kid)
+		}
 		if err != nil {
 			z = z.Err(err)
 		}
 		z.Msg("infer end")
 		return
 	}
+	kid := keyIDField(r)
 	if err != nil {
-		log.Printf("infer end status=%s dur=%s err=%v", status, time.Since(start), err)
+		log.Printf("infer end status=%s dur=%s%s err=%v", status, time.Since(start), kid, err)
 	} else {
-		log.Printf("infer end status=%s dur=%s", status, time.Since(start))
+		log.Printf("infer end status=%s dur=%s%s", status, time.Since(start), kid)
+	}
+}
+
+// keyIDField returns " key_id=<id>" for authenticated requests, or "".
+func keyIDField(r *http.Request) string {
+	if id := requestKeyID(r); id != "" {
+		return " key_id=" + id
+	}
+	return ""
+}
+
+type accessLogKey struct{}
+
+// accessLogEntry collects request details known only to inner handlers.
+type accessLogEntry struct {
+	keyID string
+}
+
+// setAccessLogKeyID records the authenticated key ID for the access log.
+func setAccessLogKeyID(ctx context.Context, id string) {
+	if e, ok := ctx.Value(accessLogKey{}).(*accessLogEntry); ok {
+		e.keyID = id
 	}
 }
+
+// accessLog emits one "request" message per request when LevelInfo or
+// higher, including the key ID for authenticated requests.
+func accessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requestLogLevel(r) < LevelInfo {
+			next.ServeHTTP(w, r)
+			return
+		}
+		entry := &accessLogEntry{}
+		sr := &statusRecorder{ResponseWriter: w, status: 200}
+		start := time.Now()
+		next.ServeHTTP(sr, r.WithContext(context.WithValue(r.Context(), accessLogKey{}, entry)))
+		if zlog != nil {
+			z := zlog.Info().Str("method", r.Method).Str("path", r.URL.Path).Int("status", sr.status).Dur("dur", time.Since(start))
+			if rid := middleware.GetReqID(r.Context()); rid != "" {
+				z = z.Str("request_id", rid)
+			}
+			if entry.keyID != "" {
+				z = z.Str("key_id", entry.keyID)
+			}
+			z.Msg("request")
+			return
+		}
+		kid := ""
+		if entry.keyID != "" {
+			kid = " key_id=" + entry.keyID
+		}
+		log.Printf("request method=%s path=%s status=%d dur=%s%s", r.Method, r.URL.Path, sr.status, time.Since(start), kid)
+	})
+}
@@ -0,0 +1,108 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"modeld/internal/manager"
+	"modeld/pkg/types"
+)
+
+// LogService is implemented by services that keep the output of spawned
+// runtimes. It is optional: NewMux only mounts the instance log route when
+// the Service passed to it also implements LogService.
+type LogService interface {
+	InstanceLogs(modelID string, tail int) ([]types.LogLine, error)
+	FollowInstanceLogs(modelID string, tail int) ([]types.LogLine, *manager.LogSubscription, error)
+}
+
+// getAdminInstanceLogs returns or streams the runtime output of a model.
+// @Summary Runtime logs of an instance
+// @Description Returns the buffered stdout/stderr lines of the llama-server spawned for a model (spawn mode) as NDJSON, oldest first. With follow=1 the response stays open and streams new lines as they are written; lines are dropped if the client falls behind.
+// @Tags admin
+// @Produce application/x-ndjson
+// @Param id path string true "Model ID"
+// @Param tail query int false "Only the last N lines (default: all buffered)"
+// @Param follow query bool false "Keep streaming new lines"
+// @Success 200 {object} types.LogLine
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 503 {object} types.ErrorResponse
+// @Router /admin/instances/{id}/logs [get]
+func getAdminInstanceLogs(svc LogService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Wildcard route: model IDs may contain slashes.
+		id, ok := strings.CutSuffix(chi.URLParam(r, "*"), "/logs")
+		if !ok || id == "" {
+			writeJSONError(w, http.StatusNotFound, "not found")
+			return
+		}
+		if !authorizeModel(w, r, id) {
+			return
+		}
+		q := r.URL.Query()
+		tail := 0
+		if v := q.Get("tail"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				writeJSONError(w, http.StatusBadRequest, "tail must be a non-negative integer")
+				return
+			}
+			tail = n
+		}
+		follow, _ := strconv.ParseBool(q.Get("follow"))
+
+		var lines []types.LogLine
+		var sub *manager.LogSubscription
+		var err error
+		if follow {
+			lines, sub, err = svc.FollowInstanceLogs(id, tail)
+		} else {
+			lines, err = svc.InstanceLogs(id, tail)
+		}
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case manager.IsModelNotFound(err):
+				status = http.StatusNotFound
+			case manager.IsDependencyUnavailable(err):
+				status = http.StatusServiceUnavailable
+			}
+			writeJSONError(w, status, err.Error())
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		enc := json.NewEncoder(w)
+		for _, l := range lines {
+			if err := enc.Encode(l); err != nil {
+				break
+			}
+		}
+		if sub == nil {
+			return
+		}
+		defer sub.Close()
+		// Long-lived stream: lift the server's write deadline for this response.
+		rc := http.NewResponseController(w)
+		_ = rc.SetWriteDeadline(time.Time{})
+		_ = rc.Flush()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-serverBaseCtx.Done():
+				return
+			case l := <-sub.C():
+				if err := enc.Encode(l); err != nil {
+					return
+				}
+				_ = rc.Flush()
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"modeld/internal/manager"
+	"modeld/pkg/types"
+)
+
+// logService is a mockService that also implements LogService.
+type logService struct {
+	mockService
+	lines   []types.LogLine
+	gotID   string
+	gotTail int
+}
+
+func (s *logService) InstanceLogs(modelID string, tail int) ([]types.LogLine, error) {
+	s.gotID, s.gotTail = modelID, tail
+	switch modelID {
+	case "missing":
+		return nil, manager.ErrModelNotFound(modelID)
+	case "remote":
+		return nil, manager.ErrDependencyUnavailable("runtime logs are only kept in spawn mode")
+	}
+	if tail > 0 && tail < len(s.lines) {
+		return s.lines[len(s.lines)-tail:], nil
+	}
+	return s.lines, nil
+}
+
+func (s *logService) FollowInstanceLogs(modelID string, tail int) ([]types.LogLine, *manager.LogSubscription, error) {
+	lines, err := s.InstanceLogs(modelID, tail)
+	return lines, nil, err
+}
+
+func TestAdmin_InstanceLogs(t *testing.T) {
+	svc := &logService{lines: []types.LogLine{
+		{PID: 7, Stream: "stderr", Line: "loading"},
+		{PID: 7, Stream: "stdout", Line: "listening"},
+	}}
+	h := NewMux(svc)
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := get("/admin/instances/vendor/m.gguf/logs?tail=1")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("logs: %d %s", rec.Code, rec.Body.String())
+	}
+	if svc.gotID != "vendor/m.gguf" || svc.gotTail != 1 {
+		t.Fatalf("service got id=%q tail=%d", svc.gotID, svc.gotTail)
+	}
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	var l types.LogLine
+	if len(lines) != 1 || json.Unmarshal([]byte(lines[0]), &l) != nil || l.Line != "listening" || l.PID != 7 {
+		t.Fatalf("body=%s", rec.Body.String())
+	}
+	if rec := get("/admin/instances/m/logs?follow=1"); rec.Code != http.StatusOK || strings.Count(rec.Body.String(), "\n") != 2 {
+		t.Fatalf("follow backlog: %d %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := get("/admin/instances/m/logs?tail=-1"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("negative tail: %d", rec.Code)
+	}
+	if rec := get("/admin/instances/missing/logs"); rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown model: %d", rec.Code)
+	}
+	if rec := get("/admin/instances/remote/logs"); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("not spawn mode: %d", rec.Code)
+	}
+	if rec := get("/admin/instances/m/stats"); rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown action: %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	NewMux(&mockService{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/instances/m/logs", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected logs route to be absent, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,483 @@
+package httpapi
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"modeld/internal/manager"
+	"modeld/pkg/types"
+)
+
+// OpenAI-compatible surface. Requests are translated into types.InferRequest
+// and executed through Service.Infer, so they share the manager's ensure,
+// VRAM budgeting and per-instance admission path with POST /infer. Chat
+// messages are passed through and rendered by the manager's chat templates.
+// The NDJSON lines produced by Infer are re-encoded as OpenAI JSON or SSE
+// chunks.
+
+// ndjsonLine is the union of the token and final lines emitted by Infer.
+type ndjsonLine struct {
+	Token        *string                `json:"token"`
+	Done         bool                   `json:"done"`
+	Model        string                 `json:"model"`
+	Content      string                 `json:"content"`
+	FinishReason string                 `json:"finish_reason"`
+	Usage        *types.CompletionUsage `json:"usage"`
+}
+
+// ndjsonDecoder is an io.Writer that splits the NDJSON stream written by
+// Service.Infer into lines and dispatches token and final lines to callbacks.
+// Lines that are neither are ignored.
+type ndjsonDecoder struct {
+	buf     []byte
+	onToken func(tok string) error
+	onDone  func(line ndjsonLine) error
+	// model is the registry ID the request was routed to, reported before
+	// output starts or, failing that, by the final line.
+	model string
+}
+
+// modelOr returns the resolved model ID, or requested when none is known.
+func (d *ndjsonDecoder) modelOr(requested string) string {
+	if d.model != "" {
+		return d.model
+	}
+	return requested
+}
+
+func (d *ndjsonDecoder) Write(p []byte) (int, error) {
+	d.buf = append(d.buf, p...)
+	for {
+		idx := bytes.IndexByte(d.buf, '\n')
+		if idx < 0 {
+			break
+		}
+		raw := bytes.TrimSpace(d.buf[:idx])
+		d.buf = d.buf[idx+1:]
+		if len(raw) == 0 {
+			continue
+		}
+		var line ndjsonLine
+		if err := json.Unmarshal(raw, &line); err != nil {
+			continue
+		}
+		switch {
+		case line.Done:
+			if d.model == "" {
+				d.model = line.Model
+			}
+			if d.onDone != nil {
+				if err := d.onDone(line); err != nil {
+					return 0, err
+				}
+			}
+		case line.Token != nil:
+			if d.onToken != nil {
+				if err := d.onToken(*line.Token); err != nil {
+					return 0, err
+				}
+			}
+		}
+	}
+	return len(p), nil
+}
+
+// sseWriter writes Server-Sent Events to the response and tracks whether the
+// stream has started (after which HTTP errors can no longer be sent).
+type sseWriter struct {
+	w       http.ResponseWriter
+	flush   func()
+	started bool
+}
+
+func newSSEWriter(w http.ResponseWriter) *sseWriter {
+	s := &sseWriter{w: w}
+	if f, ok := w.(http.Flusher); ok {
+		s.flush = f.Flush
+	}
+	return s
+}
+
+// start sends the event-stream headers once.
+func (s *sseWriter) start() {
+	if s.started {
+		return
+	}
+	s.started = true
+	h := s.w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no")
+	s.w.WriteHeader(http.StatusOK)
+}
+
+// data sends v as the JSON data of an unnamed event.
+func (s *sseWriter) data(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.raw(b)
+}
+
+// raw sends b verbatim as event data.
+func (s *sseWriter) raw(b []byte) error {
+	s.start()
+	if _, err := fmt.Fprintf(s.w, "data: %s\n\n", b); err != nil {
+		return err
+	}
+	if s.flush != nil {
+		s.flush()
+	}
+	return nil
+}
+
+// decodeJSONRequest enforces the JSON content type and size limit and
+// decodes the body into v, writing a 4xx error and returning false on failure.
+func decodeJSONRequest(w http.ResponseWriter, r *http.Request, v any) bool {
+	ct := r.Header.Get("Content-Type")
+	if ct == "" || !strings.HasPrefix(strings.ToLower(ct), "application/json") {
+		writeJSONError(w, http.StatusUnsupportedMediaType, "Content-Type must be application/json")
+		return false
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON body")
+		return false
+	}
+	return true
+}
+
+// newCompletionID returns a random OpenAI-style object ID with the given prefix.
+func newCompletionID(prefix string) string {
+	var b [12]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return prefix + "-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return prefix + "-" + hex.EncodeToString(b[:])
+}
+
+// usageOrZero dereferences u, returning zero usage when absent.
+func usageOrZero(u *types.CompletionUsage) types.CompletionUsage {
+	if u == nil {
+		return types.CompletionUsage{}
+	}
+	return *u
+}
+
+// finishReasonOrStop defaults an unreported finish reason to "stop".
+func finishReasonOrStop(s string) string {
+	if s == "" {
+		return "stop"
+	}
+	return s
+}
+
+// writeOpenAIJSON encodes a non-streaming OpenAI response.
+func writeOpenAIJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "failed to encode response")
+	}
+}
+
+// serveOpenAIInfer runs req through svc.Infer, feeding the NDJSON output to
+// dec, with the same logging, timeout and error mapping as POST /infer. When
+// sse is non-nil and the stream has already started, failures are reported
+// in-band as a final data event. It returns true if inference succeeded.
+func serveOpenAIInfer(w http.ResponseWriter, r *http.Request, svc Service, req types.InferRequest, dec *ndjsonDecoder, sse *sseWriter) bool {
+	lvl := requestLogLevel(r)
+	logInferStart(lvl, r, req.Model)
+	start := time.Now()
+	ctx, cancel := inferContext(w, r)
+	defer cancel()
+	ctx = manager.WithResolvedModelHook(ctx, func(modelID string) { dec.model = modelID })
+	err := svc.Infer(ctx, req, dec, nil)
+	if err == nil {
+		logInferEnd(lvl, start, r, "200", nil)
+		return true
+	}
+	// If context was canceled (client disconnect), just return.
+	if r.Context().Err() != nil || serverBaseCtx.Err() != nil {
+		return false
+	}
+	if sse != nil && sse.started {
+		_ = sse.data(types.ErrorResponse{Error: err.Error(), Code: inferErrorStatus(err)})
+		logInferEnd(lvl, start, r, "200", err)
+		return false
+	}
+	status := writeInferError(w, err)
+	logInferEnd(lvl, start, r, strconv.Itoa(status), err)
+	return false
+}
+
+// postChatCompletions implements the OpenAI chat completions API.
+// @Summary OpenAI-compatible chat completions
+// @Description Generates a chat completion. With stream=true the response is a Server-Sent Events stream of chat.completion.chunk objects terminated by "data: [DONE]".
+// @Tags openai
+// @Accept json
+// @Produce json
+// @Produce text/event-stream
+// @Param request body types.ChatCompletionRequest true "Chat completion request"
+// @Param X-Priority header string false "Scheduling priority: interactive|normal|batch"
+// @Success 200 {object} types.ChatCompletionResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 403 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 415 {object} types.ErrorResponse
+// @Failure 429 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /v1/chat/completions [post]
+func postChatCompletions(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req types.ChatCompletionRequest
+		if !decodeJSONRequest(w, r, &req) {
+			return
+		}
+		if len(req.Messages) == 0 {
+			writeJSONError(w, http.StatusBadRequest, "messages is required")
+			return
+		}
+		if err := manager.ValidateChatMessages(req.Messages); err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		maxTokens := req.MaxTokens
+		if req.MaxCompletionTokens > 0 {
+			maxTokens = req.MaxCompletionTokens
+		}
+		inferReq := types.InferRequest{
+			Model:         req.Model,
+			Messages:      req.Messages,
+			Stream:        req.Stream,
+			MaxTokens:     maxTokens,
+			Temperature:   req.Temperature,
+			TopP:          req.TopP,
+			TopK:          req.TopK,
+			Stop:          req.Stop,
+			Seed:          req.Seed,
+			RepeatPenalty: req.RepeatPenalty,
+		}
+		id := newCompletionID("chatcmpl")
+		created := time.Now().Unix()
+
+		if !req.Stream {
+			var sb strings.Builder
+			var final ndjsonLine
+			dec := &ndjsonDecoder{
+				onToken: func(tok string) error { sb.WriteString(tok); return nil },
+				onDone:  func(line ndjsonLine) error { final = line; return nil },
+			}
+			if !serveOpenAIInfer(w, r, svc, inferReq, dec, nil) {
+				return
+			}
+			content := final.Content
+			if content == "" {
+				content = sb.String()
+			}
+			writeOpenAIJSON(w, types.ChatCompletionResponse{
+				ID:      id,
+				Object:  "chat.completion",
+				Created: created,
+				Model:   dec.modelOr(req.Model),
+				Choices: []types.ChatCompletionChoice{{
+					Index:        0,
+					Message:      types.ChatMessage{Role: "assistant", Content: content},
+					FinishReason: finishReasonOrStop(final.FinishReason),
+				}},
+				Usage: usageOrZero(final.Usage),
+			})
+			return
+		}
+
+		sse := newSSEWriter(w)
+		dec := &ndjsonDecoder{}
+		chunk := func(delta types.ChatCompletionDelta, finish *string) types.ChatCompletionChunk {
+			return types.ChatCompletionChunk{
+				ID:      id,
+				Object:  "chat.completion.chunk",
+				Created: created,
+				Model:   dec.modelOr(req.Model),
+				Choices: []types.ChatCompletionChunkChoice{{Index: 0, Delta: delta, FinishReason: finish}},
+			}
+		}
+		dec.onToken = func(tok string) error {
+			delta := types.ChatCompletionDelta{Content: tok}
+			if !sse.started {
+				delta.Role = "assistant"
+			}
+			return sse.data(chunk(delta, nil))
+		}
+		dec.onDone = func(line ndjsonLine) error {
+			finish := finishReasonOrStop(line.FinishReason)
+			if err := sse.data(chunk(types.ChatCompletionDelta{}, &finish)); err != nil {
+				return err
+			}
+			if req.StreamOptions != nil && req.StreamOptions.IncludeUsage {
+				u := usageOrZero(line.Usage)
+				c := chunk(types.ChatCompletionDelta{}, nil)
+				c.Choices = []types.ChatCompletionChunkChoice{}
+				c.Usage = &u
+				if err := sse.data(c); err != nil {
+					return err
+				}
+			}
+			return sse.raw([]byte("[DONE]"))
+		}
+		serveOpenAIInfer(w, r, svc, inferReq, dec, sse)
+	}
+}
+
+// postCompletions implements the OpenAI (legacy) text completions API.
+// @Summary OpenAI-compatible text completions
+// @Description Generates a text completion for a single prompt. With stream=true the response is a Server-Sent Events stream of text_completion objects terminated by "data: [DONE]".
+// @Tags openai
+// @Accept json
+// @Produce json
+// @Produce text/event-stream
+// @Param request body types.CompletionRequest true "Completion request"
+// @Param X-Priority header string false "Scheduling priority: interactive|normal|batch"
+// @Success 200 {object} types.CompletionResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 403 {object} types.ErrorResponse
+// @Failure 404 {object} types.ErrorResponse
+// @Failure 415 {object} types.ErrorResponse
+// @Failure 429 {object} types.ErrorResponse
+// @Failure 500 {object} types.ErrorResponse
+// @Router /v1/completions [post]
+func postCompletions(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req types.CompletionRequest
+		if !decodeJSONRequest(w, r, &req) {
+			return
+		}
+		if len(req.Prompt) > 1 {
+			writeJSONError(w, http.StatusBadRequest, "only a single prompt per request is supported")
+			return
+		}
+		if len(req.Prompt) == 0 || strings.TrimSpace(req.Prompt[0]) == "" {
+			writeJSONError(w, http.StatusBadRequest, "prompt is required")
+			return
+		}
+		inferReq := types.InferRequest{
+			Model:         req.Model,
+			Prompt:        req.Prompt[0],
+			Stream:        req.Stream,
+			MaxTokens:     req.MaxTokens,
+			Temperature:   req.Temperature,
+			TopP:          req.TopP,
+			TopK:          req.TopK,
+			Stop:          req.Stop,
+			Seed:          req.Seed,
+			RepeatPenalty: req.RepeatPenalty,
+		}
+		id := newCompletionID("cmpl")
+		created := time.Now().Unix()
+		dec := &ndjsonDecoder{}
+		completion := func(text string, finish *string) types.CompletionResponse {
+			return types.CompletionResponse{
+				ID:      id,
+				Object:  "text_completion",
+				Created: created,
+				Model:   dec.modelOr(req.Model),
+				Choices: []types.CompletionChoice{{Index: 0, Text: text, FinishReason: finish}},
+			}
+		}
+
+		if !req.Stream {
+			var sb strings.Builder
+			var final ndjsonLine
+			dec.onToken = func(tok string) error { sb.WriteString(tok); return nil }
+			dec.onDone = func(line ndjsonLine) error { final = line; return nil }
+			if !serveOpenAIInfer(w, r, svc, inferReq, dec, nil) {
+				return
+			}
+			text := final.Content
+			if text == "" {
+				text = sb.String()
+			}
+			finish := finishReasonOrStop(final.FinishReason)
+			resp := completion(text, &finish)
+			u := usageOrZero(final.Usage)
+			resp.Usage = &u
+			writeOpenAIJSON(w, resp)
+			return
+		}
+
+		sse := newSSEWriter(w)
+		dec.onToken = func(tok string) error { return sse.data(completion(tok, nil)) }
+		dec.onDone = func(line ndjsonLine) error {
+			finish := finishReasonOrStop(line.FinishReason)
+			if err := sse.data(completion("", &finish)); err != nil {
+				return err
+			}
+			if req.StreamOptions != nil && req.StreamOptions.IncludeUsage {
+				u := usageOrZero(line.Usage)
+				c := completion("", nil)
+				c.Choices = []types.CompletionChoice{}
+				c.Usage = &u
+				if err := sse.data(c); err != nil {
+					return err
+				}
+			}
+			return sse.raw([]byte("[DONE]"))
+		}
+		serveOpenAIInfer(w, r, svc, inferReq, dec, sse)
+	}
+}
+
+// openAIModel converts a registry model into OpenAI's model object.
+func openAIModel(m types.Model) types.OpenAIModel {
+	return types.OpenAIModel{ID: m.ID, Object: "model", OwnedBy: "modeld"}
+}
+
+// getOpenAIModels lists registry models in OpenAI's list format.
+// @Summary OpenAI-compatible model list
+// @Description Returns the models discovered by the registry as {object:"list",data:[...]}.
+// @Tags openai
+// @Produce json
+// @Success 200 {object} types.OpenAIModelList
+// @Router /v1/models [get]
+func getOpenAIModels(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		models := visibleModels(r, svc.ListModels())
+		resp := types.OpenAIModelList{Object: "list", Data: make([]types.OpenAIModel, 0, len(models))}
+		for _, m := range models {
+			resp.Data = append(resp.Data, openAIModel(m))
+		}
+		writeOpenAIJSON(w, resp)
+	}
+}
+
+// getOpenAIModel returns a single registry model in OpenAI's format.
+// @Summary OpenAI-compatible model lookup
+// @Tags openai
+// @Produce json
+// @Param id path string true "Model ID"
+// @Success 200 {object} types.OpenAIModel
+// @Failure 404 {object} types.ErrorResponse
+// @Router /v1/models/{id} [get]
+func getOpenAIModel(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Wildcard route: model IDs may contain slashes.
+		id := chi.URLParam(r, "*")
+		for _, m := range visibleModels(r, svc.ListModels()) {
+			if m.ID == id {
+				writeOpenAIJSON(w, openAIModel(m))
+				return
+			}
+		}
+		writeJSONError(w, http.StatusNotFound, "model not found: "+id)
+	}
+}
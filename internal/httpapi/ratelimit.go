@@ -0,0 +1,338 @@
+package httpapi
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"modeld/internal/manager"
+)
+
+// RateLimit configures token buckets for one caller (an API key or a client
+// IP). A zero rate disables that limit; a zero burst defaults to one minute's
+// worth of the rate.
+type RateLimit struct {
+	RequestsPerMinute float64
+	RequestBurst      int
+	// Generated (completion) tokens. Usage is charged after generation, so a
+	// request is admitted while the budget is positive and may overdraw it;
+	// the caller then waits until the debt is refilled.
+	TokensPerMinute float64
+	TokenBurst      int
+}
+
+func (rl RateLimit) enabled() bool { return rl.RequestsPerMinute > 0 || rl.TokensPerMinute > 0 }
+
+// maxRateBuckets bounds per-caller state; refilled buckets are pruned first.
+const maxRateBuckets = 10000
+
+var rateLimitedTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "modeld",
+		Subsystem: "http",
+		Name:      "rate_limited_total",
+		Help:      "Requests rejected by rate limits (429)",
+	},
+	[]string{"scope", "limit"},
+)
+
+func init() {
+	prometheus.MustRegister(rateLimitedTotal)
+}
+
+// bucket is a token bucket refilled continuously at rate per second.
+type bucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(perMinute float64, burst int) *bucket {
+	b := &bucket{rate: perMinute / 60, burst: float64(burst)}
+	if b.burst <= 0 {
+		b.burst = math.Max(1, math.Ceil(perMinute))
+	}
+	b.tokens = b.burst
+	return b
+}
+
+func (b *bucket) refill(now time.Time) {
+	if !b.last.IsZero() {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+}
+
+// wait returns how long until the bucket holds n tokens.
+func (b *bucket) wait(n float64) time.Duration {
+	if b.tokens >= n || b.rate <= 0 {
+		return 0
+	}
+	return time.Duration((n - b.tokens) / b.rate * float64(time.Second))
+}
+
+// resetIn returns how long until the bucket is full again.
+func (b *bucket) resetIn() time.Duration { return b.wait(b.burst) }
+
+// callerLimits holds the request and token buckets of one caller.
+type callerLimits struct {
+	requests *bucket
+	tokens   *bucket
+}
+
+// rateLimiter tracks buckets per caller within one scope ("key" or "ip").
+type rateLimiter struct {
+	scope   string
+	mu      sync.Mutex
+	callers map[string]*callerLimits
+}
+
+func newRateLimiter(scope string) *rateLimiter {
+	return &rateLimiter{scope: scope, callers: make(map[string]*callerLimits)}
+}
+
+// get returns the caller's buckets, creating them from cfg. Caller holds l.mu.
+func (l *rateLimiter) get(id string, cfg RateLimit, now time.Time) *callerLimits {
+	c := l.callers[id]
+	if c == nil {
+		if len(l.callers) >= maxRateBuckets {
+			l.pruneLocked(now)
+		}
+		c = &callerLimits{}
+		if cfg.RequestsPerMinute > 0 {
+			c.requests = newBucket(cfg.RequestsPerMinute, cfg.RequestBurst)
+		}
+		if cfg.TokensPerMinute > 0 {
+			c.tokens = newBucket(cfg.TokensPerMinute, cfg.TokenBurst)
+		}
+		l.callers[id] = c
+	}
+	for _, b := range []*bucket{c.requests, c.tokens} {
+		if b != nil {
+			b.refill(now)
+		}
+	}
+	return c
+}
+
+// pruneLocked drops callers whose buckets have refilled completely; they
+// are indistinguishable from new callers.
+func (l *rateLimiter) pruneLocked(now time.Time) {
+	for id, c := range l.callers {
+		full := true
+		for _, b := range []*bucket{c.requests, c.tokens} {
+			if b != nil {
+				b.refill(now)
+				full = full && b.tokens >= b.burst
+			}
+		}
+		if full {
+			delete(l.callers, id)
+		}
+	}
+}
+
+// rateDecision is the outcome of checking one request in one scope.
+type rateDecision struct {
+	limiter    *rateLimiter
+	id         string
+	cfg        RateLimit
+	scope      string
+	limit      string // "requests" or "tokens" when rejected
+	retryAfter time.Duration
+	requests   *bucketState
+	tokens     *bucketState
+}
+
+// bucketState is a snapshot for X-RateLimit-* headers.
+type bucketState struct {
+	limit     float64
+	remaining float64
+	reset     time.Duration
+}
+
+func snapshot(b *bucket) *bucketState {
+	if b == nil {
+		return nil
+	}
+	return &bucketState{limit: b.burst, remaining: math.Max(0, math.Floor(b.tokens)), reset: b.resetIn()}
+}
+
+// admit checks that the caller has a request token and, when checkTokens is
+// set, a positive generated-token budget, and consumes the request token if
+// so. Check and consume happen under one lock so concurrent requests cannot
+// all pass on the same token.
+func (l *rateLimiter) admit(id string, cfg RateLimit, checkTokens bool, now time.Time) rateDecision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	c := l.get(id, cfg, now)
+	d := rateDecision{limiter: l, scope: l.scope, id: id, cfg: cfg}
+	if c.requests != nil {
+		if w := c.requests.wait(1); w > 0 {
+			d.limit, d.retryAfter = "requests", w
+		}
+	}
+	if d.limit == "" && checkTokens && c.tokens != nil && c.tokens.tokens <= 0 {
+		d.limit, d.retryAfter = "tokens", c.tokens.wait(1)
+	}
+	if d.limit == "" && c.requests != nil {
+		c.requests.tokens--
+	}
+	d.requests, d.tokens = snapshot(c.requests), snapshot(c.tokens)
+	return d
+}
+
+// refund returns a request token taken by admit, e.g. when another scope
+// rejected the request.
+func (l *rateLimiter) refund(id string, cfg RateLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if c := l.get(id, cfg, time.Now()); c.requests != nil {
+		c.requests.tokens = math.Min(c.requests.burst, c.requests.tokens+1)
+	}
+}
+
+// charge deducts generated tokens from the caller's token budget.
+func (l *rateLimiter) charge(id string, cfg RateLimit, n int) {
+	if n <= 0 || cfg.TokensPerMinute <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if c := l.get(id, cfg, time.Now()); c.tokens != nil {
+		c.tokens.tokens -= float64(n)
+	}
+}
+
+// Rate limit configuration and state. Per-key limits apply to authenticated
+// requests (an APIKey's own RateLimit overrides perKeyRateLimit); per-IP
+// limits apply to every request.
+var (
+	perKeyRateLimit RateLimit
+	perIPRateLimit  RateLimit
+	keyLimiter      = newRateLimiter("key")
+	ipLimiter       = newRateLimiter("ip")
+)
+
+// SetRateLimits configures the default per-API-key and per-client-IP limits
+// and resets all buckets.
+func SetRateLimits(perKey, perIP RateLimit) {
+	perKeyRateLimit, perIPRateLimit = perKey, perIP
+	keyLimiter = newRateLimiter("key")
+	ipLimiter = newRateLimiter("ip")
+}
+
+// keyRateLimit returns the limits for an authenticated key.
+func keyRateLimit(k *APIKey) RateLimit {
+	if k.RateLimit != nil {
+		return *k.RateLimit
+	}
+	return perKeyRateLimit
+}
+
+// clientIP returns the request's client address without the port.
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// rateLimit enforces request-rate limits and, for inference routes
+// (checkTokens), the generated-token budget. It must run after requireScope
+// so the API key is known. Responses carry X-RateLimit-* headers for the most
+// restrictive bucket; rejections are 429 with Retry-After.
+func rateLimit(checkTokens bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			now := time.Now()
+			var decisions []rateDecision
+			reject := func(d rateDecision) {
+				// Give back tokens already taken in other scopes.
+				for _, prev := range decisions[:len(decisions)-1] {
+					prev.limiter.refund(prev.id, prev.cfg)
+				}
+				setRateLimitHeaders(w, decisions)
+				rateLimitedTotal.WithLabelValues(d.scope, d.limit).Inc()
+				IncrementBackpressure("rate_limit")
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(d.retryAfter.Seconds()))))
+				writeJSONError(w, http.StatusTooManyRequests, "rate limit exceeded ("+d.scope+" "+d.limit+")")
+			}
+			if key := apiKeyFromContext(r.Context()); key != nil {
+				if cfg := keyRateLimit(key); cfg.enabled() {
+					d := keyLimiter.admit(key.ID, cfg, checkTokens, now)
+					decisions = append(decisions, d)
+					if d.limit != "" {
+						reject(d)
+						return
+					}
+				}
+			}
+			if perIPRateLimit.enabled() {
+				d := ipLimiter.admit(clientIP(r), perIPRateLimit, checkTokens, now)
+				decisions = append(decisions, d)
+				if d.limit != "" {
+					reject(d)
+					return
+				}
+			}
+			if len(decisions) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			setRateLimitHeaders(w, decisions)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// setRateLimitHeaders reports the bucket with the fewest remaining tokens.
+func setRateLimitHeaders(w http.ResponseWriter, decisions []rateDecision) {
+	var req, tok *bucketState
+	for _, d := range decisions {
+		if d.requests != nil && (req == nil || d.requests.remaining < req.remaining) {
+			req = d.requests
+		}
+		if d.tokens != nil && (tok == nil || d.tokens.remaining < tok.remaining) {
+			tok = d.tokens
+		}
+	}
+	h := w.Header()
+	if req != nil {
+		h.Set("X-RateLimit-Limit", strconv.Itoa(int(req.limit)))
+		h.Set("X-RateLimit-Remaining", strconv.Itoa(int(req.remaining)))
+		h.Set("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(req.reset.Seconds()))))
+	}
+	if tok != nil {
+		h.Set("X-RateLimit-Limit-Tokens", strconv.Itoa(int(tok.limit)))
+		h.Set("X-RateLimit-Remaining-Tokens", strconv.Itoa(int(tok.remaining)))
+		h.Set("X-RateLimit-Reset-Tokens", strconv.Itoa(int(math.Ceil(tok.reset.Seconds()))))
+	}
+}
+
+// rateLimitContext attaches a usage hook that charges generated tokens to
+// the caller's key and IP budgets.
+func rateLimitContext(ctx context.Context, r *http.Request) context.Context {
+	key := apiKeyFromContext(r.Context())
+	var keyCfg RateLimit
+	if key != nil {
+		keyCfg = keyRateLimit(key)
+	}
+	if keyCfg.TokensPerMinute <= 0 && perIPRateLimit.TokensPerMinute <= 0 {
+		return ctx
+	}
+	ip := clientIP(r)
+	keys, ips := keyLimiter, ipLimiter
+	return manager.WithUsageHook(ctx, func(u manager.UsageReport) {
+		if key != nil {
+			keys.charge(key.ID, keyCfg, u.Usage.CompletionTokens)
+		}
+		ips.charge(ip, perIPRateLimit, u.Usage.CompletionTokens)
+	})
+}
@@ -0,0 +1,183 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"modeld/internal/manager"
+	"modeld/pkg/types"
+)
+
+// setTestRateLimits installs rate limits for one test and clears them after.
+func setTestRateLimits(t *testing.T, perKey, perIP RateLimit) {
+	t.Helper()
+	SetRateLimits(perKey, perIP)
+	t.Cleanup(func() { SetRateLimits(RateLimit{}, RateLimit{}) })
+}
+
+// wordsAdapter generates a fixed number of tokens per request.
+type wordsAdapter struct{ n int }
+
+func (a wordsAdapter) Start(string, manager.InferParams) (manager.InferSession, error) {
+	return wordsSession(a), nil
+}
+
+type wordsSession struct{ n int }
+
+func (s wordsSession) Generate(ctx context.Context, _ string, onToken func(string) error) (manager.FinalResult, error) {
+	for i := 0; i < s.n; i++ {
+		if err := onToken("w "); err != nil {
+			return manager.FinalResult{}, err
+		}
+	}
+	return manager.FinalResult{Usage: manager.Usage{CompletionTokens: s.n}}, nil
+}
+
+func (wordsSession) Close() error { return nil }
+
+func TestRateLimit_PerIPRequests(t *testing.T) {
+	setTestRateLimits(t, RateLimit{}, RateLimit{RequestsPerMinute: 1, RequestBurst: 2})
+	h := NewMux(&mockService{})
+	before := testutil.ToFloat64(rateLimitedTotal.WithLabelValues("ip", "requests"))
+
+	for i := 0; i < 2; i++ {
+		rec := authRequest(h, http.MethodGet, "/status", "", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("request %d: status=%d", i, rec.Code)
+		}
+		if got, want := rec.Header().Get("X-RateLimit-Remaining"), []string{"1", "0"}[i]; got != want {
+			t.Fatalf("request %d: remaining=%q want %q", i, got, want)
+		}
+		if rec.Header().Get("X-RateLimit-Limit") != "2" {
+			t.Fatalf("unexpected limit header %q", rec.Header().Get("X-RateLimit-Limit"))
+		}
+	}
+	rec := authRequest(h, http.MethodGet, "/status", "", "")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rec.Code)
+	}
+	if ra := rec.Header().Get("Retry-After"); ra == "" || ra == "0" {
+		t.Fatalf("expected Retry-After, got %q", ra)
+	}
+	if got := testutil.ToFloat64(rateLimitedTotal.WithLabelValues("ip", "requests")); got != before+1 {
+		t.Fatalf("rate_limited_total=%v want %v", got, before+1)
+	}
+	// Another client address has its own bucket; probes are never limited.
+	if rec := authRequest(h, http.MethodGet, "/healthz", "", ""); rec.Code != http.StatusOK {
+		t.Fatalf("healthz limited: %d", rec.Code)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/status", nil)
+	req.RemoteAddr = "10.9.9.9:4321"
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("other IP limited: %d", rec.Code)
+	}
+}
+
+func TestRateLimit_PerKeyTokensAndOverride(t *testing.T) {
+	bulk := RateLimit{TokensPerMinute: 6000}
+	setTestKeys(t,
+		APIKey{ID: "small", SHA256: HashAPIKey("small"), Scopes: []string{ScopeInfer, ScopeRead}},
+		APIKey{ID: "bulk", SHA256: HashAPIKey("bulk"), Scopes: []string{ScopeInfer}, RateLimit: &bulk},
+	)
+	setTestRateLimits(t, RateLimit{TokensPerMinute: 1, TokenBurst: 3}, RateLimit{})
+
+	m := manager.NewWithConfig(manager.ManagerConfig{Registry: []types.Model{{ID: "m", Path: "m.gguf"}}, DefaultModel: "m"})
+	m.SetInferenceAdapter(wordsAdapter{n: 5})
+	h := NewMux(m)
+
+	// The first request is admitted with a positive budget and overdraws it.
+	rec := authRequest(h, http.MethodPost, "/infer", "small", `{"prompt":"hi"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("first infer: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("X-RateLimit-Remaining-Tokens") != "3" {
+		t.Fatalf("remaining tokens before charge = %q", rec.Header().Get("X-RateLimit-Remaining-Tokens"))
+	}
+	rec = authRequest(h, http.MethodPost, "/infer", "small", `{"prompt":"hi"}`)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("X-RateLimit-Remaining-Tokens") != "0" {
+		t.Fatalf("expected token 429, got %d headers=%v", rec.Code, rec.Header())
+	}
+	// Read routes do not check the token budget.
+	if rec := authRequest(h, http.MethodGet, "/status", "small", ""); rec.Code != http.StatusOK {
+		t.Fatalf("status limited by token budget: %d", rec.Code)
+	}
+	// A key with its own limit is unaffected by the default.
+	for i := 0; i < 3; i++ {
+		if rec := authRequest(h, http.MethodPost, "/infer", "bulk", `{"prompt":"hi"}`); rec.Code != http.StatusOK {
+			t.Fatalf("bulk infer %d: %d", i, rec.Code)
+		}
+	}
+}
+
+func TestRateLimit_ConcurrentAdmitsBurst(t *testing.T) {
+	setTestKeys(t, APIKey{ID: "k", SHA256: HashAPIKey("k"), Scopes: []string{ScopeRead}})
+	setTestRateLimits(t, RateLimit{RequestsPerMinute: 1, RequestBurst: 3}, RateLimit{RequestsPerMinute: 1, RequestBurst: 1000})
+	h := NewMux(&mockService{})
+
+	var admitted atomic.Int32
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			if rec := authRequest(h, http.MethodGet, "/status", "k", ""); rec.Code == http.StatusOK {
+				admitted.Add(1)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	if got := admitted.Load(); got != 3 {
+		t.Fatalf("admitted %d concurrent requests, want burst 3", got)
+	}
+}
+
+func TestRateLimit_RefundsKeyWhenIPRejects(t *testing.T) {
+	setTestKeys(t, APIKey{ID: "k", SHA256: HashAPIKey("k"), Scopes: []string{ScopeRead}})
+	setTestRateLimits(t, RateLimit{RequestsPerMinute: 1, RequestBurst: 5}, RateLimit{RequestsPerMinute: 1, RequestBurst: 1})
+	h := NewMux(&mockService{})
+
+	if rec := authRequest(h, http.MethodGet, "/status", "k", ""); rec.Code != http.StatusOK {
+		t.Fatalf("first request: %d", rec.Code)
+	}
+	if rec := authRequest(h, http.MethodGet, "/status", "k", ""); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected IP 429, got %d", rec.Code)
+	}
+	keyLimiter.mu.Lock()
+	tokens := keyLimiter.callers["k"].requests.tokens
+	keyLimiter.mu.Unlock()
+	if tokens < 3.9 || tokens > 4.1 {
+		t.Fatalf("key bucket tokens=%v, want 4 after refund", tokens)
+	}
+}
+
+func TestBucket_RefillAndDebt(t *testing.T) {
+	b := newBucket(60, 0) // 1/s, burst defaults to a minute's worth
+	if b.burst != 60 {
+		t.Fatalf("burst=%v", b.burst)
+	}
+	now := time.Now()
+	b.refill(now)
+	b.tokens = -2
+	if w := b.wait(1); w != 3*time.Second {
+		t.Fatalf("wait=%v", w)
+	}
+	b.refill(now.Add(2 * time.Second))
+	if b.tokens != 0 {
+		t.Fatalf("tokens=%v", b.tokens)
+	}
+	b.refill(now.Add(time.Hour))
+	if b.tokens != 60 {
+		t.Fatalf("refill should cap at burst, got %v", b.tokens)
+	}
+}
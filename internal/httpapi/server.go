@@ -6,6 +6,7 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -37,6 +38,14 @@ func NewMuxWithOptions(svc Service, opt Options) http.Handler {
 	if opt.BaseContext != nil {
 		SetBaseContext(opt.BaseContext)
 	}
+	if opt.APIKeys != nil {
+		if err := SetAPIKeys(opt.APIKeys); err != nil {
+			log.Printf("%v", err)
+		}
+	}
+	if opt.RateLimitPerKey.enabled() || opt.RateLimitPerIP.enabled() {
+		SetRateLimits(opt.RateLimitPerKey, opt.RateLimitPerIP)
+	}
 	return NewMux(svc)
 }
 
@@ -56,6 +65,8 @@ func NewMux(svc Service) http.Handler {
 	r.Use(middleware.Recoverer)
 	// Metrics instrumentation
 	r.Use(MetricsMiddleware)
+	// Per-request access log (see requestLogLevel)
+	r.Use(accessLog)
 	// Compression for JSON endpoints
 	r.Use(middleware.Compress(5))
 	// Security headers
@@ -76,12 +87,49 @@ func NewMux(svc Service) http.Handler {
 		}))
 	}
 
-	// Register routes
-	r.Get("/models", getModels(svc))
+	// Register routes. API key scopes apply once keys are configured (see
+	// SetAPIKeys) and rate limits once set (SetRateLimits); health, readiness
+	// and metrics are exempt from both.
+	read := r.With(requireScope(ScopeRead), rateLimit(false))
+	infer := r.With(requireScope(ScopeInfer), rateLimit(true))
+
+	read.Get("/models", getModels(svc))
+	read.Get("/models/*", getModel(svc))
 
-	r.Get("/status", getStatus(svc))
+	read.Get("/status", getStatus(svc))
 
-	r.Post("/infer", postInfer(svc))
+	infer.Post("/infer", postInfer(svc))
+
+	// OpenAI-compatible API
+	infer.Post("/v1/chat/completions", postChatCompletions(svc))
+	infer.Post("/v1/completions", postCompletions(svc))
+	read.Get("/v1/models", getOpenAIModels(svc))
+	read.Get("/v1/models/*", getOpenAIModel(svc))
+
+	// Admin lifecycle, registry reload, alias API and runtime logs (only when
+	// the service supports them) and usage reports (only when a ledger is
+	// configured)
+	admin := r.With(requireScope(ScopeAdmin), rateLimit(false))
+	if svc, ok := svc.(AdminService); ok {
+		mountAdmin(admin, svc)
+	}
+	if svc, ok := svc.(RegistryReloader); ok {
+		admin.Post("/admin/registry/reload", postAdminRegistryReload(svc))
+	}
+	if svc, ok := svc.(AliasService); ok {
+		mountAliases(admin, svc)
+	}
+	if svc, ok := svc.(LogService); ok {
+		admin.Get("/admin/instances/*", getAdminInstanceLogs(svc))
+	}
+	if usageLedger != nil {
+		admin.Get("/admin/usage", getAdminUsage(usageLedger))
+	}
+
+	// Live manager events (only when the service supports it)
+	if src, ok := svc.(EventSource); ok {
+		read.Get("/events", getEvents(src))
+	}
 
 	r.Get("/healthz", getHealthz())
 
@@ -109,7 +157,7 @@ func NewMux(svc Service) http.Handler {
 func getModels(svc Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		resp := types.ModelsResponse{Models: svc.ListModels()}
+		resp := types.ModelsResponse{Models: visibleModels(r, svc.ListModels())}
 		if err := json.NewEncoder(w).Encode(resp); err != nil {
 			writeJSONError(w, http.StatusInternalServerError, "failed to encode response")
 			return
@@ -117,6 +165,32 @@ func getModels(svc Service) http.HandlerFunc {
 	}
 }
 
+// getModel returns one registry model, including its GGUF header metadata.
+// @Summary Get model
+// @Description Returns a model by ID or alias, with metadata parsed from the GGUF header.
+// @Tags models
+// @Produce json
+// @Param id path string true "Model ID or alias"
+// @Success 200 {object} types.Model
+// @Failure 404 {object} types.ErrorResponse
+// @Router /models/{id} [get]
+func getModel(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Wildcard route: model IDs may contain slashes.
+		id := chi.URLParam(r, "*")
+		for _, m := range visibleModels(r, svc.ListModels()) {
+			if m.ID == id || slices.Contains(m.Aliases, id) {
+				w.Header().Set("Content-Type", "application/json")
+				if err := json.NewEncoder(w).Encode(m); err != nil {
+					writeJSONError(w, http.StatusInternalServerError, "failed to encode response")
+				}
+				return
+			}
+		}
+		writeJSONError(w, http.StatusNotFound, "model not found: "+id)
+	}
+}
+
 // getStatus returns server status.
 // @Summary Server status
 // @Description Returns runtime status of loaded instances and resource usage.
@@ -145,8 +219,11 @@ func getStatus(svc Service) http.HandlerFunc {
 // @Param log query string false "Optional per-request log level override: off|error|info|debug"
 // @Param X-Log-Level header string false "Optional per-request log level override: off|error|info|debug"
 // @Param X-Log-Infer header string false "Legacy flag; when '1' enables token debug logging"
+// @Param X-Priority header string false "Scheduling priority: interactive|normal|batch (request field priority wins)"
 // @Success 200 {string} string "NDJSON stream"
 // @Failure 400 {object} types.ErrorResponse
+// @Failure 401 {object} types.ErrorResponse
+// @Failure 403 {object} types.ErrorResponse
 // @Failure 404 {object} types.ErrorResponse
 // @Failure 415 {object} types.ErrorResponse
 // @Failure 429 {object} types.ErrorResponse
@@ -169,8 +246,12 @@ func postInfer(svc Service) http.HandlerFunc {
 			return
 		}
 		// Basic validation
-		if strings.TrimSpace(req.Prompt) == "" {
-			writeJSONError(w, http.StatusBadRequest, "prompt is required")
+		if strings.TrimSpace(req.Prompt) == "" && len(req.Messages) == 0 {
+			writeJSONError(w, http.StatusBadRequest, "prompt or messages is required")
+			return
+		}
+		if err := manager.ValidateChatMessages(req.Messages); err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
 			return
 		}
 
@@ -188,55 +269,80 @@ func postInfer(svc Service) http.HandlerFunc {
 			writer = io.MultiWriter(w, &loggingLineWriter{})
 		}
 		logInferStart(lvl, r, req.Model)
-		// Join server base context with request context so shutdown cancels work too.
-		joinedCtx, cancel := joinContexts(serverBaseCtx, r.Context())
-		// Apply optional per-handler timeout if configured
-		if inferTimeout > 0 {
-			var tctx context.Context
-			tctx, cancel = context.WithTimeout(joinedCtx, time.Duration(inferTimeout)*time.Second)
-			joinedCtx = tctx
-		}
+		joinedCtx, cancel := inferContext(w, r)
 		defer cancel()
 		if err := svc.Infer(joinedCtx, req, writer, flush); err != nil {
 			// If context was canceled (client disconnect), just return.
 			if r.Context().Err() != nil || serverBaseCtx.Err() != nil {
 				return
 			}
-			// Map well-known manager errors to HTTP status codes
-			if manager.IsModelNotFound(err) {
-				writeJSONError(w, http.StatusNotFound, err.Error())
-				logInferEnd(lvl, start, r, "404", err)
-				return
-			}
-			if manager.IsDependencyUnavailable(err) {
-				writeJSONError(w, http.StatusServiceUnavailable, err.Error())
-				logInferEnd(lvl, start, r, "503", err)
-				return
-			}
-			if manager.IsBudgetExceeded(err) {
-				writeJSONError(w, http.StatusInsufficientStorage, err.Error())
-				logInferEnd(lvl, start, r, "507", err)
-				return
-			}
-			if manager.IsTooBusy(err) {
-				writeJSONError(w, http.StatusTooManyRequests, err.Error())
-				IncrementBackpressure("queue")
-				logInferEnd(lvl, start, r, "429", err)
-				return
-			}
-			if he, ok := err.(HTTPError); ok {
-				writeJSONError(w, he.StatusCode(), he.Error())
-				logInferEnd(lvl, start, r, strconv.Itoa(he.StatusCode()), err)
-				return
-			}
-			writeJSONError(w, http.StatusInternalServerError, err.Error())
-			logInferEnd(lvl, start, r, "500", err)
+			status := writeInferError(w, err)
+			logInferEnd(lvl, start, r, strconv.Itoa(status), err)
 			return
 		}
 		logInferEnd(lvl, start, r, "200", nil)
 	}
 }
 
+// inferContext joins the server base context with the request context so
+// shutdown cancels work too, and applies the optional per-handler timeout.
+// The model the request is routed to is reported in the X-Model header.
+func inferContext(w http.ResponseWriter, r *http.Request) (context.Context, context.CancelFunc) {
+	joinedCtx, cancel := joinContexts(serverBaseCtx, r.Context())
+	joinedCtx = usageContext(rateLimitContext(schedulingContext(joinedCtx, r), r), r)
+	joinedCtx = manager.WithResolvedModelHook(joinedCtx, func(modelID string) {
+		w.Header().Set("X-Model", modelID)
+	})
+	if inferTimeout > 0 {
+		tctx, tcancel := context.WithTimeout(joinedCtx, time.Duration(inferTimeout)*time.Second)
+		return tctx, func() { tcancel(); cancel() }
+	}
+	return joinedCtx, cancel
+}
+
+// schedulingContext attaches the X-Priority header and the caller's tenant
+// for queue admission. The tenant is the API key ID when authenticated,
+// otherwise the client address (RealIP has already been applied).
+func schedulingContext(ctx context.Context, r *http.Request) context.Context {
+	if p := r.Header.Get("X-Priority"); p != "" {
+		ctx = manager.WithPriority(ctx, p)
+	}
+	return authContext(manager.WithTenant(ctx, clientIP(r)), r)
+}
+
+// inferErrorStatus maps well-known manager errors to HTTP status codes.
+func inferErrorStatus(err error) int {
+	switch {
+	case manager.IsInvalidRequest(err):
+		return http.StatusBadRequest
+	case manager.IsForbidden(err):
+		return http.StatusForbidden
+	case manager.IsModelNotFound(err):
+		return http.StatusNotFound
+	case manager.IsDependencyUnavailable(err):
+		return http.StatusServiceUnavailable
+	case manager.IsBudgetExceeded(err):
+		return http.StatusInsufficientStorage
+	case manager.IsTooBusy(err):
+		return http.StatusTooManyRequests
+	}
+	if he, ok := err.(HTTPError); ok {
+		return he.StatusCode()
+	}
+	return http.StatusInternalServerError
+}
+
+// writeInferError writes the JSON error for a failed inference, records
+// backpressure for 429s and returns the status code used.
+func writeInferError(w http.ResponseWriter, err error) int {
+	status := inferErrorStatus(err)
+	if status == http.StatusTooManyRequests && manager.IsTooBusy(err) {
+		IncrementBackpressure("queue")
+	}
+	writeJSONError(w, status, err.Error())
+	return status
+}
+
 // getHealthz returns OK for liveness checks.
 // @Summary Health check
 // @Tags health
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"testing"
 
+	"modeld/internal/manager"
 	"modeld/pkg/types"
 )
 
@@ -69,6 +70,33 @@ func TestModelsHandler(t *testing.T) {
 	}
 }
 
+func TestModelHandler(t *testing.T) {
+	svc := &mockService{models: []types.Model{
+		{ID: "org/m1", Aliases: []string{"m1"}, GGUF: &types.GGUFMetadata{Architecture: "llama", Layers: 32}},
+		{ID: "m2"},
+	}}
+	r := NewMux(svc)
+	for _, path := range []string{"/models/org/m1", "/models/m1"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status=%d", path, w.Code)
+		}
+		var m types.Model
+		if err := json.Unmarshal(w.Body.Bytes(), &m); err != nil {
+			t.Fatalf("json: %v", err)
+		}
+		if m.ID != "org/m1" || m.GGUF == nil || m.GGUF.Architecture != "llama" || m.GGUF.Layers != 32 {
+			t.Fatalf("%s: unexpected model %+v", path, m)
+		}
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/models/nope", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+}
+
 func TestStatusHandler(t *testing.T) {
 	svc := &mockService{status: types.StatusResponse{BudgetMB: 10}}
 	r := NewMux(svc)
@@ -212,3 +240,36 @@ func TestHealthz(t *testing.T) {
 		t.Fatalf("status=%d", w.Code)
 	}
 }
+
+// tenantService records the scheduling tenant attached to the infer context.
+type tenantService struct {
+	mockService
+	tenant string
+}
+
+func (s *tenantService) Infer(ctx context.Context, req types.InferRequest, w io.Writer, flush func()) error {
+	s.tenant = manager.TenantFromContext(ctx)
+	return s.mockService.Infer(ctx, req, w, flush)
+}
+
+func TestInferSchedulingContext(t *testing.T) {
+	svc := &tenantService{}
+	req := httptest.NewRequest(http.MethodPost, "/infer", bytes.NewBufferString(`{"prompt":"hi"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Forwarded-For", "10.1.2.3")
+	NewMux(svc).ServeHTTP(httptest.NewRecorder(), req)
+	if svc.tenant != "10.1.2.3" {
+		t.Fatalf("tenant=%q", svc.tenant)
+	}
+
+	// An unknown priority is rejected by the manager with 400.
+	m := manager.NewWithConfig(manager.ManagerConfig{Registry: []types.Model{{ID: "m", Path: "m.gguf"}}, DefaultModel: "m"})
+	req = httptest.NewRequest(http.MethodPost, "/infer", bytes.NewBufferString(`{"prompt":"hi"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Priority", "urgent")
+	w := httptest.NewRecorder()
+	NewMux(m).ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,71 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"modeld/internal/manager"
+	"modeld/internal/usage"
+	"modeld/pkg/types"
+)
+
+// usageLedger records per-request usage when set (see SetUsageLedger).
+var usageLedger *usage.Ledger
+
+// SetUsageLedger enables usage accounting to l and mounts GET /admin/usage
+// on routers built afterwards. nil disables accounting.
+func SetUsageLedger(l *usage.Ledger) { usageLedger = l }
+
+// usageContext attaches a usage hook that appends one ledger record per
+// inference, attributed to the request's API key.
+func usageContext(ctx context.Context, r *http.Request) context.Context {
+	l := usageLedger
+	if l == nil {
+		return ctx
+	}
+	keyID := requestKeyID(r)
+	return manager.WithUsageHook(ctx, func(u manager.UsageReport) {
+		rec := usage.Record{
+			KeyID:            keyID,
+			ModelID:          u.ModelID,
+			PromptTokens:     u.Usage.PromptTokens,
+			CompletionTokens: u.Usage.CompletionTokens,
+			GenerationMs:     u.GenerationTime.Milliseconds(),
+		}
+		if err := l.Record(rec); err != nil {
+			log.Printf("usage ledger write failed: %v", err)
+		}
+	})
+}
+
+// getAdminUsage reports aggregated usage from the ledger.
+// @Summary Usage report
+// @Description Aggregates prompt/completion tokens, request counts and generation time per UTC day, API key and model. from/to accept YYYY-MM-DD (to is inclusive) or RFC 3339 timestamps (to is exclusive).
+// @Tags admin
+// @Produce json
+// @Param key query string false "API key ID"
+// @Param model query string false "Model ID"
+// @Param from query string false "Start (YYYY-MM-DD or RFC 3339)"
+// @Param to query string false "End (YYYY-MM-DD inclusive, or RFC 3339 exclusive)"
+// @Success 200 {object} types.UsageResponse
+// @Failure 400 {object} types.ErrorResponse
+// @Router /admin/usage [get]
+func getAdminUsage(l *usage.Ledger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		from, to, err := usage.ParseRange(q.Get("from"), q.Get("to"))
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		rows, err := l.Query(usage.Filter{KeyID: q.Get("key"), ModelID: q.Get("model"), From: from, To: to})
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "usage query failed: "+err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(types.UsageResponse{Rows: rows})
+	}
+}
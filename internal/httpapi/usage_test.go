@@ -0,0 +1,112 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"modeld/internal/manager"
+	"modeld/internal/usage"
+	"modeld/pkg/types"
+)
+
+func TestUsageLedger_RecordsAndReports(t *testing.T) {
+	l, err := usage.Open(filepath.Join(t.TempDir(), "usage.jsonl"))
+	if err != nil {
+		t.Fatalf("open ledger: %v", err)
+	}
+	SetUsageLedger(l)
+	t.Cleanup(func() { SetUsageLedger(nil); _ = l.Close() })
+	setTestKeys(t,
+		APIKey{ID: "team", SHA256: HashAPIKey("team"), Scopes: []string{ScopeInfer}},
+		APIKey{ID: "ops", SHA256: HashAPIKey("ops"), Scopes: []string{ScopeAdmin}},
+	)
+
+	m := manager.NewWithConfig(manager.ManagerConfig{Registry: []types.Model{{ID: "m", Path: "m.gguf"}}, DefaultModel: "m"})
+	m.SetInferenceAdapter(wordsAdapter{n: 5})
+	h := NewMux(m)
+	for i := 0; i < 2; i++ {
+		if rec := authRequest(h, http.MethodPost, "/v1/completions", "team", `{"prompt":"hi"}`); rec.Code != http.StatusOK {
+			t.Fatalf("infer: %d %s", rec.Code, rec.Body.String())
+		}
+	}
+
+	if rec := authRequest(h, http.MethodGet, "/admin/usage", "team", ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 without admin scope, got %d", rec.Code)
+	}
+	today := time.Now().UTC().Format(time.DateOnly)
+	rec := authRequest(h, http.MethodGet, "/admin/usage?key=team&from="+today+"&to="+today, "ops", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("usage: %d %s", rec.Code, rec.Body.String())
+	}
+	var resp types.UsageResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	if len(resp.Rows) != 1 {
+		t.Fatalf("unexpected rows: %+v", resp.Rows)
+	}
+	if r := resp.Rows[0]; r.KeyID != "team" || r.ModelID != "m" || r.Requests != 2 || r.CompletionTokens != 10 || r.Day != today {
+		t.Fatalf("unexpected row: %+v", r)
+	}
+
+	if rec := authRequest(h, http.MethodGet, "/admin/usage?from=last-week", "ops", ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for bad from, got %d", rec.Code)
+	}
+}
+
+func TestUsageLedger_RecordsBackendUsage(t *testing.T) {
+	l, err := usage.Open(filepath.Join(t.TempDir(), "usage.jsonl"))
+	if err != nil {
+		t.Fatalf("open ledger: %v", err)
+	}
+	SetUsageLedger(l)
+	t.Cleanup(func() { SetUsageLedger(nil); _ = l.Close() })
+
+	// llama-server streams fragments, then a final chunk carrying usage and
+	// timings; the fragment count does not match the token count.
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, frag := range []string{"hello", " world"} {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", frag)
+		}
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{},\"finish_reason\":\"stop\"}],"+
+			"\"usage\":{\"prompt_tokens\":7,\"completion_tokens\":3,\"total_tokens\":10},"+
+			"\"timings\":{\"prompt_n\":7,\"predicted_n\":3}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(backend.Close)
+
+	m := manager.NewWithConfig(manager.ManagerConfig{Registry: []types.Model{{ID: "m", Path: "m.gguf"}}, DefaultModel: "m"})
+	m.SetInferenceAdapter(manager.NewLlamaServerAdapter(backend.URL, "", true, 5*time.Second, time.Second))
+	h := NewMux(m)
+
+	rec := authRequest(h, http.MethodPost, "/infer", "", `{"prompt":"hi"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("infer: %d %s", rec.Code, rec.Body.String())
+	}
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	var final struct {
+		Usage manager.Usage `json:"usage"`
+	}
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &final); err != nil {
+		t.Fatalf("final line: %v", err)
+	}
+	want := manager.Usage{PromptTokens: 7, CompletionTokens: 3, TotalTokens: 10}
+	if final.Usage != want {
+		t.Fatalf("final line usage=%+v want %+v", final.Usage, want)
+	}
+
+	rows, err := l.Query(usage.Filter{})
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(rows) != 1 || rows[0].PromptTokens != 7 || rows[0].CompletionTokens != 3 {
+		t.Fatalf("unexpected ledger rows: %+v", rows)
+	}
+}
@@ -83,6 +83,12 @@ type openAICompletionRequest struct {
 	// RepeatPenalty is not standard OpenAI; some llama.cpp builds accept it under different names.
 	// We include it using the common key if present; servers that ignore it will safely ignore.
 	RepeatPenalty float32 `json:"repeat_penalty,omitempty"`
+	// StreamOptions asks for a usage object on the final stream chunk.
+	StreamOptions *openAIStreamOptions `json:"stream_options,omitempty"`
+}
+
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
 }
 
 // openAIStreamChoiceDelta is a minimal subset of OpenAI streaming response.
@@ -96,6 +102,34 @@ type openAIStreamChoiceDelta struct {
 type openAIStreamResponse struct {
 	Object  string                    `json:"object"`
 	Choices []openAIStreamChoiceDelta `json:"choices"`
+	Usage   *Usage                    `json:"usage"`
+	// Timings is llama-server's per-request summary, sent on the last chunk.
+	Timings *llamaTimings `json:"timings"`
+}
+
+// llamaTimings is the subset of llama-server timings used for token counts.
+type llamaTimings struct {
+	PromptN    int `json:"prompt_n"`
+	PredictedN int `json:"predicted_n"`
+}
+
+// applyUsage records token counts from a stream chunk into final. An OpenAI
+// usage object wins over llama-server timings.
+func (m openAIStreamResponse) applyUsage(final *FinalResult) {
+	switch {
+	case m.Usage != nil && (m.Usage.PromptTokens > 0 || m.Usage.CompletionTokens > 0):
+		final.Usage = *m.Usage
+	case m.Timings != nil && (m.Timings.PromptN > 0 || m.Timings.PredictedN > 0):
+		if final.Usage.PromptTokens > 0 || final.Usage.CompletionTokens > 0 {
+			return
+		}
+		final.Usage = Usage{PromptTokens: m.Timings.PromptN, CompletionTokens: m.Timings.PredictedN}
+	default:
+		return
+	}
+	if final.Usage.TotalTokens < final.Usage.PromptTokens+final.Usage.CompletionTokens {
+		final.Usage.TotalTokens = final.Usage.PromptTokens + final.Usage.CompletionTokens
+	}
 }
 
 func (s *llamaServerSession) Generate(ctx context.Context, prompt string, onToken func(string) error) (FinalResult, error) {
@@ -128,6 +162,7 @@ func (s *llamaServerSession) generateOpenAI(ctx context.Context, prompt string,
 		Seed:          s.baseParams.Seed,
 		Stream:        true,
 		RepeatPenalty: s.baseParams.RepeatPenalty,
+		StreamOptions: &openAIStreamOptions{IncludeUsage: true},
 	}
 	body, _ := json.Marshal(payload)
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.adapter.baseURL+"/v1/completions", bytes.NewReader(body))
@@ -166,7 +201,11 @@ func (s *llamaServerSession) generateOpenAI(ctx context.Context, prompt string,
 					break
 				}
 				var msg openAIStreamResponse
-				if err := json.Unmarshal([]byte(data), &msg); err == nil && len(msg.Choices) > 0 {
+				if err := json.Unmarshal([]byte(data), &msg); err == nil && (len(msg.Choices) > 0 || msg.Usage != nil || msg.Timings != nil) {
+					msg.applyUsage(&final)
+					if len(msg.Choices) == 0 {
+						continue
+					}
 					frag := msg.Choices[0].Delta.Content
 					if frag != "" {
 						if cbErr := onToken(frag); cbErr != nil {
@@ -129,3 +129,27 @@ func TestLlamaServerAdapter_ContextCancel(t *testing.T) {
 		t.Fatalf("expected context deadline exceeded or cancel error due to short req timeout")
 	}
 }
+
+func TestLlamaServerAdapter_UsageFromTimings(t *testing.T) {
+	// Builds that omit the OpenAI usage object still report llama-server timings.
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		sw := sseWriter{w: w}
+		sw.writeLine(`data: {"choices":[{"delta":{"content":"ab"}}]}`)
+		sw.writeLine(`data: {"choices":[{"delta":{},"finish_reason":"length"}],"timings":{"prompt_n":4,"predicted_n":2}}`)
+		sw.writeLine("data: [DONE]")
+	}))
+	defer ts.Close()
+
+	sess, err := NewLlamaServerAdapter(ts.URL, "", true, 5*time.Second, 2*time.Second).Start("m", InferParams{})
+	if err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	final, err := sess.Generate(testCtx(t), "x", func(string) error { return nil })
+	if err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if want := (Usage{PromptTokens: 4, CompletionTokens: 2, TotalTokens: 6}); final.Usage != want || final.FinishReason != "length" {
+		t.Fatalf("usage=%+v finish=%q", final.Usage, final.FinishReason)
+	}
+}
@@ -11,12 +11,19 @@ import (
     "log"
     "net"
     "net/http"
+    "net/url"
+    "os"
     "os/exec"
     "strconv"
     "strings"
     "sync"
     "time"
     "syscall"
+
+    "github.com/rs/zerolog"
+
+    "modeld/internal/common/fsutil"
+    "modeld/pkg/types"
 )
 
 // llamaSubprocessAdapter spawns and manages a llama.cpp server per model path.
@@ -27,6 +34,109 @@ type llamaSubprocessAdapter struct {
     procs      map[string]*procInfo // key: modelPath
     httpClient *http.Client
     publisher  EventPublisher
+    parallel   map[string]int // key: modelPath; --parallel slots when > 1
+    profiles   map[string]types.RuntimeProfile // key: modelPath; resolved per-model settings
+    // Crash supervision (see spawn_supervisor.go)
+    hooks      spawnHooks
+    crashes    map[string]int           // key: modelPath; crashes since the last stable run
+    restarts   map[string]chan struct{} // key: modelPath; closed by Stop to cancel a pending restart
+    // Runtime output (see runtime_logs.go)
+    logs       map[string]*runtimeLog // key: modelPath
+    logger     *zerolog.Logger
+    // Runtime state file (see spawn_state.go): stateMu serializes writes;
+    // detached is set once Close leaves the runtimes to the next modeld
+    stateMu    sync.Mutex
+    detached   bool
+}
+
+// logFor returns the output log for modelPath, creating it on first use.
+// name (the model ID) labels the log file and forwarded lines; it defaults
+// to the path.
+func (a *llamaSubprocessAdapter) logFor(modelPath, name string) *runtimeLog {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    if l := a.logs[modelPath]; l != nil { return l }
+    if name == "" { name = modelPath }
+    var file *rotatingFile
+    if dir := strings.TrimSpace(a.cfg.LlamaLogDir); dir != "" {
+        if p, err := fsutil.ExpandHome(dir); err == nil { dir = p }
+        maxMB, backups := a.cfg.LlamaLogMaxMB, a.cfg.LlamaLogBackups
+        if maxMB <= 0 { maxMB = defaultRuntimeLogMaxMB }
+        if backups == 0 { backups = defaultRuntimeLogBackups }
+        file = newRotatingFile(runtimeLogFile(dir, name), int64(maxMB)*mib, backups)
+    }
+    if a.logs == nil { a.logs = make(map[string]*runtimeLog) }
+    l := newRuntimeLog(name, a.cfg.LlamaLogLines, file, a.logger)
+    a.logs[modelPath] = l
+    return l
+}
+
+// setLogger installs the structured logger runtime output is forwarded to.
+func (a *llamaSubprocessAdapter) setLogger(logger zerolog.Logger) {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    a.logger = &logger
+    for _, l := range a.logs {
+        l.mu.Lock()
+        l.logger = a.logger
+        l.mu.Unlock()
+    }
+}
+
+// setProfile records the runtime profile to spawn the process for modelPath
+// with; paths without one use the global settings.
+func (a *llamaSubprocessAdapter) setProfile(modelPath string, p types.RuntimeProfile) {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    if a.profiles == nil { a.profiles = make(map[string]types.RuntimeProfile) }
+    a.profiles[modelPath] = p
+}
+
+// profileFor returns the runtime profile for modelPath.
+func (a *llamaSubprocessAdapter) profileFor(modelPath string) types.RuntimeProfile {
+    a.mu.Lock()
+    p, ok := a.profiles[modelPath]
+    a.mu.Unlock()
+    if !ok { return globalProfile(a.cfg) }
+    return p
+}
+
+// setParallel records the number of parallel slots to request (--parallel)
+// when the process for modelPath is next spawned.
+func (a *llamaSubprocessAdapter) setParallel(modelPath string, n int) {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    if a.parallel == nil { a.parallel = make(map[string]int) }
+    a.parallel[modelPath] = n
+}
+
+// spawnArgs builds the llama-server command line for modelPath.
+func (a *llamaSubprocessAdapter) spawnArgs(modelPath, host string, port int) []string {
+    args := []string{
+        "-m", modelPath,
+        "--host", host,
+        "--port", fmt.Sprint(port),
+    }
+    a.mu.Lock()
+    np := a.parallel[modelPath]
+    a.mu.Unlock()
+    prof := a.profileFor(modelPath)
+    args = append(args, profileArgs(prof)...)
+    if np > 1 && !hasParallelArg(prof.ExtraArgs) { args = append(args, "--parallel", fmt.Sprint(np)) }
+    // Extra arguments (global, then per-model) come last so they override
+    // the typed settings.
+    args = append(args, prof.ExtraArgs...)
+    return args
+}
+
+// hasParallelArg reports whether args already set the slot count.
+func hasParallelArg(args []string) bool {
+    for _, a := range args {
+        if a == "-np" || a == "--parallel" || strings.HasPrefix(a, "--parallel=") {
+            return true
+        }
+    }
+    return false
 }
 
 // isHealthy checks if the llama-server at baseURL responds OK to /v1/models.
@@ -69,14 +179,31 @@ func NewLlamaSubprocessAdapter(cfg ManagerConfig) InferenceAdapter {
     // Intentionally set Timeout=0: all calls must use context-based timeouts.
     // ensureProcess() and Generate() create requests with contexts carrying deadlines.
     cli := &http.Client{ Timeout: 0 }
+    if strings.TrimSpace(cfg.SpawnStateFile) != "" {
+        // Runtimes outlive modeld; see ignoreSIGPIPE.
+        ignoreSIGPIPE()
+    }
     return &llamaSubprocessAdapter{cfg: cfg, procs: make(map[string]*procInfo), httpClient: cli, publisher: noopPublisher{}}
 }
 
 type procInfo struct {
-    cmd    *exec.Cmd
+    cmd    *exec.Cmd // nil for an adopted process
+    process *os.Process
     baseURL string
     ready  bool
     pid    int
+    started time.Time
+    // Command line, for the state file
+    host   string
+    port   int
+    args   []string
+    env    []string
+    adopted bool
+    // done is closed once cmd.Wait has returned waitErr; stopping is set
+    // by Stop so the supervisor does not treat the exit as a crash.
+    done     chan struct{}
+    waitErr  error
+    stopping bool
 }
 
 // llamaSubprocessSession represents a session in spawn mode.
@@ -91,6 +218,13 @@ func (a *llamaSubprocessAdapter) Start(modelPath string, params InferParams) (In
     if strings.TrimSpace(modelPath) == "" {
         return nil, errors.New("modelPath is empty")
     }
+    a.mu.Lock()
+    restarting := a.restarts[modelPath] != nil
+    a.mu.Unlock()
+    if restarting {
+        // Leave the respawn to the supervisor rather than racing it.
+        return nil, fmt.Errorf("llama-server for %s crashed; restart pending", modelPath)
+    }
     baseURL, err := a.ensureProcess(modelPath)
     if err != nil {
         return nil, err
@@ -111,6 +245,7 @@ func (s *llamaSubprocessSession) Generate(ctx context.Context, prompt string, on
         Seed:          s.params.Seed,
         Stream:        true,
         RepeatPenalty: s.params.RepeatPenalty,
+        StreamOptions: &openAIStreamOptions{IncludeUsage: true},
     }
     body, _ := json.Marshal(payload)
     req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/v1/completions", bytes.NewReader(body))
@@ -136,12 +271,15 @@ func (s *llamaSubprocessSession) Generate(ctx context.Context, prompt string, on
                 data := strings.TrimSpace(l[len("data:"):])
                 if data == "[DONE]" { break }
                 var msg openAIStreamResponse
-                if e := json.Unmarshal([]byte(data), &msg); e == nil && len(msg.Choices) > 0 {
-                    frag := msg.Choices[0].Delta.Content
-                    if frag != "" {
-                        if cbErr := onToken(frag); cbErr != nil { return final, cbErr }
+                if e := json.Unmarshal([]byte(data), &msg); e == nil {
+                    msg.applyUsage(&final)
+                    if len(msg.Choices) > 0 {
+                        frag := msg.Choices[0].Delta.Content
+                        if frag != "" {
+                            if cbErr := onToken(frag); cbErr != nil { return final, cbErr }
+                        }
+                        if fr := msg.Choices[0].FinishReason; fr != "" { final.FinishReason = fr }
                     }
-                    if fr := msg.Choices[0].FinishReason; fr != "" { final.FinishReason = fr }
                 }
             }
         }
@@ -159,6 +297,10 @@ func (s *llamaSubprocessSession) Close() error { return nil }
 // ensureProcess starts (or returns existing) llama-server for given modelPath and waits readiness.
 func (a *llamaSubprocessAdapter) ensureProcess(modelPath string) (string, error) {
     a.mu.Lock()
+    if a.detached {
+        a.mu.Unlock()
+        return "", errors.New("llama-server adapter is shutting down")
+    }
     if a.procs == nil { a.procs = make(map[string]*procInfo) }
     if p := a.procs[modelPath]; p != nil {
         base := p.baseURL
@@ -169,9 +311,9 @@ func (a *llamaSubprocessAdapter) ensureProcess(modelPath string) (string, error)
                 return base, nil
             }
             // unhealthy: fall through to restart
-            a.mu.Lock()
             // best effort stop; ignore error
             _ = a.Stop(modelPath)
+            a.mu.Lock()
         } else {
             // Not ready yet: try health just in case; else continue to wait/spawn
             a.mu.Unlock()
@@ -181,8 +323,8 @@ func (a *llamaSubprocessAdapter) ensureProcess(modelPath string) (string, error)
                 a.mu.Unlock()
                 return base, nil
             }
-            a.mu.Lock()
             _ = a.Stop(modelPath)
+            a.mu.Lock()
         }
     }
     a.mu.Unlock()
@@ -201,38 +343,62 @@ func (a *llamaSubprocessAdapter) ensureProcess(modelPath string) (string, error)
     if err != nil { return "", err }
     baseURL := fmt.Sprintf("http://%s:%d", host, port)
 
-    args := []string{
-        "-m", modelPath,
-        "--host", host,
-        "--port", fmt.Sprint(port),
-    }
-    if a.cfg.LlamaCtxSize > 0 { args = append(args, "-c", fmt.Sprint(a.cfg.LlamaCtxSize)) }
-    if a.cfg.LlamaNGL > 0 { args = append(args, "-ngl", fmt.Sprint(a.cfg.LlamaNGL)) }
-    if a.cfg.LlamaThreads > 0 { args = append(args, "-t", fmt.Sprint(a.cfg.LlamaThreads)) }
-    if len(a.cfg.LlamaExtraArgs) > 0 { args = append(args, a.cfg.LlamaExtraArgs...) }
-
+    args := a.spawnArgs(modelPath, host, port)
     cmd := exec.Command(a.cfg.LlamaBin, args...)
-    // Inherit stdout/stderr to aid debugging. Could swap for logger later.
-    // cmd.Stdout = os.Stdout; cmd.Stderr = os.Stderr
-    // Capture stderr for diagnostics (kept in-memory; tail is included on failure)
-    var stderr bytes.Buffer
-    cmd.Stderr = &stderr
+    env := profileEnv(a.profileFor(modelPath))
+    if len(env) > 0 {
+        cmd.Env = append(os.Environ(), env...)
+    }
+    if a.statePath() != "" {
+        // Keep the runtime out of modeld's process group so a Ctrl+C or
+        // group kill aimed at modeld leaves it for the next one to adopt.
+        detachProcess(cmd)
+    }
+    // Output goes to the model's runtime log; the stderr tail is included
+    // in early-exit errors.
+    stdoutPipe, err := cmd.StdoutPipe()
+    if err != nil { return "", fmt.Errorf("start llama-server: %w", err) }
+    stderrPipe, err := cmd.StderrPipe()
+    if err != nil { return "", fmt.Errorf("start llama-server: %w", err) }
     if err := cmd.Start(); err != nil {
         return "", fmt.Errorf("start llama-server: %w", err)
     }
+    rlog := a.logFor(modelPath, "")
+    var copying sync.WaitGroup
+    for _, out := range []struct{ name string; r io.Reader }{{"stdout", stdoutPipe}, {"stderr", stderrPipe}} {
+        w := rlog.writer(out.name, cmd.Process.Pid)
+        copying.Add(1)
+        go func() {
+            defer copying.Done()
+            _, _ = io.Copy(w, out.r)
+            w.flush()
+        }()
+    }
     log.Printf("adapter=llama_subprocess event=start model=%q pid=%d host=%s port=%d", modelPath, cmd.Process.Pid, host, port)
     a.publisher.Publish(Event{Name: "spawn_start", ModelID: modelPath, Fields: map[string]any{"pid": cmd.Process.Pid, "host": host, "port": port}})
 
     // Save proc before readiness wait
+    proc := &procInfo{cmd: cmd, process: cmd.Process, baseURL: baseURL, ready: false, pid: cmd.Process.Pid, started: time.Now(),
+        host: host, port: port, args: args, env: env, done: make(chan struct{})}
     a.mu.Lock()
-    a.procs[modelPath] = &procInfo{cmd: cmd, baseURL: baseURL, ready: false, pid: cmd.Process.Pid}
+    a.procs[modelPath] = proc
+    detached := a.detached
     a.mu.Unlock()
 
-    // Early-exit watcher: surface non-zero exit before readiness
-    waitErrCh := make(chan error, 1)
+    // The only waiter: surfaces an early exit before readiness and, once
+    // ready, a crash to the supervisor.
     go func() {
-        waitErrCh <- cmd.Wait()
+        // Drain the output before Wait closes the pipes.
+        copying.Wait()
+        proc.waitErr = cmd.Wait()
+        close(proc.done)
     }()
+    if detached {
+        // Close ran while this runtime was starting; it will not be
+        // recorded for adoption, so do not leave it running.
+        _ = a.Stop(modelPath)
+        return "", errors.New("llama-server adapter is shutting down")
+    }
 
     // Wait readiness with deadline and early failure detection
     deadline := time.Now().Add(30 * time.Second)
@@ -248,11 +414,10 @@ func (a *llamaSubprocessAdapter) ensureProcess(modelPath string) (string, error)
         }
         // Check if process exited
         select {
-        case werr := <-waitErrCh:
-            if werr != nil {
+        case <-proc.done:
+            if werr := proc.waitErr; werr != nil {
                 // Include a small tail of stderr for context
-                tail := stderr.String()
-                if len(tail) > 4096 { tail = tail[len(tail)-4096:] }
+                tail := rlog.stderrTail(proc.pid, 4096)
                 // Cleanup proc entry on failure
                 a.mu.Lock()
                 delete(a.procs, modelPath)
@@ -288,8 +453,15 @@ func (a *llamaSubprocessAdapter) ensureProcess(modelPath string) (string, error)
         time.Sleep(100 * time.Millisecond)
     }
     a.mu.Lock()
-    if p := a.procs[modelPath]; p != nil { p.ready = true }
+    stopping := proc.stopping
+    proc.ready = !stopping
     a.mu.Unlock()
+    if stopping {
+        // Stopped while loading (e.g. by Close); never report it ready.
+        return "", fmt.Errorf("llama-server stopped before ready: %s", baseURL)
+    }
+    a.saveState()
+    go a.supervise(modelPath, proc)
     return baseURL, nil
 }
 
@@ -319,33 +491,49 @@ func (a *llamaSubprocessAdapter) getProcInfo(modelPath string) (pid int, baseURL
     return 0, "", false, false
 }
 
+// endpoint returns the PID and port of the process for modelPath.
+func (a *llamaSubprocessAdapter) endpoint(modelPath string) (pid, port int, ok bool) {
+    pid, base, _, ok := a.getProcInfo(modelPath)
+    if !ok { return 0, 0, false }
+    u, err := url.Parse(base)
+    if err != nil { return 0, 0, false }
+    port, err = strconv.Atoi(u.Port())
+    if err != nil { return 0, 0, false }
+    return pid, port, true
+}
+
 // Stop terminates a spawned llama-server process for the given modelPath, if present.
 func (a *llamaSubprocessAdapter) Stop(modelPath string) error {
     a.mu.Lock()
     p := a.procs[modelPath]
+    // An explicit stop cancels a pending restart and resets crash counting.
+    if cancel := a.restarts[modelPath]; cancel != nil {
+        close(cancel)
+        delete(a.restarts, modelPath)
+    }
+    delete(a.crashes, modelPath)
+    if p != nil { p.stopping = true }
     a.mu.Unlock()
-    if p == nil || p.cmd == nil || p.cmd.Process == nil {
+    if p == nil || p.process == nil {
         return nil
     }
     // Try to gracefully terminate first, then fall back to kill.
-    // Best-effort: platform-specific; on Unix send SIGTERM.
-    _ = p.cmd.Process.Signal(syscall.SIGTERM)
-    done := make(chan struct{})
-    go func() {
-        _, _ = p.cmd.Process.Wait()
-        close(done)
-    }()
+    // Best-effort: platform-specific; on Unix send SIGTERM. The process is
+    // reaped by the waiter started in ensureProcess; waiting here as well
+    // would race it.
+    _ = p.process.Signal(syscall.SIGTERM)
     select {
-    case <-done:
+    case <-p.done:
         // exited gracefully
     case <-time.After(2 * time.Second):
         // force kill
-        _ = p.cmd.Process.Kill()
-        _, _ = p.cmd.Process.Wait()
+        _ = p.process.Kill()
+        <-p.done
     }
     a.mu.Lock()
-    delete(a.procs, modelPath)
+    if a.procs[modelPath] == p { delete(a.procs, modelPath) }
     a.mu.Unlock()
+    a.saveState()
     a.publisher.Publish(Event{Name: "spawn_stop", ModelID: modelPath, Fields: map[string]any{}})
     return nil
 }
@@ -0,0 +1,100 @@
+//go:build integration
+// +build integration
+
+package manager
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"modeld/pkg/types"
+)
+
+func TestSubprocessAdoptedAfterRestart(t *testing.T) {
+	bin := buildTestBinary(t)
+	cfg := ManagerConfig{
+		Registry:       []types.Model{{ID: "m1", Path: "m1.gguf"}},
+		DefaultModel:   "m1",
+		SpawnLlama:     true,
+		LlamaBin:       bin,
+		LlamaHost:      "127.0.0.1",
+		LlamaPortStart: 31280,
+		LlamaPortEnd:   31290,
+		SpawnStateFile: filepath.Join(t.TempDir(), "runtimes.json"),
+	}
+	first := NewWithConfig(cfg)
+	if err := first.EnsureInstance(testContext(t), "m1"); err != nil {
+		t.Fatalf("EnsureInstance: %v", err)
+	}
+	before := waitInstance(t, first, "m1", func(st types.InstanceStatus) bool { return st.PID > 0 })
+	// Shutting down leaves the runtime running.
+	_ = first.Close()
+	if !processAlive(before.PID) {
+		t.Fatalf("runtime stopped on Close")
+	}
+
+	second := NewWithConfig(cfg)
+	defer second.StopAllInstances()
+	if ids := second.AdoptRuntimes(); len(ids) != 1 || ids[0] != "m1" {
+		t.Fatalf("adopted %v", ids)
+	}
+	st := waitInstance(t, second, "m1", func(st types.InstanceStatus) bool { return st.State == string(StateReady) })
+	if st.PID != before.PID || st.Port != before.Port || !st.Adopted || second.Status().UsedMB != st.EstVRAMMB {
+		t.Fatalf("adopted instance %+v, before %+v", st, before)
+	}
+	// Requests use the adopted runtime rather than spawning a new one.
+	if err := second.EnsureInstance(testContext(t), "m1"); err != nil {
+		t.Fatalf("EnsureInstance: %v", err)
+	}
+	if pid, _, _ := second.adapter.(*llamaSubprocessAdapter).endpoint("m1.gguf"); pid != before.PID {
+		t.Fatalf("runtime respawned: pid %d, want %d", pid, before.PID)
+	}
+
+	// An adopted runtime that dies is noticed and restarted.
+	sa := second.adapter.(*llamaSubprocessAdapter)
+	sa.mu.Lock()
+	p := sa.procs["m1.gguf"]
+	sa.mu.Unlock()
+	_ = p.process.Kill()
+	st = waitInstance(t, second, "m1", func(st types.InstanceStatus) bool { return st.Restarts == 1 && st.State == string(StateReady) })
+	if st.PID == before.PID {
+		t.Fatalf("expected a new process after the crash")
+	}
+}
+
+func TestSubprocessNotAdoptedWhenArgsChanged(t *testing.T) {
+	bin := buildTestBinary(t)
+	cfg := ManagerConfig{
+		Registry:       []types.Model{{ID: "m1", Path: "m1.gguf"}},
+		SpawnLlama:     true,
+		LlamaBin:       bin,
+		LlamaHost:      "127.0.0.1",
+		LlamaPortStart: 31291,
+		LlamaPortEnd:   31299,
+		SpawnStateFile: filepath.Join(t.TempDir(), "runtimes.json"),
+	}
+	first := NewWithConfig(cfg)
+	if err := first.EnsureInstance(testContext(t), "m1"); err != nil {
+		t.Fatalf("EnsureInstance: %v", err)
+	}
+	before := waitInstance(t, first, "m1", func(st types.InstanceStatus) bool { return st.PID > 0 })
+	_ = first.Close()
+
+	cfg.Slots = 4 // adds --parallel 4 to the command line
+	second := NewWithConfig(cfg)
+	defer second.StopAllInstances()
+	if ids := second.AdoptRuntimes(); len(ids) != 0 {
+		t.Fatalf("adopted %v", ids)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for processAlive(before.PID) {
+		if time.Now().After(deadline) {
+			t.Fatalf("stale runtime %d still running", before.PID)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if len(second.Status().Instances) != 0 {
+		t.Fatalf("unexpected instances: %+v", second.Status().Instances)
+	}
+}
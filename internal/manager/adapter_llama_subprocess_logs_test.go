@@ -0,0 +1,56 @@
+//go:build integration
+// +build integration
+
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"modeld/pkg/types"
+)
+
+func TestSubprocessOutputIsCaptured(t *testing.T) {
+	bin := buildTestBinary(t)
+	dir := t.TempDir()
+	m := NewWithConfig(ManagerConfig{
+		Registry:       []types.Model{{ID: "vendor/m1", Path: "m1.gguf"}},
+		DefaultModel:   "vendor/m1",
+		SpawnLlama:     true,
+		LlamaBin:       bin,
+		LlamaHost:      "127.0.0.1",
+		LlamaPortStart: 31260,
+		LlamaPortEnd:   31270,
+		LlamaLogDir:    dir,
+	})
+	defer m.StopAllInstances()
+	if err := m.EnsureInstance(testContext(t), "vendor/m1"); err != nil {
+		t.Fatalf("EnsureInstance: %v", err)
+	}
+	st := waitInstance(t, m, "vendor/m1", func(st types.InstanceStatus) bool { return st.PID > 0 })
+
+	lines, err := m.InstanceLogs("vendor/m1", 0)
+	if err != nil {
+		t.Fatalf("InstanceLogs: %v", err)
+	}
+	var stdout, stderr bool
+	for _, l := range lines {
+		if l.PID != st.PID {
+			t.Fatalf("line from pid %d, instance pid %d", l.PID, st.PID)
+		}
+		stdout = stdout || (l.Stream == "stdout" && strings.Contains(l.Line, "fake llama-server"))
+		stderr = stderr || (l.Stream == "stderr" && strings.Contains(l.Line, "server is listening"))
+	}
+	if !stdout || !stderr {
+		t.Fatalf("missing output: %+v", lines)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "vendor_m1.log"))
+	if err != nil || !strings.Contains(string(b), "server is listening") {
+		t.Fatalf("log file: %q (%v)", b, err)
+	}
+	if _, err := m.InstanceLogs("nope", 0); !IsModelNotFound(err) {
+		t.Fatalf("expected model not found, got %v", err)
+	}
+}
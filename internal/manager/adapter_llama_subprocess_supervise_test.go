@@ -0,0 +1,97 @@
+//go:build integration
+// +build integration
+
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"modeld/pkg/types"
+)
+
+// waitInstance polls /status until cond holds for the instance of id.
+func waitInstance(t *testing.T, m *Manager, id string, cond func(types.InstanceStatus) bool) types.InstanceStatus {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		for _, st := range m.Status().Instances {
+			if st.ModelID == id && cond(st) {
+				return st
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s: %+v", id, m.Status().Instances)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestSubprocessCrashIsRestarted(t *testing.T) {
+	bin := buildTestBinary(t)
+	m := NewWithConfig(ManagerConfig{
+		Registry:            []types.Model{{ID: "m", Path: "m.gguf"}},
+		SpawnLlama:          true,
+		LlamaBin:            bin,
+		LlamaHost:           "127.0.0.1",
+		LlamaPortStart:      31500,
+		LlamaPortEnd:        31510,
+		SpawnRestartBackoff: 50 * time.Millisecond,
+	})
+	defer m.Close()
+	pub := NewMemoryPublisher()
+	m.SetEventPublisher(pub)
+	if err := m.EnsureInstance(testCtx(t), "m"); err != nil {
+		t.Fatalf("EnsureInstance: %v", err)
+	}
+	sa := m.adapter.(*llamaSubprocessAdapter)
+	sa.mu.Lock()
+	first := sa.procs["m.gguf"]
+	sa.mu.Unlock()
+	_ = first.cmd.Process.Kill()
+
+	st := waitInstance(t, m, "m", func(st types.InstanceStatus) bool { return st.Restarts == 1 })
+	if st.State != string(StateReady) || st.PID == 0 || st.PID == first.pid {
+		t.Fatalf("unexpected status after restart: %+v", st)
+	}
+	var crash, restart bool
+	for _, e := range pub.Events() {
+		crash = crash || e.Name == "spawn_crash"
+		restart = restart || e.Name == "spawn_restart"
+	}
+	if !crash || !restart {
+		t.Fatalf("expected spawn_crash and spawn_restart events, got %+v", pub.Events())
+	}
+}
+
+func TestSubprocessCrashLoopStopsRestarts(t *testing.T) {
+	bin := buildTestBinary(t)
+	m := NewWithConfig(ManagerConfig{
+		Registry:          []types.Model{{ID: "m", Path: "m.gguf"}},
+		SpawnLlama:        true,
+		LlamaBin:          bin,
+		LlamaHost:         "127.0.0.1",
+		LlamaPortStart:    31520,
+		LlamaPortEnd:      31530,
+		SpawnRestartLimit: -1,
+	})
+	defer m.Close()
+	if err := m.EnsureInstance(testCtx(t), "m"); err != nil {
+		t.Fatalf("EnsureInstance: %v", err)
+	}
+	sa := m.adapter.(*llamaSubprocessAdapter)
+	sa.mu.Lock()
+	p := sa.procs["m.gguf"]
+	sa.mu.Unlock()
+	_ = p.cmd.Process.Kill()
+
+	st := waitInstance(t, m, "m", func(st types.InstanceStatus) bool { return st.State == string(StateError) })
+	if st.Restarting || st.Error == "" {
+		t.Fatalf("expected a final error without restart: %+v", st)
+	}
+	// A new request loads the model again.
+	if err := m.EnsureInstance(testCtx(t), "m"); err != nil {
+		t.Fatalf("EnsureInstance after crash: %v", err)
+	}
+	waitInstance(t, m, "m", func(st types.InstanceStatus) bool { return st.State == string(StateReady) && st.PID != 0 })
+}
@@ -0,0 +1,117 @@
+package manager
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+
+	"modeld/pkg/types"
+)
+
+// The alias table maps logical model names (chat-small, coder) to registry
+// models. Unlike manifest aliases, which are extra names of one model, an
+// alias may split traffic between several models by weight, and can be
+// changed at runtime through the admin API. Aliases are resolved by Infer
+// only; admin operations take registry IDs.
+
+// SetAlias creates or replaces an alias. Targets must name registry models
+// (IDs or manifest aliases, stored as IDs), and the name must not shadow a
+// registry model.
+func (m *Manager) SetAlias(a types.ModelAlias) (types.ModelAlias, error) {
+	a.Name = strings.TrimSpace(a.Name)
+	if a.Name == "" {
+		return types.ModelAlias{}, ErrInvalidRequest("alias name is required")
+	}
+	if _, ok := m.getModelByID(a.Name); ok {
+		return types.ModelAlias{}, ErrInvalidRequest(fmt.Sprintf("alias %q shadows a registry model", a.Name))
+	}
+	if len(a.Targets) == 0 {
+		return types.ModelAlias{}, ErrInvalidRequest(fmt.Sprintf("alias %q has no targets", a.Name))
+	}
+	targets := make([]types.AliasTarget, 0, len(a.Targets))
+	for _, t := range a.Targets {
+		if t.Weight < 0 {
+			return types.ModelAlias{}, ErrInvalidRequest(fmt.Sprintf("alias %q: negative weight for %q", a.Name, t.Model))
+		}
+		mdl, ok := m.getModelByID(strings.TrimSpace(t.Model))
+		if !ok {
+			return types.ModelAlias{}, ErrModelNotFound(t.Model)
+		}
+		targets = append(targets, types.AliasTarget{Model: mdl.ID, Weight: t.Weight})
+	}
+	a.Targets = targets
+
+	m.aliasMu.Lock()
+	if m.aliasTable == nil {
+		m.aliasTable = make(map[string]types.ModelAlias)
+	}
+	m.aliasTable[a.Name] = a
+	m.aliasMu.Unlock()
+	m.publisher.Publish(Event{Name: "alias_set", ModelID: a.Name, Fields: map[string]any{"targets": aliasTargetsString(a.Targets)}})
+	return a, nil
+}
+
+// DeleteAlias removes an alias. Unknown names return a model-not-found error.
+func (m *Manager) DeleteAlias(name string) error {
+	m.aliasMu.Lock()
+	_, ok := m.aliasTable[name]
+	delete(m.aliasTable, name)
+	m.aliasMu.Unlock()
+	if !ok {
+		return ErrModelNotFound(name)
+	}
+	m.publisher.Publish(Event{Name: "alias_deleted", ModelID: name, Fields: map[string]any{}})
+	return nil
+}
+
+// ListAliases returns the alias table sorted by name.
+func (m *Manager) ListAliases() []types.ModelAlias {
+	m.aliasMu.RLock()
+	out := make([]types.ModelAlias, 0, len(m.aliasTable))
+	for _, a := range m.aliasTable {
+		a.Targets = slices.Clone(a.Targets)
+		out = append(out, a)
+	}
+	m.aliasMu.RUnlock()
+	slices.SortFunc(out, func(a, b types.ModelAlias) int { return strings.Compare(a.Name, b.Name) })
+	return out
+}
+
+// resolveAlias maps an alias to one of its targets, picked by weight; other
+// names are returned unchanged.
+func (m *Manager) resolveAlias(name string) (string, bool) {
+	m.aliasMu.RLock()
+	a, ok := m.aliasTable[name]
+	m.aliasMu.RUnlock()
+	if !ok {
+		return name, false
+	}
+	total := 0
+	for _, t := range a.Targets {
+		total += t.Weight
+	}
+	intN := m.aliasIntN
+	if intN == nil {
+		intN = rand.IntN
+	}
+	if total == 0 {
+		return a.Targets[intN(len(a.Targets))].Model, true
+	}
+	n := intN(total)
+	for _, t := range a.Targets {
+		if n < t.Weight {
+			return t.Model, true
+		}
+		n -= t.Weight
+	}
+	return a.Targets[len(a.Targets)-1].Model, true
+}
+
+func aliasTargetsString(ts []types.AliasTarget) string {
+	parts := make([]string, len(ts))
+	for i, t := range ts {
+		parts[i] = fmt.Sprintf("%s:%d", t.Model, t.Weight)
+	}
+	return strings.Join(parts, ",")
+}
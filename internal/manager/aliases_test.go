@@ -0,0 +1,128 @@
+package manager
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"modeld/pkg/types"
+)
+
+func aliasTestManager(t *testing.T) *Manager {
+	t.Helper()
+	m := NewWithConfig(ManagerConfig{Registry: []types.Model{
+		{ID: "small-v1.gguf", Path: "small-v1.gguf"},
+		{ID: "small-v2.gguf", Path: "small-v2.gguf", Aliases: []string{"v2"}},
+	}})
+	m.adapter = &fakeAdapter{tokens: []string{"ok"}}
+	return m
+}
+
+func TestSetAlias_Validation(t *testing.T) {
+	m := aliasTestManager(t)
+	cases := []struct {
+		name  string
+		alias types.ModelAlias
+		check func(error) bool
+	}{
+		{"empty name", types.ModelAlias{Targets: []types.AliasTarget{{Model: "v2"}}}, IsInvalidRequest},
+		{"shadows model", types.ModelAlias{Name: "small-v1.gguf", Targets: []types.AliasTarget{{Model: "v2"}}}, IsInvalidRequest},
+		{"shadows manifest alias", types.ModelAlias{Name: "v2", Targets: []types.AliasTarget{{Model: "small-v1.gguf"}}}, IsInvalidRequest},
+		{"no targets", types.ModelAlias{Name: "chat"}, IsInvalidRequest},
+		{"negative weight", types.ModelAlias{Name: "chat", Targets: []types.AliasTarget{{Model: "v2", Weight: -1}}}, IsInvalidRequest},
+		{"unknown target", types.ModelAlias{Name: "chat", Targets: []types.AliasTarget{{Model: "nope"}}}, IsModelNotFound},
+	}
+	for _, c := range cases {
+		if _, err := m.SetAlias(c.alias); !c.check(err) {
+			t.Errorf("%s: err = %v", c.name, err)
+		}
+	}
+	if got := m.ListAliases(); len(got) != 0 {
+		t.Fatalf("invalid aliases were stored: %+v", got)
+	}
+
+	// Targets are stored by registry ID; the list is sorted by name.
+	if _, err := m.SetAlias(types.ModelAlias{Name: "zeta", Targets: []types.AliasTarget{{Model: "small-v1.gguf"}}}); err != nil {
+		t.Fatal(err)
+	}
+	a, err := m.SetAlias(types.ModelAlias{Name: "chat", Targets: []types.AliasTarget{{Model: "v2"}}})
+	if err != nil || a.Targets[0].Model != "small-v2.gguf" {
+		t.Fatalf("SetAlias = %+v, %v", a, err)
+	}
+	if got := m.ListAliases(); len(got) != 2 || got[0].Name != "chat" || got[1].Name != "zeta" {
+		t.Fatalf("ListAliases = %+v", got)
+	}
+	if err := m.DeleteAlias("zeta"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.DeleteAlias("zeta"); !IsModelNotFound(err) {
+		t.Fatalf("second delete err = %v", err)
+	}
+}
+
+func TestResolveAlias_WeightedSplit(t *testing.T) {
+	m := aliasTestManager(t)
+	if _, err := m.SetAlias(types.ModelAlias{Name: "chat", Targets: []types.AliasTarget{
+		{Model: "small-v1.gguf", Weight: 90},
+		{Model: "small-v2.gguf", Weight: 10},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	for n, want := range map[int]string{0: "small-v1.gguf", 89: "small-v1.gguf", 90: "small-v2.gguf", 99: "small-v2.gguf"} {
+		m.aliasIntN = func(total int) int {
+			if total != 100 {
+				t.Fatalf("total weight = %d", total)
+			}
+			return n
+		}
+		if got, ok := m.resolveAlias("chat"); !ok || got != want {
+			t.Errorf("pick %d -> %s, want %s", n, got, want)
+		}
+	}
+	if got, ok := m.resolveAlias("small-v1.gguf"); ok || got != "small-v1.gguf" {
+		t.Fatalf("non-alias resolved to %s", got)
+	}
+
+	// Zero weights split evenly.
+	if _, err := m.SetAlias(types.ModelAlias{Name: "even", Targets: []types.AliasTarget{{Model: "small-v1.gguf"}, {Model: "small-v2.gguf"}}}); err != nil {
+		t.Fatal(err)
+	}
+	m.aliasIntN = func(n int) int { return n - 1 }
+	if got, _ := m.resolveAlias("even"); got != "small-v2.gguf" {
+		t.Fatalf("even pick = %s", got)
+	}
+}
+
+func TestInfer_AliasReportsResolvedModel(t *testing.T) {
+	m := aliasTestManager(t)
+	if _, err := m.SetAlias(types.ModelAlias{Name: "coder", Targets: []types.AliasTarget{{Model: "small-v2.gguf"}}}); err != nil {
+		t.Fatal(err)
+	}
+	var resolved string
+	ctx := WithResolvedModelHook(testCtx(t), func(id string) { resolved = id })
+	var buf bytes.Buffer
+	if err := m.Infer(ctx, types.InferRequest{Model: "coder", Prompt: "hi"}, &buf, nil); err != nil {
+		t.Fatalf("Infer: %v", err)
+	}
+	if resolved != "small-v2.gguf" {
+		t.Fatalf("hook got %q", resolved)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var final struct {
+		Done  bool   `json:"done"`
+		Model string `json:"model"`
+	}
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &final); err != nil || !final.Done || final.Model != "small-v2.gguf" {
+		t.Fatalf("final line = %s (%v)", lines[len(lines)-1], err)
+	}
+
+	// An allow-list naming the alias admits its targets.
+	ctx = WithAllowedModels(testCtx(t), []string{"coder"})
+	if err := m.Infer(ctx, types.InferRequest{Model: "coder", Prompt: "hi"}, &bytes.Buffer{}, nil); err != nil {
+		t.Fatalf("Infer with alias allow-list: %v", err)
+	}
+	if err := m.Infer(ctx, types.InferRequest{Model: "small-v1.gguf", Prompt: "hi"}, &bytes.Buffer{}, nil); !IsForbidden(err) {
+		t.Fatalf("expected forbidden, got %v", err)
+	}
+}
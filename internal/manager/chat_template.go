@@ -0,0 +1,330 @@
+package manager
+
+import (
+	"fmt"
+	"strings"
+
+	"modeld/pkg/types"
+)
+
+// chatTemplate renders a chat message list into a raw prompt for one prompt
+// format. Stop lists end-of-turn markers that are added to the request's stop
+// sequences so generation ends at the assistant turn boundary.
+//
+// Roles are pasted into special-token markers, so messages must pass
+// ValidateChatMessages before rendering; renderChat strips the markers from
+// message content.
+//
+// BOS tokens (<s>, <|begin_of_text|>) are intentionally omitted: llama-server
+// adds them during tokenization and a second copy degrades output.
+type chatTemplate struct {
+	Name   string
+	Stop   []string
+	render func(msgs []types.ChatMessage) string
+}
+
+// Built-in templates keyed by name.
+var chatTemplates = map[string]chatTemplate{
+	"chatml":  {Name: "chatml", Stop: []string{"<|im_end|>"}, render: renderChatML},
+	"llama2":  {Name: "llama2", Stop: []string{"</s>"}, render: renderLlama2},
+	"llama3":  {Name: "llama3", Stop: []string{"<|eot_id|>"}, render: renderLlama3},
+	"mistral": {Name: "mistral", Stop: []string{"</s>"}, render: renderMistral},
+	"gemma":   {Name: "gemma", Stop: []string{"<end_of_turn>"}, render: renderGemma},
+	"phi3":    {Name: "phi3", Stop: []string{"<|end|>"}, render: renderPhi3},
+	"zephyr":  {Name: "zephyr", Stop: []string{"</s>"}, render: renderZephyr},
+	"plain":   {Name: "plain", render: renderPlain},
+}
+
+// defaultChatTemplate is used when nothing more specific is known.
+const defaultChatTemplate = "plain"
+
+// familyTemplates maps model families (types.Model.Family, or names guessed
+// from the model ID) to template names. Longer keys are matched first when
+// guessing from IDs, so "codellama" wins over "llama-2". The bare "llama"
+// architecture is shared by Llama 2, Llama 3 and many fine-tunes, so it is
+// deliberately absent.
+var familyTemplates = map[string]string{
+	"llama3":     "llama3",
+	"llama-3":    "llama3",
+	"llama2":     "llama2",
+	"llama-2":    "llama2",
+	"codellama":  "llama2",
+	"mistral":    "mistral",
+	"mixtral":    "mistral",
+	"gemma":      "gemma",
+	"gemma2":     "gemma",
+	"phi3":       "phi3",
+	"phi-3":      "phi3",
+	"qwen":       "chatml",
+	"qwen2":      "chatml",
+	"yi":         "chatml",
+	"openhermes": "chatml",
+	"chatml":     "chatml",
+	"tinyllama":  "zephyr",
+	"zephyr":     "zephyr",
+}
+
+// chatControlTokens are the turn markers of the built-in templates. They are
+// removed from message content so a message cannot open or close turns.
+var chatControlTokens = []string{
+	"<|im_start|>", "<|im_end|>",
+	"<|begin_of_text|>", "<|start_header_id|>", "<|end_header_id|>", "<|eot_id|>",
+	"<s>", "</s>", "[INST]", "[/INST]", "<<SYS>>", "<</SYS>>",
+	"<start_of_turn>", "<end_of_turn>",
+	"<|system|>", "<|user|>", "<|assistant|>", "<|end|>",
+}
+
+var chatControlStripper = func() *strings.Replacer {
+	pairs := make([]string, 0, 2*len(chatControlTokens))
+	for _, tok := range chatControlTokens {
+		pairs = append(pairs, tok, "")
+	}
+	return strings.NewReplacer(pairs...)
+}()
+
+// stripControlTokens removes chatControlTokens from s, repeating until none
+// remain so that removal cannot splice a new marker together.
+func stripControlTokens(s string) string {
+	for {
+		out := chatControlStripper.Replace(s)
+		if out == s {
+			return out
+		}
+		s = out
+	}
+}
+
+// chatRoles are the message roles accepted for rendering.
+var chatRoles = map[string]bool{"system": true, "user": true, "assistant": true, "tool": true}
+
+// ValidateChatMessages rejects messages whose role is not one of system,
+// user, assistant or tool. Anything else could smuggle turn markers into the
+// rendered prompt.
+func ValidateChatMessages(msgs []types.ChatMessage) error {
+	for i, msg := range msgs {
+		if !chatRoles[msg.Role] {
+			return ErrInvalidRequest(fmt.Sprintf("messages[%d]: unsupported role %q", i, msg.Role))
+		}
+	}
+	return nil
+}
+
+// IsKnownChatTemplate reports whether name is a built-in chat template.
+func IsKnownChatTemplate(name string) bool {
+	_, ok := chatTemplates[strings.ToLower(strings.TrimSpace(name))]
+	return ok
+}
+
+// templateForFamily returns the template name for a family, if known.
+func templateForFamily(family string) (string, bool) {
+	name, ok := familyTemplates[strings.ToLower(strings.TrimSpace(family))]
+	return name, ok
+}
+
+// guessTemplateFromID derives a template name from well-known substrings of a
+// model ID or filename (e.g. "Meta-Llama-3-8B-Instruct.Q4_K_M.gguf").
+func guessTemplateFromID(id string) (string, bool) {
+	lower := strings.ToLower(id)
+	best, bestLen := "", 0
+	bestKey := ""
+	for key, name := range familyTemplates {
+		// Very short keys (e.g. "yi") match too many unrelated names.
+		if len(key) < 4 || !strings.Contains(lower, key) {
+			continue
+		}
+		if len(key) > bestLen || (len(key) == bestLen && key < bestKey) {
+			best, bestLen, bestKey = name, len(key), key
+		}
+	}
+	return best, bestLen > 0
+}
+
+// detectEmbeddedTemplate maps a Jinja chat template embedded in GGUF metadata
+// (tokenizer.chat_template) to a built-in template by its turn markers.
+func detectEmbeddedTemplate(jinja string) (string, bool) {
+	switch {
+	case jinja == "":
+		return "", false
+	case strings.Contains(jinja, "<|im_start|>"):
+		return "chatml", true
+	case strings.Contains(jinja, "<|start_header_id|>"):
+		return "llama3", true
+	case strings.Contains(jinja, "<start_of_turn>"):
+		return "gemma", true
+	case strings.Contains(jinja, "<|user|>") && strings.Contains(jinja, "<|end|>"):
+		return "phi3", true
+	case strings.Contains(jinja, "<|user|>"):
+		return "zephyr", true
+	case strings.Contains(jinja, "<<SYS>>"):
+		return "llama2", true
+	case strings.Contains(jinja, "[INST]"):
+		return "mistral", true
+	}
+	return "", false
+}
+
+// chatTemplateFor resolves the template for a model. Precedence: configured
+// per-model override, the model's ChatTemplate field, the template embedded in
+// the GGUF file (from the registry's parsed header, or read from the file when
+// enabled), the model family, a guess from the model ID, and finally the
+// configured default.
+func (m *Manager) chatTemplateFor(mdl types.Model) chatTemplate {
+	candidates := []string{m.chatTemplateOverrides[mdl.ID], mdl.ChatTemplate}
+	for _, name := range candidates {
+		if t, ok := chatTemplates[strings.ToLower(strings.TrimSpace(name))]; ok {
+			return t
+		}
+	}
+	embedded := ""
+	if mdl.GGUF != nil {
+		embedded = mdl.GGUF.ChatTemplate
+	} else if m.chatTemplateFromGGUF {
+		embedded = m.embeddedChatTemplate(mdl.Path)
+	}
+	if name, ok := detectEmbeddedTemplate(embedded); ok {
+		return chatTemplates[name]
+	}
+	if name, ok := templateForFamily(mdl.Family); ok {
+		return chatTemplates[name]
+	}
+	if name, ok := guessTemplateFromID(mdl.ID); ok {
+		return chatTemplates[name]
+	}
+	if t, ok := chatTemplates[m.defaultChatTemplate]; ok {
+		return t
+	}
+	return chatTemplates[defaultChatTemplate]
+}
+
+// renderChat renders msgs with the model's template and returns the prompt and
+// the template's stop sequences. Control tokens are stripped from content.
+func (m *Manager) renderChat(mdl types.Model, msgs []types.ChatMessage) (string, []string) {
+	t := m.chatTemplateFor(mdl)
+	clean := make([]types.ChatMessage, len(msgs))
+	for i, msg := range msgs {
+		msg.Content = stripControlTokens(msg.Content)
+		clean[i] = msg
+	}
+	return t.render(clean), t.Stop
+}
+
+// splitSystem separates leading system messages (joined) from the rest, for
+// formats without a system role. A system-only conversation is returned as a
+// single user turn.
+func splitSystem(msgs []types.ChatMessage) (string, []types.ChatMessage) {
+	var sys []string
+	i := 0
+	for ; i < len(msgs) && msgs[i].Role == "system"; i++ {
+		sys = append(sys, msgs[i].Content)
+	}
+	joined := strings.Join(sys, "\n\n")
+	if i == len(msgs) {
+		return "", []types.ChatMessage{{Role: "user", Content: joined}}
+	}
+	return joined, msgs[i:]
+}
+
+func renderChatML(msgs []types.ChatMessage) string {
+	var sb strings.Builder
+	for _, msg := range msgs {
+		sb.WriteString("<|im_start|>" + msg.Role + "\n" + msg.Content + "<|im_end|>\n")
+	}
+	sb.WriteString("<|im_start|>assistant\n")
+	return sb.String()
+}
+
+func renderLlama3(msgs []types.ChatMessage) string {
+	var sb strings.Builder
+	for _, msg := range msgs {
+		sb.WriteString("<|start_header_id|>" + msg.Role + "<|end_header_id|>\n\n" + strings.TrimSpace(msg.Content) + "<|eot_id|>")
+	}
+	sb.WriteString("<|start_header_id|>assistant<|end_header_id|>\n\n")
+	return sb.String()
+}
+
+func renderLlama2(msgs []types.ChatMessage) string {
+	sys, rest := splitSystem(msgs)
+	var sb strings.Builder
+	first := true
+	for _, msg := range rest {
+		if msg.Role == "assistant" {
+			sb.WriteString(" " + strings.TrimSpace(msg.Content) + " </s>")
+			continue
+		}
+		sb.WriteString("[INST] ")
+		if first && sys != "" {
+			sb.WriteString("<<SYS>>\n" + sys + "\n<</SYS>>\n\n")
+		}
+		first = false
+		sb.WriteString(strings.TrimSpace(msg.Content) + " [/INST]")
+	}
+	return sb.String()
+}
+
+func renderMistral(msgs []types.ChatMessage) string {
+	sys, rest := splitSystem(msgs)
+	var sb strings.Builder
+	first := true
+	for _, msg := range rest {
+		if msg.Role == "assistant" {
+			sb.WriteString(strings.TrimSpace(msg.Content) + "</s>")
+			continue
+		}
+		content := strings.TrimSpace(msg.Content)
+		if first && sys != "" {
+			content = sys + "\n\n" + content
+		}
+		first = false
+		sb.WriteString("[INST] " + content + " [/INST]")
+	}
+	return sb.String()
+}
+
+func renderGemma(msgs []types.ChatMessage) string {
+	sys, rest := splitSystem(msgs)
+	var sb strings.Builder
+	first := true
+	for _, msg := range rest {
+		role := "user"
+		if msg.Role == "assistant" {
+			role = "model"
+		}
+		content := strings.TrimSpace(msg.Content)
+		if first && sys != "" && role == "user" {
+			content = sys + "\n\n" + content
+			first = false
+		}
+		sb.WriteString("<start_of_turn>" + role + "\n" + content + "<end_of_turn>\n")
+	}
+	sb.WriteString("<start_of_turn>model\n")
+	return sb.String()
+}
+
+func renderPhi3(msgs []types.ChatMessage) string {
+	var sb strings.Builder
+	for _, msg := range msgs {
+		sb.WriteString("<|" + msg.Role + "|>\n" + msg.Content + "<|end|>\n")
+	}
+	sb.WriteString("<|assistant|>\n")
+	return sb.String()
+}
+
+func renderZephyr(msgs []types.ChatMessage) string {
+	var sb strings.Builder
+	for _, msg := range msgs {
+		sb.WriteString("<|" + msg.Role + "|>\n" + msg.Content + "</s>\n")
+	}
+	sb.WriteString("<|assistant|>\n")
+	return sb.String()
+}
+
+// renderPlain produces a neutral "Role: content" transcript ending with an
+// open assistant turn; used when the model's format is unknown.
+func renderPlain(msgs []types.ChatMessage) string {
+	var sb strings.Builder
+	for _, msg := range msgs {
+		sb.WriteString(strings.ToUpper(msg.Role[:1]) + msg.Role[1:] + ": " + msg.Content + "\n")
+	}
+	sb.WriteString("Assistant:")
+	return sb.String()
+}
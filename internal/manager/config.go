@@ -1,8 +1,11 @@
 package manager
 
 import (
+	"strings"
 	"time"
 
+	"modeld/internal/common/fsutil"
+	"modeld/internal/registry"
 	"modeld/pkg/types"
 )
 
@@ -22,6 +25,24 @@ type ManagerConfig struct {
 	MaxQueueDepth int
 	MaxWait       time.Duration
 	DrainTimeout  time.Duration
+	// Parallel generation slots per instance (concurrent in-flight requests).
+	// Slots applies to all models (default 1); ModelSlots overrides it per
+	// model ID. In spawn mode the value is passed as --parallel.
+	Slots      int
+	ModelSlots map[string]int
+	// Admission scheduling. PriorityQueueDepth caps waiting requests per
+	// priority class ("interactive", "normal", "batch"; default MaxQueueDepth
+	// each). PriorityAging promotes a waiting request one class per interval
+	// (default 5s; negative disables). TenantWeights sets weighted fair
+	// queuing shares per tenant (default weight 1).
+	PriorityQueueDepth map[string]int
+	PriorityAging      time.Duration
+	TenantWeights      map[string]float64
+	// RegistryLoader rescans the models for ReloadRegistry (optional).
+	RegistryLoader func() ([]types.Model, error)
+	// RegistryDuplicates reports the files the last scan dropped or renamed
+	// as duplicates; Preflight lists them (optional).
+	RegistryDuplicates func() []registry.Duplicate
 	// HTTP llama server configuration
 	LlamaServerURL      string
 	LlamaAPIKey         string
@@ -38,6 +59,37 @@ type ManagerConfig struct {
 	LlamaCtxSize   int
 	LlamaNGL       int
 	LlamaExtraArgs []string
+	// ModelProfiles overrides the llama-server settings above per model ID
+	// (or alias), on top of any profile from the manifest.
+	ModelProfiles map[string]types.RuntimeProfile
+	// Crash supervision: a runtime that exits after becoming ready is
+	// restarted after SpawnRestartBackoff (default 1s, doubling up to 30s).
+	// More than SpawnRestartLimit consecutive crashes or failed restarts
+	// (default 5; negative disables restarts) leave the instance in error.
+	SpawnRestartLimit   int
+	SpawnRestartBackoff time.Duration
+	// Runtime output: lines kept in memory per model (default 1000) and,
+	// when LlamaLogDir is set, a log file per model rotated at LlamaLogMaxMB
+	// (default 10) keeping LlamaLogBackups old files (default 3; negative
+	// keeps none).
+	LlamaLogLines   int
+	LlamaLogDir     string
+	LlamaLogMaxMB   int
+	LlamaLogBackups int
+	// SpawnStateFile records the ready runtimes (model path, PID, port,
+	// command line) so a restarted modeld can adopt them (AdoptRuntimes)
+	// instead of loading the models again. When set, Close leaves the
+	// runtimes running and they are started in their own process group.
+	SpawnStateFile string
+	// LRUStateFile keeps the last-use history of models across restarts,
+	// for Prewarm (optional).
+	LRUStateFile string
+	// Chat templating: per-model template overrides (model ID -> template
+	// name), the fallback template, and whether to honor the template
+	// embedded in GGUF metadata (tokenizer.chat_template).
+	ChatTemplates        map[string]string
+	DefaultChatTemplate  string
+	ChatTemplateFromGGUF bool
 }
 
 // NewWithConfig constructs a Manager from ManagerConfig.
@@ -49,6 +101,10 @@ func NewWithConfig(cfg ManagerConfig) *Manager {
 		marginMB:     cfg.MarginMB,
 		defaultModel: cfg.DefaultModel,
 		instances:    make(map[string]*Instance),
+
+		chatTemplateOverrides: cfg.ChatTemplates,
+		defaultChatTemplate:   strings.ToLower(strings.TrimSpace(cfg.DefaultChatTemplate)),
+		chatTemplateFromGGUF:  cfg.ChatTemplateFromGGUF,
 	}
 	// Apply defaults if unset
 	if cfg.MaxQueueDepth <= 0 {
@@ -66,6 +122,29 @@ func NewWithConfig(cfg ManagerConfig) *Manager {
 	} else {
 		m.drainTimeout = cfg.DrainTimeout
 	}
+	m.defaultModel = m.canonicalID(cfg.DefaultModel)
+	m.registryLoader = cfg.RegistryLoader
+	m.registryDuplicates = cfg.RegistryDuplicates
+	m.baseProfile, m.modelProfiles = globalProfile(cfg), cfg.ModelProfiles
+	if p := strings.TrimSpace(cfg.LRUStateFile); p != "" {
+		if exp, err := fsutil.ExpandHome(p); err == nil {
+			p = exp
+		}
+		m.lruPath = p
+		m.loadLRUMetadata()
+	}
+	m.slots = cfg.Slots
+	m.modelSlots = cfg.ModelSlots
+	m.schedCfg = schedConfig{aging: cfg.PriorityAging, weights: cfg.TenantWeights}
+	if cfg.PriorityAging == 0 {
+		m.schedCfg.aging = defaultPriorityAging
+	}
+	for p := range m.schedCfg.maxDepth {
+		m.schedCfg.maxDepth[p] = m.maxQueueDepth
+		if d := cfg.PriorityQueueDepth[Priority(p).String()]; d > 0 {
+			m.schedCfg.maxDepth[p] = d
+		}
+	}
 	// Adapter selection
 	if cfg.SpawnLlama && cfg.LlamaBin != "" {
 		m.adapter = NewLlamaSubprocessAdapter(cfg)
@@ -80,11 +159,14 @@ func NewWithConfig(cfg ManagerConfig) *Manager {
 	}
 	m.startTime = time.Now()
 	// Initialize event publisher and wire into adapter if needed
+	managerVRAMBudgetMB.Set(float64(m.budgetMB))
+	m.events = NewBroadcaster(0)
 	if m.publisher == nil {
-		m.publisher = noopPublisher{}
+		m.publisher = m.events
 	}
 	if sa, ok := m.adapter.(*llamaSubprocessAdapter); ok {
 		sa.setPublisher(m.publisher)
+		sa.setSpawnHooks(spawnHooks{crashed: m.onSpawnCrash, restarted: m.onSpawnRestart})
 	}
 	return m
 }
@@ -7,11 +7,17 @@
 //   - errors.go: error types and helpers (IsTooBusy, IsModelNotFound).
 //   - helpers.go: small utilities (model lookup, VRAM estimation).
 //   - queue_admission.go: per-instance queueing and generation admission.
+//   - scheduler.go: priority classes, fair queuing across tenants and aging.
+//   - request_context.go: per-request scheduling context (WithPriority, WithTenant).
 //   - instance_ensure.go: EnsureInstance/EnsureModel lifecycle and loading.
 //   - instance_evict.go: eviction logic to fit within VRAM budget.
 //   - inference.go: inference API entry point and streaming behavior (MVP).
+//   - chat_template.go: chat message templating per model family.
 //   - status_report.go: Status/Snapshot reporting helpers.
-//   - ops_switch.go: operational stubs like Switch.
+//   - metrics.go: manager-level Prometheus metrics (modeld_manager_*).
+//   - ops.go: async operation tracking and cancellation (LoadModel, UnloadModel, Operation).
+//   - ops_switch.go: Switch, an async ensure recorded as an operation.
+//   - events.go, event_broadcast.go: event publishing and live fan-out (SubscribeEvents).
 //
 // Build tags and runtimes:
 //
@@ -8,8 +8,8 @@ import (
 func TestStatusCountsWarmupAndDraining(t *testing.T) {
 	m := NewWithConfig(ManagerConfig{})
 	m.mu.Lock()
-	m.instances["a"] = &Instance{ID: "a", State: StateLoading, LastUsed: time.Now(), EstVRAMMB: 10, genCh: make(chan struct{}, 1), queueCh: make(chan struct{}, 2)}
-	m.instances["b"] = &Instance{ID: "b", State: StateDraining, LastUsed: time.Now(), EstVRAMMB: 20, genCh: make(chan struct{}, 1), queueCh: make(chan struct{}, 2)}
+	m.instances["a"] = &Instance{ID: "a", State: StateLoading, LastUsed: time.Now(), EstVRAMMB: 10, sched: newScheduler(1, schedConfig{})}
+	m.instances["b"] = &Instance{ID: "b", State: StateDraining, LastUsed: time.Now(), EstVRAMMB: 20, sched: newScheduler(1, schedConfig{})}
 	m.mu.Unlock()
 	st := m.Status()
 	if st.WarmupsInProgress != 1 {
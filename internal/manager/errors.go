@@ -57,3 +57,45 @@ func IsBudgetExceeded(err error) bool {
     var e budgetExceededError
     return errors.As(err, &e)
 }
+
+// operationNotFoundError signals an unknown async operation ID.
+type operationNotFoundError struct{ id string }
+
+func (e operationNotFoundError) Error() string { return "operation not found: " + e.id }
+
+// ErrOperationNotFound constructs an operationNotFoundError.
+func ErrOperationNotFound(id string) error { return operationNotFoundError{id: id} }
+
+// IsOperationNotFound reports whether err indicates an unknown operation ID.
+func IsOperationNotFound(err error) bool {
+    var e operationNotFoundError
+    return errors.As(err, &e)
+}
+
+// invalidRequestError signals a malformed request parameter (HTTP 400).
+type invalidRequestError struct{ msg string }
+
+func (e invalidRequestError) Error() string { return e.msg }
+
+// ErrInvalidRequest constructs an invalidRequestError.
+func ErrInvalidRequest(msg string) error { return invalidRequestError{msg: msg} }
+
+// IsInvalidRequest reports whether err indicates an invalid request parameter.
+func IsInvalidRequest(err error) bool {
+    var e invalidRequestError
+    return errors.As(err, &e)
+}
+
+// forbiddenError signals that the caller may not use a model (HTTP 403).
+type forbiddenError struct{ modelID string }
+
+func (e forbiddenError) Error() string { return "model not allowed: " + e.modelID }
+
+// ErrForbidden constructs a forbiddenError for modelID.
+func ErrForbidden(modelID string) error { return forbiddenError{modelID: modelID} }
+
+// IsForbidden reports whether err indicates a model outside the caller's allow-list.
+func IsForbidden(err error) bool {
+    var e forbiddenError
+    return errors.As(err, &e)
+}
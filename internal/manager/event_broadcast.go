@@ -0,0 +1,116 @@
+package manager
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultSubscriberBuffer is the per-subscriber event buffer used when
+// NewBroadcaster is given a non-positive size.
+const defaultSubscriberBuffer = 256
+
+// Broadcaster is an EventPublisher that fans events out to any number of
+// subscribers. Each subscriber has a bounded buffer; when it is full, events
+// for that subscriber are dropped (and counted) rather than blocking the
+// manager.
+type Broadcaster struct {
+	mu   sync.RWMutex
+	subs map[*Subscription]struct{}
+	buf  int
+}
+
+// NewBroadcaster returns a Broadcaster whose subscribers buffer up to buf
+// events each.
+func NewBroadcaster(buf int) *Broadcaster {
+	if buf <= 0 {
+		buf = defaultSubscriberBuffer
+	}
+	return &Broadcaster{subs: make(map[*Subscription]struct{}), buf: buf}
+}
+
+// Subscription receives events from a Broadcaster until closed.
+type Subscription struct {
+	b       *Broadcaster
+	ch      chan Event
+	match   func(Event) bool
+	dropped atomic.Uint64
+	once    sync.Once
+}
+
+// Publish delivers e to every matching subscriber without blocking. Events
+// are stamped with the current time if Time is unset.
+func (b *Broadcaster) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for s := range b.subs {
+		if s.match != nil && !s.match(e) {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
+
+// Subscribe registers a subscriber. If match is non-nil, only events for which
+// it returns true are delivered. Callers must Close the subscription.
+func (b *Broadcaster) Subscribe(match func(Event) bool) *Subscription {
+	s := &Subscription{b: b, ch: make(chan Event, b.buf), match: match}
+	b.mu.Lock()
+	b.subs[s] = struct{}{}
+	b.mu.Unlock()
+	return s
+}
+
+// Subscribers returns the number of active subscriptions.
+func (b *Broadcaster) Subscribers() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs)
+}
+
+// C returns the channel on which events are delivered. It is closed by Close.
+func (s *Subscription) C() <-chan Event { return s.ch }
+
+// Dropped returns how many events were dropped because the buffer was full.
+func (s *Subscription) Dropped() uint64 { return s.dropped.Load() }
+
+// Close unsubscribes and closes the event channel. Safe to call multiple times.
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.b.mu.Lock()
+		delete(s.b.subs, s)
+		s.b.mu.Unlock()
+		close(s.ch)
+	})
+}
+
+// multiPublisher forwards events to several publishers in order.
+type multiPublisher []EventPublisher
+
+func (mp multiPublisher) Publish(e Event) {
+	for _, p := range mp {
+		p.Publish(e)
+	}
+}
+
+// SubscribeEvents subscribes to the manager's live event stream. Events are
+// delivered regardless of any publisher installed via SetEventPublisher.
+func (m *Manager) SubscribeEvents(match func(Event) bool) *Subscription {
+	m.mu.Lock()
+	if m.events == nil {
+		m.events = NewBroadcaster(0)
+	}
+	b := m.events
+	m.mu.Unlock()
+	return b.Subscribe(match)
+}
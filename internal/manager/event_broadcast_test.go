@@ -0,0 +1,66 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"modeld/pkg/types"
+)
+
+func TestBroadcaster_FanOutFilterAndDrop(t *testing.T) {
+	b := NewBroadcaster(2)
+	all := b.Subscribe(nil)
+	onlyA := b.Subscribe(func(e Event) bool { return e.ModelID == "a" })
+	defer onlyA.Close()
+
+	b.Publish(Event{Name: "x", ModelID: "a"})
+	b.Publish(Event{Name: "y", ModelID: "b"})
+	b.Publish(Event{Name: "z", ModelID: "a"}) // exceeds all's buffer of 2
+
+	if e := <-all.C(); e.Name != "x" || e.Time.IsZero() {
+		t.Fatalf("unexpected first event: %+v", e)
+	}
+	if e := <-all.C(); e.Name != "y" {
+		t.Fatalf("unexpected second event: %+v", e)
+	}
+	if all.Dropped() != 1 {
+		t.Fatalf("dropped=%d, want 1", all.Dropped())
+	}
+	if e := <-onlyA.C(); e.Name != "x" {
+		t.Fatalf("unexpected filtered event: %+v", e)
+	}
+	if e := <-onlyA.C(); e.Name != "z" || onlyA.Dropped() != 0 {
+		t.Fatalf("unexpected filtered event: %+v dropped=%d", e, onlyA.Dropped())
+	}
+
+	all.Close()
+	all.Close()
+	if _, ok := <-all.C(); ok {
+		t.Fatalf("expected closed channel")
+	}
+	if b.Subscribers() != 1 {
+		t.Fatalf("subscribers=%d, want 1", b.Subscribers())
+	}
+}
+
+func TestManager_SubscribeEventsAlongsidePublisher(t *testing.T) {
+	m := NewWithConfig(ManagerConfig{Registry: []types.Model{{ID: "m", Path: "m.gguf"}}})
+	pub := NewMemoryPublisher()
+	m.SetEventPublisher(pub)
+	sub := m.SubscribeEvents(func(e Event) bool { return e.Name == "ensure_ready" })
+	defer sub.Close()
+	if err := m.EnsureInstance(testCtx(t), "m"); err != nil {
+		t.Fatalf("EnsureInstance: %v", err)
+	}
+	select {
+	case e := <-sub.C():
+		if e.ModelID != "m" {
+			t.Fatalf("unexpected event: %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no event received")
+	}
+	if len(pub.Events()) == 0 {
+		t.Fatalf("installed publisher received no events")
+	}
+}
@@ -1,11 +1,16 @@
 package manager
 
+import "time"
+
 // Event represents a manager lifecycle event.
 // Minimal and stable: name + model ID and optional fields via key/values.
 type Event struct {
 	Name    string
 	ModelID string
 	Fields  map[string]any
+	// Time is stamped by the Broadcaster on fan-out; it is not serialized
+	// by the stdout/file publishers.
+	Time time.Time `json:"-"`
 }
 
 // EventPublisher receives events from the manager. Implementations should be
@@ -14,8 +14,8 @@ func TestEvictUntilFits_ReturnsBudgetExceededWhenNoIdleAndDoesNotFit(t *testing.
 	m.budgetMB = 1
 	m.marginMB = 0
 	// Seed a single busy instance so it's not idle (has queue/inflight)
-	inst := &Instance{ID: "m", State: StateReady, LastUsed: time.Now(), EstVRAMMB: 1, genCh: make(chan struct{}, 1), queueCh: make(chan struct{}, 1)}
-	inst.genCh <- struct{}{} // mark in-flight so it's non-idle
+	inst := &Instance{ID: "m", State: StateReady, LastUsed: time.Now(), EstVRAMMB: 1, sched: newScheduler(1, schedConfig{})}
+	inst.sched.inflight = 1 // mark in-flight so it's non-idle
 	m.instances["m"] = inst
 	m.mu.Unlock()
 	// Ask to evict until fits with requiredMB > budget, no idle instances present
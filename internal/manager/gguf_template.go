@@ -0,0 +1,12 @@
+package manager
+
+import "modeld/internal/registry"
+
+// embeddedChatTemplate returns the chat template embedded in the GGUF file at
+// path, or "" if absent or unreadable.
+func (m *Manager) embeddedChatTemplate(path string) string {
+	if info := m.ggufHeader(path); info != nil {
+		return info.String(registry.GGUFChatTemplateKey)
+	}
+	return ""
+}
@@ -1,32 +1,50 @@
 package manager
 
 import (
-	"os"
-
 	"modeld/pkg/types"
 )
 
-// Helper: find model in registry by id.
+// Helper: find model in registry by id or alias.
 func (m *Manager) getModelByID(id string) (types.Model, bool) {
+	m.regMu.RLock()
+	defer m.regMu.RUnlock()
 	for _, mdl := range m.registry {
 		if mdl.ID == id {
 			return mdl, true
 		}
 	}
+	for _, mdl := range m.registry {
+		for _, a := range mdl.Aliases {
+			if a == id {
+				return mdl, true
+			}
+		}
+	}
 	return types.Model{}, false
 }
 
-// Helper: estimate VRAM based on file size (MB). Returns 0 on error.
+// canonicalID maps a model alias to its registry ID. Unknown IDs are
+// returned unchanged so callers report them as not found.
+func (m *Manager) canonicalID(id string) string {
+	if mdl, ok := m.getModelByID(id); ok {
+		return mdl.ID
+	}
+	return id
+}
+
+// Helper: estimate VRAM (MB) for an instance of mdl; see estimateVRAM.
 func (m *Manager) estimateVRAMMB(mdl types.Model) int {
-	fi, err := os.Stat(mdl.Path)
-	if err != nil {
-		// If we cannot stat the file, return a conservative minimum of 1MB
-		// to avoid bypassing budget checks due to an unknown size.
-		return 1
+	return m.estimateVRAM(mdl, m.slotsFor(mdl.ID)).TotalMB
+}
+
+// slotsFor returns the number of parallel generation slots for a model: the
+// per-model override, else the global setting, else 1.
+func (m *Manager) slotsFor(modelID string) int {
+	if n := m.modelSlots[modelID]; n > 0 {
+		return n
 	}
-	mb := int(fi.Size() / (1024 * 1024))
-	if mb <= 0 {
-		mb = 1
+	if m.slots > 0 {
+		return m.slots
 	}
-	return mb
+	return 1
 }
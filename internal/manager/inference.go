@@ -9,6 +9,7 @@ import (
 	"log"
 	"runtime/debug"
 	"strings"
+	"time"
 
 	"modeld/pkg/types"
 )
@@ -47,19 +48,44 @@ func (m *Manager) Infer(ctx context.Context, req types.InferRequest, w io.Writer
 			return modelNotFoundError{id: "(unspecified)"}
 		}
 	}
+	if err := ValidateChatMessages(req.Messages); err != nil {
+		return err
+	}
 	// Ensure MaxTokens is sane (>0) to avoid adapter errors; allow adapter defaults if zero
 	if req.MaxTokens < 0 {
 		req.MaxTokens = 0
 	}
+	// The alias table picks a target, and manifest aliases resolve to the
+	// registry ID; an allow-list may name the requested or the resolved model.
+	requested := modelID
+	modelID, routed := m.resolveAlias(modelID)
+	modelID = m.canonicalID(modelID)
+	if !ModelAllowed(ctx, modelID) && !ModelAllowed(ctx, requested) {
+		return ErrForbidden(requested)
+	}
+	if routed {
+		managerAliasRequestsTotal.WithLabelValues(requested, modelID).Inc()
+	}
+	reportResolvedModel(ctx, modelID)
+	reqStart := time.Now()
+	if req.Priority != "" {
+		ctx = WithPriority(ctx, req.Priority)
+	}
+	if _, err := ParsePriority(priorityFromContext(ctx)); err != nil {
+		return err
+	}
 	if err := m.EnsureInstance(ctx, modelID); err != nil {
 		return fmt.Errorf("ensure instance %q: %w", modelID, err)
 	}
 	// Admission: per-instance FIFO queue, single in-flight
+	admitStart := time.Now()
 	release, err := m.beginGeneration(ctx, modelID)
 	if err != nil {
 		return fmt.Errorf("begin generation %q: %w", modelID, err)
 	}
 	defer release()
+	genStart := time.Now()
+	timing := types.InferMetrics{QueueWaitMs: durMs(genStart.Sub(admitStart))}
 
 	// Adapter-backed inference is the default; require an adapter.
 	if m.adapter == nil {
@@ -70,13 +96,21 @@ func (m *Manager) Infer(ctx context.Context, req types.InferRequest, w io.Writer
 	if !ok || strings.TrimSpace(mdl.Path) == "" {
 		return ErrModelNotFound(modelID)
 	}
+	// Render chat messages with the model's template when no raw prompt is given
+	prompt := req.Prompt
+	stop := req.Stop
+	if strings.TrimSpace(prompt) == "" && len(req.Messages) > 0 {
+		var tmplStop []string
+		prompt, tmplStop = m.renderChat(mdl, req.Messages)
+		stop = mergeStops(stop, tmplStop)
+	}
 	// Map request parameters to adapter params (basic mapping for now)
 	params := InferParams{
 		Temperature:   float32(req.Temperature),
 		TopP:          float32(req.TopP),
 		TopK:          req.TopK,
 		MaxTokens:     req.MaxTokens,
-		Stop:          req.Stop,
+		Stop:          stop,
 		Seed:          int(req.Seed),
 		RepeatPenalty: float32(req.RepeatPenalty),
 	}
@@ -99,21 +133,33 @@ func (m *Manager) Infer(ctx context.Context, req types.InferRequest, w io.Writer
 	}()
 
 	var b strings.Builder
+	streamed := 0
+	var firstTok, lastTok time.Time
 	onTok := func(tok string) error {
 		// Stop early if context is canceled
 		if err := ctx.Err(); err != nil {
 			return err
 		}
+		now := time.Now()
+		if firstTok.IsZero() {
+			firstTok = now
+			managerTTFT.WithLabelValues(modelID).Observe(now.Sub(reqStart).Seconds())
+		} else {
+			managerInterToken.WithLabelValues(modelID).Observe(now.Sub(lastTok).Seconds())
+		}
+		lastTok = now
 		line := tokenLineJSON(tok)
 		if err := writeAll(w, line); err != nil {
 			return err
 		}
 		b.WriteString(tok)
+		streamed++
 		safeFlush(flusher)
 		return nil
 	}
-	final, err := sess.Generate(ctx, req.Prompt, onTok)
+	final, err := sess.Generate(ctx, prompt, onTok)
 	if err != nil {
+		reportUsage(ctx, UsageReport{ModelID: modelID, Usage: Usage{CompletionTokens: streamed, TotalTokens: streamed}, GenerationTime: time.Since(genStart)})
 		// Prefer context error when applicable to aid callers
 		if errors.Is(err, context.Canceled) || errors.Is(ctx.Err(), context.Canceled) {
 			return context.Canceled
@@ -123,6 +169,27 @@ func (m *Manager) Infer(ctx context.Context, req types.InferRequest, w io.Writer
 		}
 		return fmt.Errorf("adapter generate: %w", err)
 	}
+	generated := final.Usage.CompletionTokens
+	if generated <= 0 {
+		generated = streamed
+	}
+	managerTokensTotal.WithLabelValues(modelID).Add(float64(generated))
+	usage := final.Usage
+	usage.CompletionTokens = generated
+	if usage.TotalTokens < usage.PromptTokens+generated {
+		usage.TotalTokens = usage.PromptTokens + generated
+	}
+	reportUsage(ctx, UsageReport{ModelID: modelID, Usage: usage, GenerationTime: time.Since(genStart)})
+	if !firstTok.IsZero() {
+		timing.TTFTMs = durMs(firstTok.Sub(reqStart))
+		if streamed > 1 {
+			timing.InterTokenMs = durMs(lastTok.Sub(firstTok)) / float64(streamed-1)
+		}
+	}
+	if genDur := time.Since(genStart); generated > 0 && genDur > 0 {
+		timing.TokensPerSecond = float64(generated) / genDur.Seconds()
+		managerTokensPerSecond.WithLabelValues(modelID).Observe(timing.TokensPerSecond)
+	}
 	// Compose final line
 	content := final.Content
 	if content == "" {
@@ -130,9 +197,11 @@ func (m *Manager) Infer(ctx context.Context, req types.InferRequest, w io.Writer
 	}
 	end := map[string]any{
 		"done":          true,
+		"model":         modelID,
 		"content":       content,
 		"finish_reason": final.FinishReason,
-		"usage":         final.Usage,
+		"usage":         usage,
+		"metrics":       timing,
 	}
 	jb, merr := json.Marshal(end)
 	if merr != nil {
@@ -146,6 +215,29 @@ func (m *Manager) Infer(ctx context.Context, req types.InferRequest, w io.Writer
 	return closeErr
 }
 
+// mergeStops appends template stop sequences not already requested.
+func mergeStops(req, extra []string) []string {
+	out := append([]string(nil), req...)
+	for _, s := range extra {
+		dup := false
+		for _, have := range out {
+			if have == s {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// durMs converts d to fractional milliseconds.
+func durMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
 // tokenLineJSON formats a token NDJSON line using json.Marshal for correctness.
 func tokenLineJSON(tok string) []byte {
 	type tokenMsg struct {
@@ -2,11 +2,12 @@ package manager
 
 import (
 	"context"
-	"net"
-	"net/url"
-	"strconv"
+	"fmt"
+	"strings"
 	"time"
 	"log"
+
+	"modeld/internal/registry"
 )
 
 // EnsureInstance ensures a model instance is initialized and marked ready
@@ -20,13 +21,18 @@ func (m *Manager) EnsureInstance(ctx context.Context, modelID string) error {
 			return nil
 		}
 	}
+	modelID = m.canonicalID(modelID)
 	log.Printf("manager event=ensure_start model=%q", modelID)
 	m.publisher.Publish(Event{Name: "ensure_start", ModelID: modelID, Fields: map[string]any{}})
 
 	m.mu.RLock()
 	inst, ok := m.instances[modelID]
 	ready := ok && inst != nil && inst.State == StateReady
+	restarting := ok && inst != nil && inst.restarting
 	m.mu.RUnlock()
+	if restarting {
+		return errCrashed(modelID, true)
+	}
 	if ready {
 		// Upgrade to write lock to safely mutate LastUsed and re-check state
 		m.mu.Lock()
@@ -46,7 +52,14 @@ func (m *Manager) EnsureInstance(ctx context.Context, modelID string) error {
 		m.publisher.Publish(Event{Name: "ensure_model_not_found", ModelID: modelID, Fields: map[string]any{}})
 		return ErrModelNotFound(modelID)
 	}
-	reqMB := m.estimateVRAMMB(mdl)
+	if missing := registry.MissingShards(mdl); len(missing) > 0 {
+		log.Printf("manager event=ensure_shards_missing model=%q missing=%v", modelID, missing)
+		m.publisher.Publish(Event{Name: "ensure_shards_missing", ModelID: modelID, Fields: map[string]any{"missing": missing}})
+		return ErrDependencyUnavailable(fmt.Sprintf("model %s is missing shards: %s", modelID, strings.Join(missing, ", ")))
+	}
+	slots := m.slotsFor(modelID)
+	vram := m.estimateVRAM(mdl, slots)
+	reqMB := vram.TotalMB
 
 	// Evict until it fits budget + margin, if budget configured
 	if m.budgetMB > 0 {
@@ -73,21 +86,29 @@ func (m *Manager) EnsureInstance(ctx context.Context, modelID string) error {
 			State:     StateLoading,
 			LastUsed:  time.Now(),
 			EstVRAMMB: reqMB,
-			genCh:     make(chan struct{}, 1),
-			queueCh:   make(chan struct{}, m.maxQueueDepth),
+			VRAM:      vram,
+			path:      mdl.Path,
+			sched:     newScheduler(slots, m.schedCfg),
 		}
 		m.instances[modelID] = inst
 		addedNow = true
 	} else {
 		inst.State = StateLoading
 		inst.EstVRAMMB = reqMB
+		inst.VRAM = vram
+		inst.path = mdl.Path
 		inst.LastUsed = time.Now()
 	}
 	m.mu.Unlock()
 
 	// If using subprocess adapter, proactively spawn the runtime so readiness transitions reflect real state.
 	if sa, ok := m.adapter.(*llamaSubprocessAdapter); ok {
+		sa.setParallel(mdl.Path, slots)
+		prof := m.runtimeProfile(mdl)
+		sa.setProfile(mdl.Path, prof)
+		sa.logFor(mdl.Path, modelID)
 		if _, err := sa.ensureProcess(mdl.Path); err != nil {
+			managerSpawnFailuresTotal.WithLabelValues(modelID).Inc()
 			m.mu.Lock()
 			m.state = StateError
 			m.err = err.Error()
@@ -96,18 +117,16 @@ func (m *Manager) EnsureInstance(ctx context.Context, modelID string) error {
 			m.publisher.Publish(Event{Name: "ensure_spawn_error", ModelID: modelID, Fields: map[string]any{"error": err.Error()}})
 			return err
 		}
+		m.mu.Lock()
+		inst.profile = &prof
+		inst.err, inst.restarting = "", false
+		m.mu.Unlock()
 		// Record port and PID on instance for status visibility
-		if pid, base, _, ok2 := sa.getProcInfo(mdl.Path); ok2 {
-			if u, err := url.Parse(base); err == nil {
-				if _, portStr, err2 := net.SplitHostPort(u.Host); err2 == nil {
-					if portNum, e := strconv.Atoi(portStr); e == nil {
-						m.mu.Lock()
-						inst.Port = portNum
-						inst.PID = pid
-						m.mu.Unlock()
-					}
-				}
-			}
+		if pid, port, ok2 := sa.endpoint(mdl.Path); ok2 {
+			m.mu.Lock()
+			inst.Port = port
+			inst.PID = pid
+			m.mu.Unlock()
 		}
 		log.Printf("manager event=ensure_spawn_ready model=%q pid=%d port=%d", modelID, inst.PID, inst.Port)
 		m.publisher.Publish(Event{Name: "ensure_spawn_ready", ModelID: modelID, Fields: map[string]any{"pid": inst.PID, "port": inst.Port}})
@@ -117,7 +136,14 @@ func (m *Manager) EnsureInstance(ctx context.Context, modelID string) error {
 	select {
 	case <-time.After(50 * time.Millisecond):
 	case <-ctx.Done():
+		// Don't leave a half-loaded instance behind when the load is canceled.
+		if sa, ok := m.adapter.(*llamaSubprocessAdapter); ok && addedNow {
+			_ = sa.Stop(mdl.Path)
+		}
 		m.mu.Lock()
+		if addedNow && m.instances[modelID] == inst {
+			delete(m.instances, modelID)
+		}
 		m.state = StateError
 		m.err = ctx.Err().Error()
 		m.mu.Unlock()
@@ -135,7 +161,12 @@ func (m *Manager) EnsureInstance(ctx context.Context, modelID string) error {
 	m.cur = &ModelInfo{ID: modelID}
 	m.state = StateReady
 	m.err = ""
+	managerVRAMUsedMB.Set(float64(m.usedEstMB))
 	m.mu.Unlock()
+	m.saveLRUMetadata()
+	m.loadsTotal.Add(1)
+	managerLoadsTotal.WithLabelValues(modelID).Inc()
+	managerLoadDuration.WithLabelValues(modelID).Observe(time.Since(startTs).Seconds())
 	log.Printf("manager event=ensure_ready model=%q dur_ms=%d", modelID, time.Since(startTs)/time.Millisecond)
 	m.publisher.Publish(Event{Name: "ensure_ready", ModelID: modelID, Fields: map[string]any{"dur_ms": int(time.Since(startTs)/time.Millisecond)}})
 	return nil
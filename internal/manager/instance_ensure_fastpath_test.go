@@ -9,7 +9,7 @@ func TestEnsureInstance_FastPathUpdatesLastUsed(t *testing.T) {
 	m := NewWithConfig(ManagerConfig{MaxQueueDepth: 1})
 	// Seed an instance that's already ready
 	m.mu.Lock()
-	inst := &Instance{ID: "m", State: StateReady, LastUsed: time.Unix(1, 0), genCh: make(chan struct{}, 1), queueCh: make(chan struct{}, 1)}
+	inst := &Instance{ID: "m", State: StateReady, LastUsed: time.Unix(1, 0), sched: newScheduler(1, schedConfig{})}
 	m.instances["m"] = inst
 	m.mu.Unlock()
 	before := inst.LastUsed
@@ -15,7 +15,7 @@ func (m *Manager) evictUntilFits(requiredMB int) error {
 		// Pick LRU idle instance (no in-flight and no queued requests)
 		var lru *Instance
 		for _, inst := range m.instances {
-			if len(inst.genCh) > 0 || len(inst.queueCh) > 0 {
+			if !inst.sched.idle() {
 				// active or has queued work; skip to avoid cancel requirement in MVP
 				continue
 			}
@@ -38,9 +38,16 @@ func (m *Manager) evictUntilFits(requiredMB int) error {
 			}
 			m.mu.Lock()
 		}
+		m.rememberLRU(lru)
 		delete(m.instances, lru.ID)
 		m.usedEstMB -= lru.EstVRAMMB
+		managerVRAMUsedMB.Set(float64(m.usedEstMB))
 		m.mu.Unlock()
+		m.saveLRUMetadata()
+		m.evictionsTotal.Add(1)
+		managerEvictionsTotal.WithLabelValues(lru.ID).Inc()
+		forgetModelMetrics(lru.ID)
+		m.publisher.Publish(Event{Name: "evict", ModelID: lru.ID, Fields: map[string]any{"freed_mb": lru.EstVRAMMB}})
 
 		if time.Now().After(deadline) {
 			return nil
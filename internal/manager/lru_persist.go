@@ -1,15 +1,23 @@
 package manager
 
 import (
+	"context"
 	"encoding/json"
+	"log"
+	"maps"
 	"os"
+	"path/filepath"
+	"sort"
 )
 
+// lruRecord is the persisted usage history of one model.
 type lruRecord struct {
 	LastUsedUnix int64 `json:"last_used_unix"`
 	EstVRAMMB    int   `json:"est_vram_mb"`
 }
 
+// loadLRUMetadata reads the usage history (model ID -> record) from lruPath.
+// A missing or unreadable file starts an empty history.
 func (m *Manager) loadLRUMetadata() {
 	if m.lruPath == "" {
 		return
@@ -26,20 +34,109 @@ func (m *Manager) loadLRUMetadata() {
 	}
 }
 
+// rememberLRU records the last use of inst in the history, so it outlives
+// the instance. Caller holds m.mu.
+func (m *Manager) rememberLRU(inst *Instance) {
+	if m.lruPath == "" {
+		return
+	}
+	if m.lruMeta == nil {
+		m.lruMeta = make(map[string]lruRecord)
+	}
+	m.lruMeta[inst.ID] = lruRecord{LastUsedUnix: inst.LastUsed.Unix(), EstVRAMMB: inst.EstVRAMMB}
+}
+
+// saveLRUMetadata merges the ready instances into the history and writes it
+// to lruPath. It is called after loads, unloads and evictions and on Close.
 func (m *Manager) saveLRUMetadata() {
 	if m.lruPath == "" {
 		return
 	}
+	m.lruSaveMu.Lock()
+	defer m.lruSaveMu.Unlock()
 	// Snapshot under lock
-	m.mu.RLock()
-	snap := make(map[string]lruRecord, len(m.instances))
-	for id, inst := range m.instances {
-		snap[id] = lruRecord{LastUsedUnix: inst.LastUsed.Unix(), EstVRAMMB: inst.EstVRAMMB}
+	m.mu.Lock()
+	for _, inst := range m.instances {
+		if inst.State == StateReady {
+			m.rememberLRU(inst)
+		}
 	}
-	m.mu.RUnlock()
+	snap := maps.Clone(m.lruMeta)
+	m.mu.Unlock()
 	b, err := json.MarshalIndent(snap, "", "  ")
 	if err != nil {
 		return
 	}
-	_ = os.WriteFile(m.lruPath, b, 0o644)
+	// Write a temp file and rename so a crash never leaves a torn history.
+	if err := os.MkdirAll(filepath.Dir(m.lruPath), 0o755); err != nil {
+		log.Printf("manager event=lru_save_error err=%v", err)
+		return
+	}
+	tmp := m.lruPath + ".tmp"
+	err = os.WriteFile(tmp, b, 0o644)
+	if err == nil {
+		err = os.Rename(tmp, m.lruPath)
+	}
+	if err != nil {
+		log.Printf("manager event=lru_save_error err=%v", err)
+	}
+}
+
+// Prewarm loads the most recently used models from the persisted history
+// (LRUStateFile), most recent first, until n models are loaded. Models that
+// are already loaded (e.g. adopted) count towards n; models no longer in the
+// registry, or that do not fit in the free VRAM budget, are skipped, so
+// pre-warming never evicts. It returns the IDs it loaded.
+func (m *Manager) Prewarm(ctx context.Context, n int) []string {
+	if n <= 0 {
+		return nil
+	}
+	m.mu.RLock()
+	hist := maps.Clone(m.lruMeta)
+	m.mu.RUnlock()
+	ids := make([]string, 0, len(hist))
+	for id := range hist {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := hist[ids[i]], hist[ids[j]]
+		if a.LastUsedUnix != b.LastUsedUnix {
+			return a.LastUsedUnix > b.LastUsedUnix
+		}
+		return ids[i] < ids[j]
+	})
+
+	var loaded []string
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			break
+		}
+		m.mu.RLock()
+		warm := len(m.instances)
+		_, isLoaded := m.instances[id]
+		free := m.budgetMB - m.usedEstMB - m.marginMB
+		m.mu.RUnlock()
+		if warm >= n {
+			break
+		}
+		if isLoaded {
+			continue
+		}
+		mdl, ok := m.getModelByID(id)
+		if !ok || mdl.ID != id {
+			log.Printf("manager event=prewarm_skip model=%q reason=%q", id, "not in registry")
+			continue
+		}
+		if est := m.estimateVRAM(mdl, m.slotsFor(id)).TotalMB; m.budgetMB > 0 && est > free {
+			log.Printf("manager event=prewarm_skip model=%q reason=%q est_mb=%d free_mb=%d", id, "over budget", est, free)
+			continue
+		}
+		if err := m.EnsureInstance(ctx, id); err != nil {
+			log.Printf("manager event=prewarm_error model=%q err=%v", id, err)
+			continue
+		}
+		log.Printf("manager event=prewarm model=%q", id)
+		loaded = append(loaded, id)
+	}
+	return loaded
 }
@@ -0,0 +1,113 @@
+package manager
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"modeld/pkg/types"
+)
+
+func readLRUFile(t *testing.T, path string) map[string]lruRecord {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	var data map[string]lruRecord
+	if err := json.Unmarshal(b, &data); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return data
+}
+
+func TestLRUMetadata_SavedOnLoadUnloadEvictAndClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lru.json")
+	m := NewWithConfig(ManagerConfig{
+		Registry: []types.Model{
+			{ID: "a", Path: "a.gguf", VRAMMB: 60},
+			{ID: "b", Path: "b.gguf", VRAMMB: 60},
+			{ID: "c", Path: "c.gguf", VRAMMB: 30},
+		},
+		BudgetMB:     100,
+		LRUStateFile: path,
+	})
+	ctx := testCtx(t)
+	if err := m.EnsureInstance(ctx, "a"); err != nil {
+		t.Fatalf("ensure a: %v", err)
+	}
+	if rec, ok := readLRUFile(t, path)["a"]; !ok || rec.EstVRAMMB != 60 {
+		t.Fatalf("not saved on load: %+v", readLRUFile(t, path))
+	}
+	// Loading b evicts a; a stays in the history.
+	if err := m.EnsureInstance(ctx, "b"); err != nil {
+		t.Fatalf("ensure b: %v", err)
+	}
+	if data := readLRUFile(t, path); len(data) != 2 {
+		t.Fatalf("after eviction: %+v", data)
+	}
+	if err := m.Unload("b"); err != nil {
+		t.Fatalf("unload: %v", err)
+	}
+	if err := m.EnsureInstance(ctx, "c"); err != nil {
+		t.Fatalf("ensure c: %v", err)
+	}
+	used := time.Now().Add(time.Hour)
+	m.mu.Lock()
+	m.instances["c"].LastUsed = used
+	m.mu.Unlock()
+	_ = m.Close()
+	data := readLRUFile(t, path)
+	if len(data) != 3 || data["c"].LastUsedUnix != used.Unix() {
+		t.Fatalf("not saved on close: %+v", data)
+	}
+
+	// A new manager starts with the history.
+	m2 := NewWithConfig(ManagerConfig{LRUStateFile: path})
+	if len(m2.lruMeta) != 3 {
+		t.Fatalf("history not loaded: %+v", m2.lruMeta)
+	}
+}
+
+func TestPrewarm_MostRecentWithinBudget(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lru.json")
+	now := time.Now().Unix()
+	hist := map[string]lruRecord{
+		"a":    {LastUsedUnix: now - 10},
+		"b":    {LastUsedUnix: now - 20}, // does not fit after a
+		"gone": {LastUsedUnix: now - 5},  // no longer in the registry
+		"c":    {LastUsedUnix: now - 30},
+		"d":    {LastUsedUnix: now - 40}, // beyond n
+	}
+	b, _ := json.Marshal(hist)
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	m := NewWithConfig(ManagerConfig{
+		Registry: []types.Model{
+			{ID: "a", Path: "a.gguf", VRAMMB: 60},
+			{ID: "b", Path: "b.gguf", VRAMMB: 60},
+			{ID: "c", Path: "c.gguf", VRAMMB: 20},
+			{ID: "d", Path: "d.gguf", VRAMMB: 10},
+		},
+		BudgetMB:     100,
+		MarginMB:     10,
+		LRUStateFile: path,
+	})
+	got := m.Prewarm(testCtx(t), 2)
+	if len(got) != 2 || got[0] != "a" || got[1] != "c" {
+		t.Fatalf("prewarmed %v", got)
+	}
+	if m.evictionsTotal.Load() != 0 {
+		t.Fatalf("prewarm evicted instances")
+	}
+	// Loaded models count towards n.
+	if got := m.Prewarm(testCtx(t), 2); len(got) != 0 {
+		t.Fatalf("second prewarm loaded %v", got)
+	}
+	if got := m.Prewarm(testCtx(t), 0); got != nil {
+		t.Fatalf("n=0 loaded %v", got)
+	}
+}
@@ -1,10 +1,12 @@
 package manager
 
 import (
+	"context"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"modeld/internal/registry"
 	"modeld/pkg/types"
 )
 
@@ -13,38 +15,86 @@ type Manager struct {
 	state        State
 	cur          *ModelInfo
 	err          string
-	registry     []types.Model
+	// Model registry, swapped by SetRegistry; regMu is taken last
+	regMu          sync.RWMutex
+	registry       []types.Model
+	registryLoader func() ([]types.Model, error)
+	registryDuplicates func() []registry.Duplicate
+	// Alias table (logical name -> weighted targets), see aliases.go
+	aliasMu    sync.RWMutex
+	aliasTable map[string]types.ModelAlias
+	aliasIntN  func(n int) int // random source for weighted picks; nil uses math/rand
 	budgetMB     int
 	marginMB     int
 	defaultModel string
 	// Multi-instance fields
 	instances map[string]*Instance
 	usedEstMB int
-	// Operation sequencing (for async ops)
+	// Operation sequencing and tracking (for async ops)
 	opSeq uint64
-	// Subscribers (event listeners) could be added here in the future
+	opsMu     sync.Mutex
+	ops       map[string]*operation
+	opsOrder  []string // creation order, for retention
+	opsCtx    context.Context
+	opsCancel context.CancelFunc
+	opsWG     sync.WaitGroup
+	// Event publishing: events always go to the live broadcaster (for
+	// SubscribeEvents) and to the optional publisher set via SetEventPublisher.
 	publisher EventPublisher
+	events    *Broadcaster
 
 	// Queue config
 	maxQueueDepth int
 	maxWait       time.Duration
 	drainTimeout  time.Duration
+	// Parallel generation slots (see slotsFor) and admission scheduling
+	slots      int
+	modelSlots map[string]int
+	schedCfg   schedConfig
 
 	// Observability
-	startTime time.Time
+	startTime      time.Time
+	loadsTotal     atomic.Uint64
+	evictionsTotal atomic.Uint64
 
 	// Optional inference adapter (e.g., llama.cpp). When set and enabled,
 	// Manager.Infer will delegate token generation to this adapter.
 	adapter InferenceAdapter
 
-	// LRU metadata persistence (optional)
-	lruPath string
-	lruMeta map[string]lruRecord
+	// LRU metadata persistence (optional): last-use history by model ID,
+	// guarded by mu; lruSaveMu serializes writes
+	lruPath   string
+	lruMeta   map[string]lruRecord
+	lruSaveMu sync.Mutex
+
+	// Chat templating (messages -> prompt)
+	chatTemplateOverrides map[string]string
+	defaultChatTemplate   string
+	chatTemplateFromGGUF  bool
+
+	// Parsed GGUF headers (model path -> header, nil if unreadable) for chat
+	// templates and VRAM estimation
+	ggufMu    sync.Mutex
+	ggufCache map[string]*registry.GGUFInfo
+
+	// llama-server settings: the global flags and per-model config profiles
+	// (model ID or alias -> profile), resolved per model by runtimeProfile
+	baseProfile   types.RuntimeProfile
+	modelProfiles map[string]types.RuntimeProfile
 }
 
-// Close releases background resources. For now it stops all managed
-// subprocess instances (spawn mode). Safe to call multiple times.
+// Close releases background resources. It cancels outstanding async
+// operations, waits for them to finish, and stops all managed subprocess
+// instances (spawn mode). With a spawn state file the ready subprocesses are
+// left running instead, for the next modeld to adopt (see AdoptRuntimes).
+// Safe to call multiple times.
 func (m *Manager) Close() error {
+    m.cancelOps()
+    m.saveLRUMetadata()
+    if sa, ok := m.adapter.(*llamaSubprocessAdapter); ok && sa.statePath() != "" {
+        sa.detachAll()
+        return nil
+    }
     m.StopAllInstances()
     return nil
 }
@@ -72,14 +122,18 @@ func New(reg []types.Model, budgetMB, marginMB int, defaultModel string) *Manage
 	})
 }
 
-// SetEventPublisher installs an EventPublisher. Passing nil resets to no-op.
+// SetEventPublisher installs an additional EventPublisher alongside the live
+// event broadcaster. Passing nil removes it.
 func (m *Manager) SetEventPublisher(p EventPublisher) {
     m.mu.Lock()
     defer m.mu.Unlock()
+    if m.events == nil {
+        m.events = NewBroadcaster(0)
+    }
     if p == nil {
-        m.publisher = noopPublisher{}
+        m.publisher = m.events
     } else {
-        m.publisher = p
+        m.publisher = multiPublisher{m.events, p}
     }
     if sa, ok := m.adapter.(*llamaSubprocessAdapter); ok {
         sa.setPublisher(m.publisher)
@@ -131,8 +185,8 @@ func (m *Manager) Ready() bool {
 }
 
 func (m *Manager) ListModels() []types.Model {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	m.regMu.RLock()
+	defer m.regMu.RUnlock()
 	// return a shallow copy to avoid external mutation
 	out := make([]types.Model, len(m.registry))
 	copy(out, m.registry)
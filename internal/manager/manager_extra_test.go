@@ -90,14 +90,16 @@ func TestEvictUntilFits_NoIdleInstances(t *testing.T) {
 		t.Fatalf("ensure b: %v", err)
 	}
 	// Occupy both instances so they are not idle
-	m.mu.RLock()
-	ia := m.instances["a"]
-	ib := m.instances["b"]
-	m.mu.RUnlock()
-	ia.queueCh <- struct{}{}
-	ia.genCh <- struct{}{}
-	ib.queueCh <- struct{}{}
-	ib.genCh <- struct{}{}
+	relA, err := m.beginGeneration(context.Background(), "a")
+	if err != nil {
+		t.Fatalf("begin a: %v", err)
+	}
+	defer relA()
+	relB, err := m.beginGeneration(context.Background(), "b")
+	if err != nil {
+		t.Fatalf("begin b: %v", err)
+	}
+	defer relB()
 	usedBefore := m.usedEstMB
 	// Request that would require more space; function should return without evicting due to no idle
 	_ = m.evictUntilFits(20)
@@ -112,11 +114,6 @@ func TestEvictUntilFits_NoIdleInstances(t *testing.T) {
 	if usedAfter != usedBefore {
 		t.Fatalf("usedEstMB changed: %d -> %d", usedBefore, usedAfter)
 	}
-	// cleanup channels
-	<-ia.genCh
-	<-ia.queueCh
-	<-ib.genCh
-	<-ib.queueCh
 }
 
 func TestGetModelByID(t *testing.T) {
@@ -122,22 +122,19 @@ func TestBeginGenerationBackpressureTooBusy(t *testing.T) {
 		t.Fatalf("ensure: %v", err)
 	}
 
-	// Saturate queue and gen to force backpressure
-	m.mu.RLock()
-	inst := m.instances["m"]
-	m.mu.RUnlock()
-	inst.queueCh <- struct{}{}
-	inst.genCh <- struct{}{}
+	// Occupy the generation slot to force backpressure
+	rel, err := m.beginGeneration(context.Background(), "m")
+	if err != nil {
+		t.Fatalf("beginGeneration: %v", err)
+	}
+	defer rel()
 
 	// call Infer which uses beginGeneration under the hood
 	var buf bytes.Buffer
-	err := m.Infer(context.Background(), types.InferRequest{Model: "m", Prompt: "hi", Stream: true}, &buf, func() {})
+	err = m.Infer(context.Background(), types.InferRequest{Model: "m", Prompt: "hi", Stream: true}, &buf, func() {})
 	if err == nil || !IsTooBusy(err) {
 		t.Fatalf("expected too busy error, got %v", err)
 	}
-	// cleanup
-	<-inst.genCh
-	<-inst.queueCh
 }
 
 func TestInferNoDefaultModelError(t *testing.T) {
@@ -0,0 +1,48 @@
+package manager
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"modeld/pkg/types"
+)
+
+func TestManifest_AliasesResolveToRegistryID(t *testing.T) {
+	m := NewWithConfig(ManagerConfig{
+		Registry:     []types.Model{{ID: "llama-2-7b-q4", Aliases: []string{"llama2"}, Path: "l.gguf", VRAMMB: 4500}},
+		DefaultModel: "llama2",
+	})
+	m.SetInferenceAdapter(&fakeAdapter{})
+	var buf bytes.Buffer
+	if err := m.Infer(testCtx(t), types.InferRequest{Model: "llama2", Prompt: "hi"}, &buf, nil); err != nil {
+		t.Fatalf("infer via alias: %v", err)
+	}
+	st := m.Status()
+	if len(st.Instances) != 1 || st.Instances[0].ModelID != "llama-2-7b-q4" {
+		t.Fatalf("expected one instance under the registry ID: %+v", st.Instances)
+	}
+	if st.Instances[0].EstVRAMMB != 4500 {
+		t.Fatalf("expected manifest VRAM estimate, got %d", st.Instances[0].EstVRAMMB)
+	}
+	if m.defaultModel != "llama-2-7b-q4" {
+		t.Fatalf("default model not canonicalized: %q", m.defaultModel)
+	}
+	if op, err := m.UnloadModel("llama2"); err != nil || op.ModelID != "llama-2-7b-q4" {
+		t.Fatalf("unload via alias: %+v %v", op, err)
+	}
+}
+
+func TestSpawnArgs_ModelOverrides(t *testing.T) {
+	cfg := ManagerConfig{LlamaCtxSize: 2048, LlamaExtraArgs: []string{"--mlock"}}
+	sa := NewLlamaSubprocessAdapter(cfg).(*llamaSubprocessAdapter)
+	mdl := types.Model{Path: "m.gguf", ContextSize: 8192, LlamaArgs: []string{"--rope-freq-base", "10000"}}
+	sa.setProfile(mdl.Path, resolveProfile(globalProfile(cfg), mdl, nil))
+	args := strings.Join(sa.spawnArgs("m.gguf", "127.0.0.1", 30000), " ")
+	if !strings.Contains(args, "-c 8192") || strings.Contains(args, "-c 2048") || !strings.HasSuffix(args, "--mlock --rope-freq-base 10000") {
+		t.Fatalf("unexpected args: %s", args)
+	}
+	if args := strings.Join(sa.spawnArgs("other.gguf", "127.0.0.1", 30000), " "); !strings.Contains(args, "-c 2048") {
+		t.Fatalf("expected global ctx for other models: %s", args)
+	}
+}
@@ -0,0 +1,190 @@
+package manager
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Manager-level Prometheus metrics. They are process-global (like the HTTP
+// metrics in internal/httpapi) and labeled by model ID where applicable; the
+// per-Manager LoadsTotal/EvictionsTotal in /status are tracked separately.
+var (
+	managerLoadsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "modeld",
+			Subsystem: "manager",
+			Name:      "loads_total",
+			Help:      "Total number of model instance loads",
+		},
+		[]string{"model"},
+	)
+
+	managerEvictionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "modeld",
+			Subsystem: "manager",
+			Name:      "evictions_total",
+			Help:      "Total number of instances evicted to free VRAM",
+		},
+		[]string{"model"},
+	)
+
+	managerAliasRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "modeld",
+			Subsystem: "manager",
+			Name:      "alias_requests_total",
+			Help:      "Total number of requests routed through an alias, by alias and resolved model",
+		},
+		[]string{"alias", "model"},
+	)
+
+	managerSpawnFailuresTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "modeld",
+			Subsystem: "manager",
+			Name:      "spawn_failures_total",
+			Help:      "Total number of failed runtime subprocess spawns",
+		},
+		[]string{"model"},
+	)
+
+	managerSpawnCrashesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "modeld",
+			Subsystem: "manager",
+			Name:      "spawn_crashes_total",
+			Help:      "Total number of runtime subprocesses that exited after becoming ready",
+		},
+		[]string{"model"},
+	)
+
+	managerSpawnRestartsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "modeld",
+			Subsystem: "manager",
+			Name:      "spawn_restarts_total",
+			Help:      "Total number of crashed runtime subprocesses restarted by the supervisor",
+		},
+		[]string{"model"},
+	)
+
+	managerLoadDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "modeld",
+			Subsystem: "manager",
+			Name:      "load_duration_seconds",
+			Help:      "Time to load a model instance until ready",
+			Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+		},
+		[]string{"model"},
+	)
+
+	managerQueueWait = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "modeld",
+			Subsystem: "manager",
+			Name:      "queue_wait_seconds",
+			Help:      "Time a request waited for a generation slot",
+			Buckets:   []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		},
+		[]string{"model"},
+	)
+
+	managerInflight = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "modeld",
+			Subsystem: "manager",
+			Name:      "inflight_requests",
+			Help:      "Requests currently generating, per model",
+		},
+		[]string{"model"},
+	)
+
+	managerQueued = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "modeld",
+			Subsystem: "manager",
+			Name:      "queued_requests",
+			Help:      "Requests waiting for a generation slot, per model",
+		},
+		[]string{"model"},
+	)
+
+	managerVRAMUsedMB = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "modeld",
+			Subsystem: "manager",
+			Name:      "vram_used_mb",
+			Help:      "Estimated VRAM used by loaded instances in MB",
+		},
+	)
+
+	managerVRAMBudgetMB = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "modeld",
+			Subsystem: "manager",
+			Name:      "vram_budget_mb",
+			Help:      "Configured VRAM budget in MB (0 = unlimited)",
+		},
+	)
+
+	managerTTFT = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "modeld",
+			Subsystem: "manager",
+			Name:      "time_to_first_token_seconds",
+			Help:      "Time from request start (including load and queue wait) to the first token",
+			Buckets:   []float64{0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		},
+		[]string{"model"},
+	)
+
+	managerInterToken = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "modeld",
+			Subsystem: "manager",
+			Name:      "inter_token_latency_seconds",
+			Help:      "Latency between consecutive streamed tokens",
+			Buckets:   []float64{0.001, 0.0025, 0.005, 0.01, 0.02, 0.05, 0.1, 0.25, 0.5, 1},
+		},
+		[]string{"model"},
+	)
+
+	managerTokensPerSecond = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "modeld",
+			Subsystem: "manager",
+			Name:      "tokens_per_second",
+			Help:      "Completion tokens per second of generation time, per request",
+			Buckets:   []float64{1, 2.5, 5, 10, 20, 40, 60, 80, 120, 160, 250},
+		},
+		[]string{"model"},
+	)
+
+	managerTokensTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "modeld",
+			Subsystem: "manager",
+			Name:      "tokens_generated_total",
+			Help:      "Total number of completion tokens generated",
+		},
+		[]string{"model"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(
+		managerLoadsTotal, managerEvictionsTotal, managerSpawnFailuresTotal,
+		managerSpawnCrashesTotal, managerSpawnRestartsTotal,
+		managerLoadDuration, managerQueueWait, managerInflight, managerQueued,
+		managerVRAMUsedMB, managerVRAMBudgetMB, managerTokensTotal,
+		managerTTFT, managerInterToken, managerTokensPerSecond, managerAliasRequestsTotal,
+	)
+}
+
+// forgetModelMetrics drops per-model gauges for an instance that is gone so
+// stale series don't linger on /metrics.
+func forgetModelMetrics(modelID string) {
+	managerInflight.DeleteLabelValues(modelID)
+	managerQueued.DeleteLabelValues(modelID)
+}
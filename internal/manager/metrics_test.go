@@ -0,0 +1,107 @@
+package manager
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+
+	"modeld/pkg/types"
+)
+
+func TestMetrics_LoadsEvictionsAndStatusTotals(t *testing.T) {
+	dir := t.TempDir()
+	a := createModelFile(t, dir, "metrics-a.gguf", 1)
+	b := createModelFile(t, dir, "metrics-b.gguf", 1)
+	m := NewWithConfig(ManagerConfig{
+		Registry: []types.Model{{ID: "metrics-a", Path: a}, {ID: "metrics-b", Path: b}},
+		BudgetMB: 1,
+	})
+	if err := m.EnsureInstance(testCtx(t), "metrics-a"); err != nil {
+		t.Fatalf("ensure a: %v", err)
+	}
+	// b does not fit next to a and evicts it.
+	if err := m.EnsureInstance(testCtx(t), "metrics-b"); err != nil {
+		t.Fatalf("ensure b: %v", err)
+	}
+	st := m.Status()
+	if st.LoadsTotal != 2 || st.EvictionsTotal != 1 {
+		t.Fatalf("status totals: loads=%d evictions=%d", st.LoadsTotal, st.EvictionsTotal)
+	}
+	if got := testutil.ToFloat64(managerLoadsTotal.WithLabelValues("metrics-a")); got != 1 {
+		t.Fatalf("loads_total{metrics-a}=%v", got)
+	}
+	if got := testutil.ToFloat64(managerEvictionsTotal.WithLabelValues("metrics-a")); got != 1 {
+		t.Fatalf("evictions_total{metrics-a}=%v", got)
+	}
+	if got := testutil.ToFloat64(managerVRAMUsedMB); got != 1 {
+		t.Fatalf("vram_used_mb=%v", got)
+	}
+	if got := testutil.ToFloat64(managerVRAMBudgetMB); got != 1 {
+		t.Fatalf("vram_budget_mb=%v", got)
+	}
+}
+
+func TestMetrics_InferTokensQueueWaitAndGauges(t *testing.T) {
+	m := NewWithConfig(ManagerConfig{Registry: []types.Model{{ID: "metrics-infer", Path: "x.gguf"}}})
+	m.SetInferenceAdapter(&fakeAdapter{tokens: []string{"a", "b", "c"}})
+	var buf bytes.Buffer
+	if err := m.Infer(testCtx(t), types.InferRequest{Model: "metrics-infer", Prompt: "hi"}, &buf, nil); err != nil {
+		t.Fatalf("Infer: %v", err)
+	}
+	if got := testutil.ToFloat64(managerTokensTotal.WithLabelValues("metrics-infer")); got != 3 {
+		t.Fatalf("tokens_generated_total=%v", got)
+	}
+	if n := testutil.CollectAndCount(managerQueueWait, "modeld_manager_queue_wait_seconds"); n == 0 {
+		t.Fatalf("expected queue wait observations")
+	}
+	if got := testutil.ToFloat64(managerInflight.WithLabelValues("metrics-infer")); got != 0 {
+		t.Fatalf("inflight gauge not released: %v", got)
+	}
+	if got := testutil.ToFloat64(managerQueued.WithLabelValues("metrics-infer")); got != 0 {
+		t.Fatalf("queued gauge not released: %v", got)
+	}
+}
+
+func TestMetrics_DoneLineTimingsAndHistograms(t *testing.T) {
+	m := NewWithConfig(ManagerConfig{Registry: []types.Model{{ID: "metrics-timing", Path: "x.gguf"}}})
+	m.SetInferenceAdapter(&fakeAdapter{tokens: []string{"a", "b", "c", "d"}})
+	var buf bytes.Buffer
+	if err := m.Infer(testCtx(t), types.InferRequest{Model: "metrics-timing", Prompt: "hi"}, &buf, nil); err != nil {
+		t.Fatalf("Infer: %v", err)
+	}
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	var final struct {
+		Done    bool               `json:"done"`
+		Metrics types.InferMetrics `json:"metrics"`
+	}
+	if err := json.Unmarshal(lines[len(lines)-1], &final); err != nil || !final.Done {
+		t.Fatalf("final line: %s (%v)", lines[len(lines)-1], err)
+	}
+	// TTFT includes the ~50ms simulated load.
+	if final.Metrics.TTFTMs < 40 || final.Metrics.TokensPerSecond <= 0 || final.Metrics.QueueWaitMs < 0 {
+		t.Fatalf("unexpected metrics: %+v", final.Metrics)
+	}
+	if got := histogramCount(t, managerTTFT.WithLabelValues("metrics-timing")); got != 1 {
+		t.Fatalf("ttft observations=%d", got)
+	}
+	if got := histogramCount(t, managerInterToken.WithLabelValues("metrics-timing")); got != 3 {
+		t.Fatalf("inter-token observations=%d", got)
+	}
+	if got := histogramCount(t, managerTokensPerSecond.WithLabelValues("metrics-timing")); got != 1 {
+		t.Fatalf("tokens/sec observations=%d", got)
+	}
+}
+
+// histogramCount returns the sample count of a single histogram series.
+func histogramCount(t *testing.T, o prometheus.Observer) uint64 {
+	t.Helper()
+	var pb dto.Metric
+	if err := o.(prometheus.Metric).Write(&pb); err != nil {
+		t.Fatalf("write metric: %v", err)
+	}
+	return pb.GetHistogram().GetSampleCount()
+}
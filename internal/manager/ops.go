@@ -0,0 +1,202 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"modeld/pkg/types"
+)
+
+// Operation states reported by Operation().
+const (
+	OpPending   = "pending"
+	OpRunning   = "running"
+	OpSucceeded = "succeeded"
+	OpFailed    = "failed"
+	OpCanceled  = "canceled"
+)
+
+// maxRetainedOps bounds how many finished operations are kept for polling.
+const maxRetainedOps = 256
+
+// operation is the registry record for an async operation.
+type operation struct {
+	status types.OperationStatus
+	cancel context.CancelFunc
+}
+
+func (o *operation) terminal() bool {
+	switch o.status.State {
+	case OpSucceeded, OpFailed, OpCanceled:
+		return true
+	}
+	return false
+}
+
+// startOp registers a new operation and runs fn in the background with a
+// context that is canceled by CancelOperation or Close. The context is
+// detached from any request so client disconnects do not abort the op.
+func (m *Manager) startOp(kind, modelID string, fn func(ctx context.Context) error) types.OperationStatus {
+	m.opsMu.Lock()
+	if m.opsCtx == nil {
+		m.opsCtx, m.opsCancel = context.WithCancel(context.Background())
+	}
+	if m.ops == nil {
+		m.ops = make(map[string]*operation)
+	}
+	ctx, cancel := context.WithCancel(m.opsCtx)
+	op := &operation{
+		status: types.OperationStatus{
+			ID:            m.nextOpID(),
+			Kind:          kind,
+			ModelID:       modelID,
+			State:         OpPending,
+			CreatedUnixMs: time.Now().UnixMilli(),
+		},
+		cancel: cancel,
+	}
+	m.ops[op.status.ID] = op
+	m.opsOrder = append(m.opsOrder, op.status.ID)
+	m.pruneOpsLocked()
+	st := op.status
+	m.opsWG.Add(1)
+	m.opsMu.Unlock()
+
+	go func() {
+		defer m.opsWG.Done()
+		defer cancel()
+		if ctx.Err() != nil {
+			m.finishOp(op, ctx.Err())
+			return
+		}
+		m.opsMu.Lock()
+		op.status.State = OpRunning
+		op.status.StartedUnixMs = time.Now().UnixMilli()
+		m.opsMu.Unlock()
+		err := fn(ctx)
+		if err == nil && ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		m.finishOp(op, err)
+	}()
+	return st
+}
+
+// finishOp records the terminal state of op.
+func (m *Manager) finishOp(op *operation, err error) {
+	m.opsMu.Lock()
+	op.status.FinishedUnixMs = time.Now().UnixMilli()
+	switch {
+	case err == nil:
+		op.status.State = OpSucceeded
+	case errors.Is(err, context.Canceled):
+		op.status.State = OpCanceled
+		op.status.Error = "canceled"
+	default:
+		op.status.State = OpFailed
+		op.status.Error = err.Error()
+	}
+	st := op.status
+	m.opsMu.Unlock()
+	m.publisher.Publish(Event{Name: "op_" + st.State, ModelID: st.ModelID, Fields: map[string]any{"op_id": st.ID, "kind": st.Kind}})
+}
+
+// pruneOpsLocked drops the oldest finished operations beyond maxRetainedOps.
+// Caller must hold opsMu.
+func (m *Manager) pruneOpsLocked() {
+	excess := len(m.opsOrder) - maxRetainedOps
+	if excess <= 0 {
+		return
+	}
+	kept := m.opsOrder[:0]
+	for _, id := range m.opsOrder {
+		if excess > 0 && m.ops[id].terminal() {
+			delete(m.ops, id)
+			excess--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	m.opsOrder = kept
+}
+
+// cancelOps cancels all outstanding operations and waits for them to exit.
+func (m *Manager) cancelOps() {
+	m.opsMu.Lock()
+	if m.opsCancel != nil {
+		m.opsCancel()
+	}
+	m.opsMu.Unlock()
+	m.opsWG.Wait()
+}
+
+// Operation returns the status of a previously started operation.
+func (m *Manager) Operation(id string) (types.OperationStatus, bool) {
+	m.opsMu.Lock()
+	defer m.opsMu.Unlock()
+	op := m.ops[id]
+	if op == nil {
+		return types.OperationStatus{}, false
+	}
+	return op.status, true
+}
+
+// CancelOperation requests cancellation of an operation and returns its
+// current status. Canceling a finished operation is a no-op. The operation
+// reaches the canceled state asynchronously once its work observes the
+// cancellation; unloads drain regardless and cannot be interrupted.
+func (m *Manager) CancelOperation(id string) (types.OperationStatus, error) {
+	m.opsMu.Lock()
+	defer m.opsMu.Unlock()
+	op := m.ops[id]
+	if op == nil {
+		return types.OperationStatus{}, ErrOperationNotFound(id)
+	}
+	if !op.terminal() {
+		op.cancel()
+	}
+	return op.status, nil
+}
+
+// LoadModel starts loading (ensuring) a model instance in the background and
+// returns the operation. Unknown model IDs fail synchronously.
+func (m *Manager) LoadModel(modelID string) (types.OperationStatus, error) {
+	mdl, ok := m.getModelByID(modelID)
+	if !ok {
+		return types.OperationStatus{}, ErrModelNotFound(modelID)
+	}
+	modelID = mdl.ID
+	return m.startOp("load", modelID, func(ctx context.Context) error {
+		return m.EnsureInstance(ctx, modelID)
+	}), nil
+}
+
+// UnloadModel starts a graceful unload of a loaded instance in the background
+// and returns the operation. Models that are not loaded fail synchronously.
+func (m *Manager) UnloadModel(modelID string) (types.OperationStatus, error) {
+	return m.unloadOp(m.canonicalID(modelID))
+}
+
+// unloadOp starts the unload of the instance stored under modelID.
+func (m *Manager) unloadOp(modelID string) (types.OperationStatus, error) {
+	m.mu.RLock()
+	_, loaded := m.instances[modelID]
+	m.mu.RUnlock()
+	if !loaded {
+		return types.OperationStatus{}, ErrModelNotFound(modelID)
+	}
+	return m.startOp("unload", modelID, func(context.Context) error {
+		return m.Unload(modelID)
+	}), nil
+}
+
+// SwitchModel is like Switch but returns the full operation status and fails
+// synchronously for unknown model IDs.
+func (m *Manager) SwitchModel(modelID string) (types.OperationStatus, error) {
+	mdl, ok := m.getModelByID(modelID)
+	if !ok {
+		return types.OperationStatus{}, ErrModelNotFound(modelID)
+	}
+	return m.switchOp(mdl.ID), nil
+}
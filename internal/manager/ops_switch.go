@@ -1,20 +1,22 @@
 package manager
 
-import "context"
+import (
+	"context"
+
+	"modeld/pkg/types"
+)
 
 // Switch kicks off an async model switch/ensure and returns an operation ID.
-// The operation runs in the background; callers can poll Status() to observe
-// state transitions. Background work is intentionally detached from the caller's
-// context so that transient client cancellations do not abort the switch. For
-// shutdown, use Manager.Close()/StopAllInstances() which will stop managed
-// subprocesses and allow future extensions to cancel background ops.
+// The operation runs in the background; callers can poll Operation(id) for its
+// state and error, or cancel it with CancelOperation(id). Background work is
+// intentionally detached from the caller's context so that transient client
+// cancellations do not abort the switch; Close() cancels it on shutdown.
 func (m *Manager) Switch(ctx context.Context, modelID string) (string, error) {
-	op := m.nextOpID()
-	go func(opID string) {
-		// Use a detached context so background work isn't canceled when the
-		// caller context is canceled; we still respect shutdown via EnsureInstance.
-		_ = m.EnsureInstance(context.Background(), modelID)
-		// In a fuller implementation we would emit events to subscribers here.
-	}(op)
-	return op, nil
+	return m.switchOp(m.canonicalID(modelID)).ID, nil
+}
+
+func (m *Manager) switchOp(modelID string) types.OperationStatus {
+	return m.startOp("switch", modelID, func(ctx context.Context) error {
+		return m.EnsureInstance(ctx, modelID)
+	})
 }
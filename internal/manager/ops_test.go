@@ -0,0 +1,155 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"modeld/pkg/types"
+)
+
+// waitOp polls Operation(id) until it reaches a terminal state.
+func waitOp(t *testing.T, m *Manager, id string) types.OperationStatus {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		op, ok := m.Operation(id)
+		if !ok {
+			t.Fatalf("operation %s not found", id)
+		}
+		if op.State == OpSucceeded || op.State == OpFailed || op.State == OpCanceled {
+			return op
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("operation %s did not finish", id)
+	return types.OperationStatus{}
+}
+
+func TestLoadUnloadModel_Ops(t *testing.T) {
+	m := NewWithConfig(ManagerConfig{
+		Registry:     []types.Model{{ID: "m", Path: "m.gguf"}},
+		DrainTimeout: 100 * time.Millisecond,
+	})
+	op, err := m.LoadModel("m")
+	if err != nil {
+		t.Fatalf("LoadModel: %v", err)
+	}
+	if op.ID == "" || op.Kind != "load" || op.ModelID != "m" {
+		t.Fatalf("unexpected op: %+v", op)
+	}
+	got := waitOp(t, m, op.ID)
+	if got.State != OpSucceeded {
+		t.Fatalf("load op: %+v", got)
+	}
+	if got.CreatedUnixMs == 0 || got.StartedUnixMs < got.CreatedUnixMs || got.FinishedUnixMs < got.StartedUnixMs {
+		t.Fatalf("unexpected timestamps: %+v", got)
+	}
+	m.mu.RLock()
+	inst := m.instances["m"]
+	m.mu.RUnlock()
+	if inst == nil || inst.State != StateReady {
+		t.Fatalf("expected ready instance after load")
+	}
+
+	op, err = m.UnloadModel("m")
+	if err != nil {
+		t.Fatalf("UnloadModel: %v", err)
+	}
+	if got := waitOp(t, m, op.ID); got.State != OpSucceeded || got.Kind != "unload" {
+		t.Fatalf("unload op: %+v", got)
+	}
+	if _, err := m.UnloadModel("m"); !IsModelNotFound(err) {
+		t.Fatalf("expected not found for unloaded model, got %v", err)
+	}
+}
+
+func TestOps_UnknownModelAndOp(t *testing.T) {
+	m := NewWithConfig(ManagerConfig{Registry: []types.Model{{ID: "m", Path: "m.gguf"}}})
+	if _, err := m.LoadModel("nope"); !IsModelNotFound(err) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if _, err := m.SwitchModel("nope"); !IsModelNotFound(err) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if _, ok := m.Operation("op-999"); ok {
+		t.Fatalf("expected unknown op")
+	}
+}
+
+func TestSwitch_RecordsFailure(t *testing.T) {
+	m := NewWithConfig(ManagerConfig{Registry: []types.Model{{ID: "m", Path: "m.gguf"}}})
+	id, err := m.Switch(testCtx(t), "missing")
+	if err != nil {
+		t.Fatalf("Switch: %v", err)
+	}
+	got := waitOp(t, m, id)
+	if got.State != OpFailed || got.Error == "" || got.Kind != "switch" {
+		t.Fatalf("expected failed switch op, got %+v", got)
+	}
+}
+
+func TestCancelOperation_InFlightSwitch(t *testing.T) {
+	m := NewWithConfig(ManagerConfig{Registry: []types.Model{{ID: "m", Path: "m.gguf"}}})
+	id, _ := m.Switch(testCtx(t), "m")
+	if _, err := m.CancelOperation(id); err != nil {
+		t.Fatalf("CancelOperation: %v", err)
+	}
+	got := waitOp(t, m, id)
+	if got.State != OpCanceled || got.Error == "" {
+		t.Fatalf("expected canceled op, got %+v", got)
+	}
+	m.mu.RLock()
+	_, exists := m.instances["m"]
+	m.mu.RUnlock()
+	if exists {
+		t.Fatalf("canceled load left an instance behind")
+	}
+	// Canceling a finished op is a no-op; unknown IDs are reported.
+	if st, err := m.CancelOperation(id); err != nil || st.State != OpCanceled {
+		t.Fatalf("re-cancel: %+v %v", st, err)
+	}
+	if _, err := m.CancelOperation("op-999"); !IsOperationNotFound(err) {
+		t.Fatalf("expected operation not found, got %v", err)
+	}
+}
+
+func TestClose_CancelsOutstandingOps(t *testing.T) {
+	m := NewWithConfig(ManagerConfig{Registry: []types.Model{{ID: "a", Path: "a.gguf"}, {ID: "b", Path: "b.gguf"}}})
+	a, _ := m.LoadModel("a")
+	b, _ := m.SwitchModel("b")
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	// Close waits for the ops, so they are terminal without polling.
+	for _, id := range []string{a.ID, b.ID} {
+		if op, _ := m.Operation(id); op.State != OpCanceled {
+			t.Fatalf("expected %s canceled after Close, got %+v", id, op)
+		}
+	}
+	// Ops started after Close are canceled immediately.
+	c, _ := m.LoadModel("a")
+	if got := waitOp(t, m, c.ID); got.State != OpCanceled {
+		t.Fatalf("expected canceled op after Close, got %+v", got)
+	}
+}
+
+func TestOps_RetentionDropsOldestFinished(t *testing.T) {
+	m := NewWithConfig(ManagerConfig{Registry: []types.Model{{ID: "m", Path: "m.gguf"}}})
+	var first string
+	for i := 0; i < maxRetainedOps+5; i++ {
+		id, _ := m.Switch(testCtx(t), "missing")
+		if i == 0 {
+			first = id
+		}
+		waitOp(t, m, id)
+	}
+	if _, ok := m.Operation(first); ok {
+		t.Fatalf("expected oldest op to be pruned")
+	}
+	m.opsMu.Lock()
+	n := len(m.ops)
+	m.opsMu.Unlock()
+	if n > maxRetainedOps {
+		t.Fatalf("retained %d ops, want <= %d", n, maxRetainedOps)
+	}
+}
@@ -3,8 +3,10 @@ package manager
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"modeld/internal/registry"
 	"modeld/pkg/types"
 )
 
@@ -50,3 +52,20 @@ func TestSanityCheck_DefaultModelPathSetForNonServer(t *testing.T) {
 		t.Fatalf("expected LlamaFound true when adapter is set later, got %+v", r)
 	}
 }
+
+func TestPreflight_ReportsRegistryDuplicates(t *testing.T) {
+	dups := []registry.Duplicate{{Path: "/b/m.gguf", Of: "m.gguf", Reason: registry.DuplicateSameFile}}
+	m := NewWithConfig(ManagerConfig{
+		Registry:           []types.Model{{ID: "m.gguf", Path: "/a/m.gguf"}},
+		RegistryDuplicates: func() []registry.Duplicate { return dups },
+	})
+	var found *Check
+	for _, c := range m.Preflight() {
+		if c.Name == "registry_duplicates" {
+			found = &c
+		}
+	}
+	if found == nil || !found.OK || !strings.Contains(found.Message, "/b/m.gguf") {
+		t.Fatalf("registry_duplicates check = %+v", found)
+	}
+}
@@ -0,0 +1,132 @@
+package manager
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+
+	"modeld/pkg/types"
+)
+
+// globalProfile returns the runtime profile described by the global llama
+// flags, the bottom layer of every model's profile.
+func globalProfile(cfg ManagerConfig) types.RuntimeProfile {
+	p := types.RuntimeProfile{
+		ContextSize: cfg.LlamaCtxSize,
+		Threads:     cfg.LlamaThreads,
+		ExtraArgs:   slices.Clone(cfg.LlamaExtraArgs),
+	}
+	if cfg.LlamaNGL > 0 {
+		ngl := cfg.LlamaNGL
+		p.GPULayers = &ngl
+	}
+	return p
+}
+
+// resolveProfile layers mdl's settings over base: the manifest's
+// context_size and llama_args, then its profile, then the config profile
+// keyed by the model ID (or, failing that, one of its aliases).
+func resolveProfile(base types.RuntimeProfile, mdl types.Model, profiles map[string]types.RuntimeProfile) types.RuntimeProfile {
+	p := base
+	p.ExtraArgs = slices.Clone(base.ExtraArgs)
+	p.Env = maps.Clone(base.Env)
+	mergeProfile(&p, types.RuntimeProfile{ContextSize: mdl.ContextSize, ExtraArgs: mdl.LlamaArgs})
+	if mdl.Profile != nil {
+		mergeProfile(&p, *mdl.Profile)
+	}
+	for _, key := range append([]string{mdl.ID}, mdl.Aliases...) {
+		if cp, ok := profiles[key]; ok {
+			mergeProfile(&p, cp)
+			break
+		}
+	}
+	return p
+}
+
+// runtimeProfile returns the effective llama-server settings for mdl.
+func (m *Manager) runtimeProfile(mdl types.Model) types.RuntimeProfile {
+	return resolveProfile(m.baseProfile, mdl, m.modelProfiles)
+}
+
+// mergeProfile overrides dst with the set fields of src.
+func mergeProfile(dst *types.RuntimeProfile, src types.RuntimeProfile) {
+	if src.ContextSize > 0 {
+		dst.ContextSize = src.ContextSize
+	}
+	if src.GPULayers != nil {
+		ngl := *src.GPULayers
+		dst.GPULayers = &ngl
+	}
+	if src.Threads > 0 {
+		dst.Threads = src.Threads
+	}
+	if src.BatchSize > 0 {
+		dst.BatchSize = src.BatchSize
+	}
+	if src.UBatchSize > 0 {
+		dst.UBatchSize = src.UBatchSize
+	}
+	if src.RopeScaling != "" {
+		dst.RopeScaling = src.RopeScaling
+	}
+	if src.RopeFreqBase > 0 {
+		dst.RopeFreqBase = src.RopeFreqBase
+	}
+	if src.RopeFreqScale > 0 {
+		dst.RopeFreqScale = src.RopeFreqScale
+	}
+	if src.FlashAttn != "" {
+		dst.FlashAttn = src.FlashAttn
+	}
+	dst.ExtraArgs = append(dst.ExtraArgs, src.ExtraArgs...)
+	if len(src.Env) > 0 {
+		if dst.Env == nil {
+			dst.Env = make(map[string]string, len(src.Env))
+		}
+		maps.Copy(dst.Env, src.Env)
+	}
+}
+
+// profileArgs returns the llama-server flags for p's typed settings; the
+// caller appends p.ExtraArgs last so they can override any of them.
+func profileArgs(p types.RuntimeProfile) []string {
+	var args []string
+	add := func(flag, val string) { args = append(args, flag, val) }
+	if p.ContextSize > 0 {
+		add("-c", strconv.Itoa(p.ContextSize))
+	}
+	if p.GPULayers != nil {
+		add("-ngl", strconv.Itoa(*p.GPULayers))
+	}
+	if p.Threads > 0 {
+		add("-t", strconv.Itoa(p.Threads))
+	}
+	if p.BatchSize > 0 {
+		add("-b", strconv.Itoa(p.BatchSize))
+	}
+	if p.UBatchSize > 0 {
+		add("-ub", strconv.Itoa(p.UBatchSize))
+	}
+	if p.FlashAttn != "" {
+		add("--flash-attn", p.FlashAttn)
+	}
+	if p.RopeScaling != "" {
+		add("--rope-scaling", p.RopeScaling)
+	}
+	if p.RopeFreqBase > 0 {
+		add("--rope-freq-base", strconv.FormatFloat(p.RopeFreqBase, 'g', -1, 64))
+	}
+	if p.RopeFreqScale > 0 {
+		add("--rope-freq-scale", strconv.FormatFloat(p.RopeFreqScale, 'g', -1, 64))
+	}
+	return args
+}
+
+// profileEnv returns p.Env as sorted KEY=VALUE pairs.
+func profileEnv(p types.RuntimeProfile) []string {
+	env := make([]string, 0, len(p.Env))
+	for _, k := range slices.Sorted(maps.Keys(p.Env)) {
+		env = append(env, k+"="+p.Env[k])
+	}
+	return env
+}
@@ -0,0 +1,87 @@
+package manager
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"modeld/pkg/types"
+)
+
+func TestResolveProfile_Layers(t *testing.T) {
+	ngl0, ngl99 := 0, 99
+	cfg := ManagerConfig{LlamaCtxSize: 2048, LlamaNGL: 20, LlamaThreads: 4, LlamaExtraArgs: []string{"--mlock"}}
+	mdl := types.Model{
+		ID:        "big",
+		Aliases:   []string{"llama-70b"},
+		LlamaArgs: []string{"--no-mmap"},
+		Profile: &types.RuntimeProfile{
+			ContextSize: 8192,
+			GPULayers:   &ngl99,
+			FlashAttn:   "on",
+			Env:         map[string]string{"CUDA_VISIBLE_DEVICES": "0", "GGML_CUDA_NO_PINNED": "1"},
+		},
+	}
+	profiles := map[string]types.RuntimeProfile{
+		"llama-70b": {GPULayers: &ngl0, BatchSize: 512, ExtraArgs: []string{"--numa", "distribute"}, Env: map[string]string{"CUDA_VISIBLE_DEVICES": "1"}},
+	}
+	p := resolveProfile(globalProfile(cfg), mdl, profiles)
+	if p.ContextSize != 8192 || p.Threads != 4 || p.BatchSize != 512 || p.FlashAttn != "on" {
+		t.Fatalf("unexpected scalar settings: %+v", p)
+	}
+	if p.GPULayers == nil || *p.GPULayers != 0 {
+		t.Fatalf("config profile should force ngl 0 (CPU only): %v", p.GPULayers)
+	}
+	if want := []string{"--mlock", "--no-mmap", "--numa", "distribute"}; !slices.Equal(p.ExtraArgs, want) {
+		t.Fatalf("extra args = %v, want %v", p.ExtraArgs, want)
+	}
+	if env := profileEnv(p); !slices.Equal(env, []string{"CUDA_VISIBLE_DEVICES=1", "GGML_CUDA_NO_PINNED=1"}) {
+		t.Fatalf("env = %v", env)
+	}
+	// Resolving must not leak into the shared base or the model's profile.
+	if base := globalProfile(cfg); len(base.Env) != 0 || *base.GPULayers != 20 {
+		t.Fatalf("base profile mutated: %+v", base)
+	}
+	if *mdl.Profile.GPULayers != 99 || mdl.Profile.Env["CUDA_VISIBLE_DEVICES"] != "0" {
+		t.Fatalf("model profile mutated: %+v", mdl.Profile)
+	}
+}
+
+func TestSpawnArgs_RuntimeProfile(t *testing.T) {
+	ngl := 0
+	sa := NewLlamaSubprocessAdapter(ManagerConfig{LlamaNGL: 33}).(*llamaSubprocessAdapter)
+	sa.setProfile("m.gguf", types.RuntimeProfile{
+		ContextSize:   32768,
+		GPULayers:     &ngl,
+		Threads:       16,
+		BatchSize:     1024,
+		UBatchSize:    256,
+		RopeScaling:   "yarn",
+		RopeFreqScale: 0.25,
+		FlashAttn:     "auto",
+		ExtraArgs:     []string{"-c", "16384"},
+	})
+	args := strings.Join(sa.spawnArgs("m.gguf", "127.0.0.1", 30000), " ")
+	want := "-c 32768 -ngl 0 -t 16 -b 1024 -ub 256 --flash-attn auto --rope-scaling yarn --rope-freq-scale 0.25 -c 16384"
+	if !strings.HasSuffix(args, want) {
+		t.Fatalf("args = %s\nwant suffix %s", args, want)
+	}
+	if args := strings.Join(sa.spawnArgs("other.gguf", "127.0.0.1", 30000), " "); !strings.Contains(args, "-ngl 33") {
+		t.Fatalf("expected global ngl for models without a profile: %s", args)
+	}
+}
+
+func TestEstimateVRAM_UsesProfile(t *testing.T) {
+	dir := t.TempDir()
+	path := writeGGUFModel(t, dir)
+	ngl := 0
+	m := NewWithConfig(ManagerConfig{
+		LlamaNGL:      99,
+		ModelProfiles: map[string]types.RuntimeProfile{"cpu": {GPULayers: &ngl}},
+	})
+	full := m.estimateVRAM(types.Model{ID: "gpu", Path: path}, 1)
+	cpu := m.estimateVRAM(types.Model{ID: "cpu", Path: path}, 1)
+	if full.GPULayers == 0 || cpu.GPULayers != 0 || cpu.TotalMB >= full.TotalMB {
+		t.Fatalf("profile ngl not applied: full=%+v cpu=%+v", full, cpu)
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"time"
 )
 
-// beginGeneration reserves a queue slot and then the single in-flight slot.
+// beginGeneration waits for a generation slot on the model's instance using
+// the instance scheduler (priority classes, fair queuing across tenants).
+// The priority and tenant are taken from ctx (see WithPriority, WithTenant).
 // Returns a release func to be deferred.
 func (m *Manager) beginGeneration(ctx context.Context, modelID string) (func(), error) {
 	m.mu.RLock()
@@ -18,48 +20,36 @@ func (m *Manager) beginGeneration(ctx context.Context, modelID string) (func(),
 	if inst.State == StateDraining {
 		return func() {}, tooBusyError{modelID: modelID}
 	}
+	// A crashed runtime would only let the request time out in the queue
+	m.mu.RLock()
+	restarting := inst.restarting
+	m.mu.RUnlock()
+	if restarting {
+		return func() {}, errCrashed(modelID, true)
+	}
 
 	// Fast path: respect an already-canceled context
 	if err := ctx.Err(); err != nil {
 		return func() {}, err
 	}
-
-	// Try to reserve a queue slot with timeout (pooled timer to reduce allocations)
-	timer := time.NewTimer(m.maxWait)
-	defer timer.Stop()
-	select {
-	case inst.queueCh <- struct{}{}:
-		// reserved queue slot
-	case <-ctx.Done():
-		return func() {}, ctx.Err()
-	case <-timer.C:
-		return func() {}, tooBusyError{modelID: modelID}
+	prio, err := ParsePriority(priorityFromContext(ctx))
+	if err != nil {
+		return func() {}, err
 	}
 
-	// Wait to acquire the single in-flight slot
-	acquired := false
-	defer func() {
-		if !acquired {
-			<-inst.queueCh
-		}
-	}()
-	// Check for cancellation again before blocking on gen slot
-	if err := ctx.Err(); err != nil {
+	waitStart := time.Now()
+	release, err := inst.sched.acquire(ctx, modelID, prio, TenantFromContext(ctx), m.maxWait)
+	if err != nil {
 		return func() {}, err
 	}
-	timer2 := time.NewTimer(m.maxWait)
-	defer timer2.Stop()
-	select {
-	case inst.genCh <- struct{}{}:
-		acquired = true
-		// update last used
-		m.mu.Lock()
-		inst.LastUsed = time.Now()
-		m.mu.Unlock()
-		return func() { <-inst.genCh; <-inst.queueCh }, nil
-	case <-ctx.Done():
-		return func() {}, ctx.Err()
-	case <-timer2.C:
-		return func() {}, tooBusyError{modelID: modelID}
-	}
+	managerQueueWait.WithLabelValues(modelID).Observe(time.Since(waitStart).Seconds())
+	managerInflight.WithLabelValues(modelID).Inc()
+	// update last used
+	m.mu.Lock()
+	inst.LastUsed = time.Now()
+	m.mu.Unlock()
+	return func() {
+		managerInflight.WithLabelValues(modelID).Dec()
+		release()
+	}, nil
 }
@@ -8,9 +8,8 @@ import (
 	"modeld/pkg/types"
 )
 
-// Covers the ctx.Done branch when attempting to reserve a queue slot.
+// Covers the already-canceled fast path before queueing.
 func TestBeginGeneration_CancelBeforeQueue(t *testing.T) {
-	// queue depth 0 so the send on queueCh blocks and select can pick ctx.Done
 	dir := t.TempDir()
 	p := createModelFile(t, dir, "m.bin", 1)
 	m := NewWithConfig(ManagerConfig{Registry: []types.Model{{ID: "m", Path: p}}, DefaultModel: "m", MaxQueueDepth: 0, MaxWait: 200 * time.Millisecond})
@@ -24,7 +23,7 @@ func TestBeginGeneration_CancelBeforeQueue(t *testing.T) {
 	}
 }
 
-// Covers the ctx.Done branch while waiting for genCh after queue slot reserved.
+// Covers the ctx.Done branch while queued for a generation slot.
 func TestBeginGeneration_CancelWhileWaitingForGen(t *testing.T) {
 	dir := t.TempDir()
 	p := createModelFile(t, dir, "m.bin", 1)
@@ -32,11 +31,12 @@ func TestBeginGeneration_CancelWhileWaitingForGen(t *testing.T) {
 	if err := m.EnsureInstance(context.Background(), "m"); err != nil {
 		t.Fatalf("ensure: %v", err)
 	}
-	// Saturate genCh to force blocking on second phase
-	m.mu.RLock()
-	inst := m.instances["m"]
-	m.mu.RUnlock()
-	inst.genCh <- struct{}{}
+	// Occupy the only generation slot so the next request has to queue
+	rel, err := m.beginGeneration(context.Background(), "m")
+	if err != nil {
+		t.Fatalf("beginGeneration first: %v", err)
+	}
+	defer rel()
 	ctx, cancel := context.WithCancel(context.Background())
 	go func() {
 		// cancel shortly after to hit ctx.Done case
@@ -46,6 +46,4 @@ func TestBeginGeneration_CancelWhileWaitingForGen(t *testing.T) {
 	if _, err := m.beginGeneration(ctx, "m"); err == nil {
 		t.Fatalf("expected error due to canceled context while waiting for gen slot")
 	}
-	// cleanup the token placed into genCh
-	<-inst.genCh
 }
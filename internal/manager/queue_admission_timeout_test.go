@@ -13,31 +13,59 @@ func TestBeginGeneration_QueueTimeout(t *testing.T) {
 	if err := m.EnsureInstance(context.Background(), "m"); err != nil {
 		t.Fatalf("EnsureInstance: %v", err)
 	}
-	// First acquire to occupy both queue and gen slots
+	// First acquire to occupy the generation slot
 	rel, err := m.beginGeneration(context.Background(), "m")
 	if err != nil {
 		t.Fatalf("beginGeneration first: %v", err)
 	}
 	defer rel()
-	// Second should timeout on queue slot (since depth=1)
+	// Second queues (depth=1) and times out waiting for the slot
 	_, err = m.beginGeneration(context.Background(), "m")
 	if err == nil || !IsTooBusy(err) {
 		t.Fatalf("expected tooBusyError, got %v", err)
 	}
 }
 
+func TestBeginGeneration_QueueFullRejectsImmediately(t *testing.T) {
+	m := NewWithConfig(ManagerConfig{Registry: []types.Model{{ID: "m", Path: "m.gguf"}}, DefaultModel: "m", MaxQueueDepth: 2, MaxWait: time.Second})
+	if err := m.EnsureInstance(context.Background(), "m"); err != nil {
+		t.Fatalf("EnsureInstance: %v", err)
+	}
+	rel, err := m.beginGeneration(context.Background(), "m")
+	if err != nil {
+		t.Fatalf("beginGeneration first: %v", err)
+	}
+	defer rel()
+	// The in-flight request and one waiter fill the normal-priority depth
+	go func() {
+		if r, err := m.beginGeneration(context.Background(), "m"); err == nil {
+			r()
+		}
+	}()
+	waitQueued(t, m, "m", 1)
+	start := time.Now()
+	_, err = m.beginGeneration(context.Background(), "m")
+	if err == nil || !IsTooBusy(err) {
+		t.Fatalf("expected tooBusyError, got %v", err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("full queue should reject without waiting")
+	}
+}
+
 func TestBeginGeneration_GenTimeout(t *testing.T) {
 	m := NewWithConfig(ManagerConfig{Registry: []types.Model{{ID: "m", Path: "m.gguf"}}, DefaultModel: "m", MaxQueueDepth: 2, MaxWait: 20 * time.Millisecond})
 	if err := m.EnsureInstance(context.Background(), "m"); err != nil {
 		t.Fatalf("EnsureInstance: %v", err)
 	}
-	// Occupy genCh so acquisitions will block at gen stage
-	m.mu.Lock()
-	inst := m.instances["m"]
-	inst.genCh <- struct{}{}
-	m.mu.Unlock()
-	// Should acquire queue slot, then timeout on gen slot resulting in tooBusy
-	_, err := m.beginGeneration(context.Background(), "m")
+	// Occupy the generation slot so acquisitions have to wait
+	rel, err := m.beginGeneration(context.Background(), "m")
+	if err != nil {
+		t.Fatalf("beginGeneration first: %v", err)
+	}
+	defer rel()
+	// Should queue, then time out waiting for the slot resulting in tooBusy
+	_, err = m.beginGeneration(context.Background(), "m")
 	if err == nil || !IsTooBusy(err) {
 		t.Fatalf("expected tooBusyError on gen wait, got %v", err)
 	}
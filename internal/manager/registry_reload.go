@@ -0,0 +1,95 @@
+package manager
+
+import (
+	"log"
+	"os"
+	"slices"
+
+	"modeld/pkg/types"
+)
+
+// ReloadRegistry rescans the models with the configured RegistryLoader and
+// applies the result with SetRegistry. A failed scan keeps the current
+// registry.
+func (m *Manager) ReloadRegistry() (types.RegistryReloadResponse, error) {
+	if m.registryLoader == nil {
+		return types.RegistryReloadResponse{}, ErrDependencyUnavailable("registry loader not configured")
+	}
+	models, err := m.registryLoader()
+	if err != nil {
+		m.publisher.Publish(Event{Name: "registry_reload_error", Fields: map[string]any{"error": err.Error()}})
+		return types.RegistryReloadResponse{}, err
+	}
+	return m.SetRegistry(models), nil
+}
+
+// SetRegistry atomically replaces the model registry. It publishes
+// model_added and model_removed events and starts a graceful unload of every
+// loaded instance whose model was removed or whose file no longer exists.
+func (m *Manager) SetRegistry(models []types.Model) types.RegistryReloadResponse {
+	models = slices.Clone(models)
+	m.regMu.Lock()
+	old := m.registry
+	m.registry = models
+	m.regMu.Unlock()
+	// Files may have been replaced in place; re-read headers on next use.
+	m.ggufMu.Lock()
+	m.ggufCache = nil
+	m.ggufMu.Unlock()
+
+	resp := types.RegistryReloadResponse{Models: len(models), Added: []string{}, Removed: []string{}, Unloading: []string{}}
+	oldIDs := make(map[string]bool, len(old))
+	for _, mdl := range old {
+		oldIDs[mdl.ID] = true
+	}
+	newModels := make(map[string]types.Model, len(models))
+	for _, mdl := range models {
+		newModels[mdl.ID] = mdl
+		if !oldIDs[mdl.ID] {
+			resp.Added = append(resp.Added, mdl.ID)
+			m.publisher.Publish(Event{Name: "model_added", ModelID: mdl.ID, Fields: map[string]any{"path": mdl.Path}})
+		}
+	}
+	for _, mdl := range old {
+		if _, ok := newModels[mdl.ID]; !ok {
+			resp.Removed = append(resp.Removed, mdl.ID)
+			m.publisher.Publish(Event{Name: "model_removed", ModelID: mdl.ID, Fields: map[string]any{"path": mdl.Path}})
+		}
+	}
+
+	// Instances keep serving until drained; their model is gone from the
+	// registry or their file disappeared (or moved under the same ID).
+	m.mu.RLock()
+	var stale []string
+	for id, inst := range m.instances {
+		if inst == nil || inst.State == StateDraining {
+			continue
+		}
+		mdl, ok := newModels[id]
+		if !ok || (inst.path != "" && mdl.Path != inst.path) {
+			stale = append(stale, id)
+			continue
+		}
+		for _, p := range modelFiles(mdl) {
+			if _, err := os.Stat(p); err != nil {
+				stale = append(stale, id)
+				break
+			}
+		}
+	}
+	m.mu.RUnlock()
+	slices.Sort(stale)
+	for _, id := range stale {
+		if _, err := m.unloadOp(id); err == nil {
+			resp.Unloading = append(resp.Unloading, id)
+		}
+	}
+	if m.defaultModel != "" {
+		if _, ok := m.getModelByID(m.defaultModel); !ok {
+			log.Printf("manager event=registry_default_missing model=%q", m.defaultModel)
+		}
+	}
+	log.Printf("manager event=registry_reload models=%d added=%d removed=%d unloading=%d",
+		resp.Models, len(resp.Added), len(resp.Removed), len(resp.Unloading))
+	return resp
+}
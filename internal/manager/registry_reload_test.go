@@ -0,0 +1,116 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"modeld/pkg/types"
+)
+
+func TestSetRegistry_DiffAndEvents(t *testing.T) {
+	dir := t.TempDir()
+	a := createModelFile(t, dir, "a.gguf", 1)
+	b := createModelFile(t, dir, "b.gguf", 1)
+	m := NewWithConfig(ManagerConfig{Registry: []types.Model{{ID: "a", Path: a}}})
+	sub := m.SubscribeEvents(func(e Event) bool { return e.Name == "model_added" || e.Name == "model_removed" })
+	defer sub.Close()
+
+	resp := m.SetRegistry([]types.Model{{ID: "b", Path: b}})
+	if resp.Models != 1 || !slices.Equal(resp.Added, []string{"b"}) || !slices.Equal(resp.Removed, []string{"a"}) {
+		t.Fatalf("resp = %+v", resp)
+	}
+	if _, ok := m.getModelByID("a"); ok {
+		t.Fatalf("a still in registry")
+	}
+	got := map[string]string{}
+	for len(got) < 2 {
+		select {
+		case e := <-sub.C():
+			got[e.Name] = e.ModelID
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for events, got %v", got)
+		}
+	}
+	if got["model_added"] != "b" || got["model_removed"] != "a" {
+		t.Fatalf("events = %v", got)
+	}
+}
+
+func TestSetRegistry_UnloadsStaleInstances(t *testing.T) {
+	dir := t.TempDir()
+	a := createModelFile(t, dir, "a.gguf", 1)
+	b := createModelFile(t, dir, "b.gguf", 1)
+	c := createModelFile(t, dir, "c.gguf", 1)
+	reg := []types.Model{{ID: "a", Path: a}, {ID: "b", Path: b}, {ID: "c", Path: c}}
+	m := NewWithConfig(ManagerConfig{Registry: reg, DrainTimeout: 200 * time.Millisecond})
+	m.adapter = &fakeAdapter{}
+	for _, id := range []string{"a", "b", "c"} {
+		if err := m.EnsureInstance(context.Background(), id); err != nil {
+			t.Fatalf("EnsureInstance %s: %v", id, err)
+		}
+	}
+
+	// a is removed, b's file disappears, c is unchanged.
+	if err := os.Remove(b); err != nil {
+		t.Fatal(err)
+	}
+	resp := m.SetRegistry(reg[1:])
+	if !slices.Equal(resp.Unloading, []string{"a", "b"}) {
+		t.Fatalf("unloading = %v", resp.Unloading)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		m.mu.RLock()
+		_, hasA := m.instances["a"]
+		_, hasB := m.instances["b"]
+		_, hasC := m.instances["c"]
+		m.mu.RUnlock()
+		if !hasA && !hasB {
+			if !hasC {
+				t.Fatalf("unchanged instance c was unloaded")
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("stale instances not unloaded: a=%v b=%v", hasA, hasB)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReloadRegistry_Loader(t *testing.T) {
+	m := NewWithConfig(ManagerConfig{Registry: []types.Model{{ID: "a", Path: "a.gguf"}}})
+	if _, err := m.ReloadRegistry(); !IsDependencyUnavailable(err) {
+		t.Fatalf("without loader: err = %v, want dependency unavailable", err)
+	}
+
+	scanErr := errors.New("scan failed")
+	m = NewWithConfig(ManagerConfig{
+		Registry:       []types.Model{{ID: "a", Path: "a.gguf"}},
+		RegistryLoader: func() ([]types.Model, error) { return nil, scanErr },
+	})
+	if _, err := m.ReloadRegistry(); !errors.Is(err, scanErr) {
+		t.Fatalf("err = %v, want %v", err, scanErr)
+	}
+	if _, ok := m.getModelByID("a"); !ok {
+		t.Fatalf("failed reload dropped the registry")
+	}
+
+	m.registryLoader = func() ([]types.Model, error) {
+		return []types.Model{{ID: "a", Path: "a.gguf"}, {ID: "b", Path: "b.gguf"}}, nil
+	}
+	resp, err := m.ReloadRegistry()
+	if err != nil {
+		t.Fatalf("ReloadRegistry: %v", err)
+	}
+	if resp.Models != 2 || !slices.Equal(resp.Added, []string{"b"}) || len(resp.Removed) != 0 {
+		t.Fatalf("resp = %+v", resp)
+	}
+	if got := len(m.ListModels()); got != 2 {
+		t.Fatalf("ListModels = %d, want 2", got)
+	}
+}
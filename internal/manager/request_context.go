@@ -0,0 +1,116 @@
+package manager
+
+import (
+	"context"
+	"time"
+)
+
+type ctxKey int
+
+const (
+	tenantKey ctxKey = iota
+	priorityKey
+	allowedModelsKey
+	usageHookKey
+	resolvedHookKey
+)
+
+// WithTenant returns a context carrying the tenant (API key ID, client
+// address, ...) used for fair scheduling between callers.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey, tenant)
+}
+
+// TenantFromContext returns the tenant set by WithTenant, or "".
+func TenantFromContext(ctx context.Context) string {
+	s, _ := ctx.Value(tenantKey).(string)
+	return s
+}
+
+// WithPriority returns a context carrying a request priority name (see
+// ParsePriority). InferRequest.Priority takes precedence when set.
+func WithPriority(ctx context.Context, priority string) context.Context {
+	return context.WithValue(ctx, priorityKey, priority)
+}
+
+// priorityFromContext returns the priority name set by WithPriority, or "".
+func priorityFromContext(ctx context.Context) string {
+	s, _ := ctx.Value(priorityKey).(string)
+	return s
+}
+
+// WithAllowedModels restricts the models a request may use. A nil list means
+// no restriction; an empty non-nil list allows nothing.
+func WithAllowedModels(ctx context.Context, models []string) context.Context {
+	if models == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, allowedModelsKey, models)
+}
+
+// ModelAllowed reports whether ctx permits modelID (see WithAllowedModels).
+func ModelAllowed(ctx context.Context, modelID string) bool {
+	models, ok := ctx.Value(allowedModelsKey).([]string)
+	if !ok {
+		return true
+	}
+	for _, id := range models {
+		if id == modelID {
+			return true
+		}
+	}
+	return false
+}
+
+// UsageReport describes one finished inference for accounting.
+type UsageReport struct {
+	ModelID string
+	Usage   Usage
+	// GenerationTime is how long the request held a generation slot.
+	GenerationTime time.Duration
+}
+
+// UsageFunc receives the usage of an inference once generation ends,
+// including partial usage when generation fails or is canceled.
+type UsageFunc func(UsageReport)
+
+// WithUsageHook returns a context whose inferences report usage to fn, in
+// addition to any hooks already attached to ctx.
+func WithUsageHook(ctx context.Context, fn UsageFunc) context.Context {
+	if prev, ok := ctx.Value(usageHookKey).(UsageFunc); ok && prev != nil {
+		next := fn
+		fn = func(r UsageReport) {
+			prev(r)
+			next(r)
+		}
+	}
+	return context.WithValue(ctx, usageHookKey, fn)
+}
+
+// reportUsage calls the usage hook attached to ctx, if any.
+func reportUsage(ctx context.Context, r UsageReport) {
+	if fn, ok := ctx.Value(usageHookKey).(UsageFunc); ok && fn != nil {
+		fn(r)
+	}
+}
+
+// WithResolvedModelHook returns a context whose inferences call fn with the
+// registry model ID the request was routed to (after alias resolution),
+// before any output is written, in addition to any hooks already attached.
+func WithResolvedModelHook(ctx context.Context, fn func(modelID string)) context.Context {
+	if prev, ok := ctx.Value(resolvedHookKey).(func(string)); ok && prev != nil {
+		next := fn
+		fn = func(modelID string) {
+			prev(modelID)
+			next(modelID)
+		}
+	}
+	return context.WithValue(ctx, resolvedHookKey, fn)
+}
+
+// reportResolvedModel calls the resolved-model hook attached to ctx, if any.
+func reportResolvedModel(ctx context.Context, modelID string) {
+	if fn, ok := ctx.Value(resolvedHookKey).(func(string)); ok && fn != nil {
+		fn(modelID)
+	}
+}
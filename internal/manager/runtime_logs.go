@@ -0,0 +1,298 @@
+package manager
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"modeld/pkg/types"
+)
+
+// Runtime log defaults (spawn mode).
+const (
+	defaultRuntimeLogLines   = 1000
+	defaultRuntimeLogMaxMB   = 10
+	defaultRuntimeLogBackups = 3
+	maxRuntimeLogLine        = 64 * 1024 // longer lines are split
+	runtimeLogSubBuffer      = 256
+)
+
+// runtimeLog keeps the recent output of the processes spawned for one model
+// in a ring buffer, optionally appends it to a rotated file, forwards it to
+// a structured logger and fans it out to followers. It outlives the
+// processes so the output of a crashed runtime can still be read.
+type runtimeLog struct {
+	mu     sync.Mutex
+	lines  []types.LogLine // ring: next is the slot the next line goes to
+	next   int
+	full   bool
+	file   *rotatingFile
+	logger *zerolog.Logger
+	model  string
+	subs   map[*LogSubscription]struct{}
+}
+
+func newRuntimeLog(model string, size int, file *rotatingFile, logger *zerolog.Logger) *runtimeLog {
+	if size <= 0 {
+		size = defaultRuntimeLogLines
+	}
+	return &runtimeLog{lines: make([]types.LogLine, size), file: file, logger: logger, model: model, subs: map[*LogSubscription]struct{}{}}
+}
+
+// writer returns an io.Writer that records complete lines of stream for
+// process pid. Call flush after the process exits to record a trailing
+// partial line.
+func (l *runtimeLog) writer(stream string, pid int) *logLineWriter {
+	return &logLineWriter{log: l, stream: stream, pid: pid}
+}
+
+func (l *runtimeLog) append(line types.LogLine) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines[l.next] = line
+	l.next = (l.next + 1) % len(l.lines)
+	if l.next == 0 {
+		l.full = true
+	}
+	if l.file != nil {
+		_ = l.file.writeLine(fmt.Sprintf("%s pid=%d %s %s", time.UnixMilli(line.TimeUnixMs).UTC().Format(time.RFC3339Nano), line.PID, line.Stream, line.Line))
+	}
+	if l.logger != nil {
+		l.logger.Info().Str("model", l.model).Int("pid", line.PID).Str("stream", line.Stream).Msg(line.Line)
+	}
+	for s := range l.subs {
+		select {
+		case s.ch <- line:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
+
+// tailLocked returns the last n buffered lines (all when n <= 0), oldest
+// first. Caller holds l.mu.
+func (l *runtimeLog) tailLocked(n int) []types.LogLine {
+	var out []types.LogLine
+	if l.full {
+		out = append(out, l.lines[l.next:]...)
+	}
+	out = append(out, l.lines[:l.next]...)
+	if n > 0 && n < len(out) {
+		out = out[len(out)-n:]
+	}
+	return out
+}
+
+// tail returns the last n buffered lines (all when n <= 0), oldest first.
+func (l *runtimeLog) tail(n int) []types.LogLine {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.tailLocked(n)
+}
+
+// follow returns the last n lines and a subscription to the lines after
+// them, with nothing lost or repeated in between.
+func (l *runtimeLog) follow(n int) ([]types.LogLine, *LogSubscription) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s := &LogSubscription{log: l, ch: make(chan types.LogLine, runtimeLogSubBuffer)}
+	l.subs[s] = struct{}{}
+	return l.tailLocked(n), s
+}
+
+// stderrTail returns up to max bytes of the most recent stderr output of pid,
+// for error messages.
+func (l *runtimeLog) stderrTail(pid, max int) string {
+	var b strings.Builder
+	for _, line := range l.tail(0) {
+		if line.PID == pid && line.Stream == "stderr" {
+			b.WriteString(line.Line)
+			b.WriteByte('\n')
+		}
+	}
+	s := b.String()
+	if len(s) > max {
+		s = s[len(s)-max:]
+	}
+	return s
+}
+
+// LogSubscription receives runtime log lines until closed. Lines are dropped
+// (and counted) when the subscriber falls behind.
+type LogSubscription struct {
+	log     *runtimeLog
+	ch      chan types.LogLine
+	dropped atomic.Uint64
+	once    sync.Once
+}
+
+// C returns the channel of new lines.
+func (s *LogSubscription) C() <-chan types.LogLine { return s.ch }
+
+// Dropped returns how many lines were dropped because the buffer was full.
+func (s *LogSubscription) Dropped() uint64 { return s.dropped.Load() }
+
+// Close unsubscribes. Safe to call multiple times.
+func (s *LogSubscription) Close() {
+	s.once.Do(func() {
+		s.log.mu.Lock()
+		delete(s.log.subs, s)
+		s.log.mu.Unlock()
+	})
+}
+
+// logLineWriter splits process output into lines for a runtimeLog.
+type logLineWriter struct {
+	log    *runtimeLog
+	stream string
+	pid    int
+	buf    []byte
+}
+
+func (w *logLineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		if i := bytes.IndexByte(w.buf, '\n'); i >= 0 && i <= maxRuntimeLogLine {
+			w.emit(w.buf[:i])
+			w.buf = w.buf[i+1:]
+		} else if len(w.buf) >= maxRuntimeLogLine {
+			w.emit(w.buf[:maxRuntimeLogLine])
+			w.buf = w.buf[maxRuntimeLogLine:]
+		} else {
+			break
+		}
+	}
+	// Keep the pending partial line in a buffer of its own.
+	w.buf = append([]byte(nil), w.buf...)
+	return len(p), nil
+}
+
+// flush records a trailing partial line.
+func (w *logLineWriter) flush() {
+	if len(w.buf) > 0 {
+		w.emit(w.buf)
+		w.buf = nil
+	}
+}
+
+func (w *logLineWriter) emit(b []byte) {
+	line := strings.TrimSuffix(string(b), "\r")
+	w.log.append(types.LogLine{TimeUnixMs: time.Now().UnixMilli(), PID: w.pid, Stream: w.stream, Line: line})
+}
+
+// rotatingFile appends lines to path, renaming it to path.1 (shifting older
+// backups up to path.<backups>) once it would exceed maxBytes.
+type rotatingFile struct {
+	path     string
+	maxBytes int64
+	backups  int
+	f        *os.File
+	size     int64
+}
+
+func newRotatingFile(path string, maxBytes int64, backups int) *rotatingFile {
+	return &rotatingFile{path: path, maxBytes: maxBytes, backups: backups}
+}
+
+// writeLine appends line and a newline. The caller serializes calls.
+func (r *rotatingFile) writeLine(line string) error {
+	if r.f == nil {
+		if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return err
+		}
+		fi, err := f.Stat()
+		if err != nil {
+			_ = f.Close()
+			return err
+		}
+		r.f, r.size = f, fi.Size()
+	}
+	n := int64(len(line) + 1)
+	if r.maxBytes > 0 && r.size > 0 && r.size+n > r.maxBytes {
+		if err := r.rotate(); err != nil {
+			return err
+		}
+		return r.writeLine(line)
+	}
+	_, err := r.f.WriteString(line + "\n")
+	r.size += n
+	return err
+}
+
+func (r *rotatingFile) rotate() error {
+	_ = r.f.Close()
+	r.f = nil
+	if r.backups <= 0 {
+		return os.Remove(r.path)
+	}
+	for i := r.backups - 1; i >= 1; i-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+	}
+	return os.Rename(r.path, r.path+".1")
+}
+
+// runtimeLogFile returns the file name for modelID's runtime log.
+func runtimeLogFile(dir, modelID string) string {
+	name := strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(modelID)
+	return filepath.Join(dir, name+".log")
+}
+
+// SetRuntimeLogger forwards the output of spawned runtimes to logger, one
+// entry per line with model, pid and stream fields.
+func (m *Manager) SetRuntimeLogger(logger zerolog.Logger) {
+	if sa, ok := m.adapter.(*llamaSubprocessAdapter); ok {
+		sa.setLogger(logger)
+	}
+}
+
+// InstanceLogs returns the last tail lines (all buffered when tail <= 0) of
+// the runtime output of modelID in spawn mode.
+func (m *Manager) InstanceLogs(modelID string, tail int) ([]types.LogLine, error) {
+	l, err := m.instanceLog(modelID)
+	if err != nil {
+		return nil, err
+	}
+	return l.tail(tail), nil
+}
+
+// FollowInstanceLogs is like InstanceLogs and also subscribes to the lines
+// written after them. Callers must Close the subscription.
+func (m *Manager) FollowInstanceLogs(modelID string, tail int) ([]types.LogLine, *LogSubscription, error) {
+	l, err := m.instanceLog(modelID)
+	if err != nil {
+		return nil, nil, err
+	}
+	lines, sub := l.follow(tail)
+	return lines, sub, nil
+}
+
+func (m *Manager) instanceLog(modelID string) (*runtimeLog, error) {
+	sa, ok := m.adapter.(*llamaSubprocessAdapter)
+	if !ok {
+		return nil, ErrDependencyUnavailable("runtime logs are only kept in spawn mode")
+	}
+	modelID = m.canonicalID(modelID)
+	m.mu.RLock()
+	inst := m.instances[modelID]
+	m.mu.RUnlock()
+	var path string
+	if inst != nil && inst.path != "" {
+		path = inst.path
+	} else if mdl, ok := m.getModelByID(modelID); ok {
+		path = mdl.Path
+	} else {
+		return nil, ErrModelNotFound(modelID)
+	}
+	return sa.logFor(path, modelID), nil
+}
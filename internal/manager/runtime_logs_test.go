@@ -0,0 +1,97 @@
+package manager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"modeld/pkg/types"
+)
+
+func TestRuntimeLog_RingTailAndFollow(t *testing.T) {
+	l := newRuntimeLog("m", 3, nil, nil)
+	w := l.writer("stderr", 42)
+	_, _ = w.Write([]byte("one\ntwo\r\nthr"))
+	_, _ = w.Write([]byte("ee\nfour\npartial"))
+
+	got := l.tail(0)
+	if len(got) != 3 || got[0].Line != "two" || got[2].Line != "four" {
+		t.Fatalf("ring kept %+v", got)
+	}
+	if got := l.tail(1); len(got) != 1 || got[0].Line != "four" || got[0].PID != 42 || got[0].Stream != "stderr" {
+		t.Fatalf("tail(1) = %+v", got)
+	}
+
+	backlog, sub := l.follow(2)
+	defer sub.Close()
+	if len(backlog) != 2 || backlog[0].Line != "three" {
+		t.Fatalf("backlog = %+v", backlog)
+	}
+	w.flush()
+	select {
+	case line := <-sub.C():
+		if line.Line != "partial" {
+			t.Fatalf("followed %+v", line)
+		}
+	default:
+		t.Fatalf("no line delivered to follower")
+	}
+	if tail := l.stderrTail(42, 8); tail != "partial\n" {
+		t.Fatalf("stderrTail = %q", tail)
+	}
+
+	// A follower that does not read drops lines instead of blocking writers.
+	for i := 0; i < runtimeLogSubBuffer+5; i++ {
+		l.append(types.LogLine{Line: "x"})
+	}
+	if sub.Dropped() != 5 {
+		t.Fatalf("dropped = %d", sub.Dropped())
+	}
+	sub.Close()
+	sub.Close()
+	if len(l.subs) != 0 {
+		t.Fatalf("subscription not removed")
+	}
+}
+
+func TestLogLineWriter_SplitsLongLines(t *testing.T) {
+	l := newRuntimeLog("m", 10, nil, nil)
+	w := l.writer("stdout", 1)
+	_, _ = w.Write([]byte(strings.Repeat("a", maxRuntimeLogLine+10) + "\n"))
+	got := l.tail(0)
+	if len(got) != 2 || len(got[0].Line) != maxRuntimeLogLine || len(got[1].Line) != 10 {
+		t.Fatalf("got %d lines", len(got))
+	}
+}
+
+func TestRotatingFile_Rotates(t *testing.T) {
+	dir := t.TempDir()
+	path := runtimeLogFile(filepath.Join(dir, "logs"), "vendor/m:q4")
+	if filepath.Base(path) != "vendor_m_q4.log" {
+		t.Fatalf("file name = %s", path)
+	}
+	r := newRotatingFile(path, 20, 2)
+	for i := 0; i < 5; i++ {
+		if err := r.writeLine(fmt.Sprintf("line-%d-xxxxx", i)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	for name, want := range map[string]string{path: "line-4-xxxxx\n", path + ".1": "line-3-xxxxx\n", path + ".2": "line-2-xxxxx\n"} {
+		b, err := os.ReadFile(name)
+		if err != nil || string(b) != want {
+			t.Fatalf("%s = %q (%v), want %q", filepath.Base(name), b, err, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("kept more backups than configured")
+	}
+}
+
+func TestInstanceLogs_RequiresSpawnMode(t *testing.T) {
+	m := New([]types.Model{{ID: "m", Path: "m.gguf"}}, 0, 0, "")
+	if _, err := m.InstanceLogs("m", 0); !IsDependencyUnavailable(err) {
+		t.Fatalf("expected dependency unavailable, got %v", err)
+	}
+}
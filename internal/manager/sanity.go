@@ -3,10 +3,14 @@ package manager
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strings"
 	"time"
+
+	"modeld/internal/registry"
 )
 
 // SanityReport describes runtime checks for external dependencies.
@@ -53,6 +57,8 @@ type Check struct {
 // - Adapter presence
 // - Default model configured
 // - For in-process mode: default model path exists and is a file
+// - Split models have all their shards
+// - Duplicate model files found by the registry scan (reported, not fatal)
 // Note: it does not attempt to load the model (avoids heavy I/O at startup).
 func (m *Manager) Preflight() []Check {
 	var checks []Check
@@ -139,6 +145,36 @@ func (m *Manager) Preflight() []Check {
 			}
 		}
 	}
+	// Split models load from the first shard; llama.cpp fails on missing ones.
+	var incomplete []string
+	split := false
+	for _, mdl := range m.ListModels() {
+		if mdl.ShardCount == 0 {
+			continue
+		}
+		split = true
+		if missing := registry.MissingShards(mdl); len(missing) > 0 {
+			incomplete = append(incomplete, fmt.Sprintf("%s (%d of %d shards, missing %s)", mdl.ID, len(mdl.Shards), mdl.ShardCount, strings.Join(missing, ", ")))
+		}
+	}
+	if len(incomplete) > 0 {
+		checks = append(checks, Check{Name: "model_shards_complete", OK: false, Message: "incomplete split models: " + strings.Join(incomplete, "; ")})
+	} else if split {
+		checks = append(checks, Check{Name: "model_shards_complete", OK: true})
+	}
+	// Duplicates were resolved by the scanner; list them so operators can
+	// clean up overlapping directories or rename files.
+	if m.registryDuplicates != nil {
+		if dups := m.registryDuplicates(); len(dups) > 0 {
+			msgs := make([]string, len(dups))
+			for i, d := range dups {
+				msgs[i] = d.String()
+			}
+			checks = append(checks, Check{Name: "registry_duplicates", OK: true, Message: fmt.Sprintf("%d duplicate model files: %s", len(dups), strings.Join(msgs, "; "))})
+		} else {
+			checks = append(checks, Check{Name: "registry_duplicates", OK: true})
+		}
+	}
 	return checks
 }
 
@@ -0,0 +1,285 @@
+package manager
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Priority is the scheduling class of a request. Higher values are served
+// first; within a class, requests are ordered by weighted fair queuing across
+// tenants.
+type Priority int
+
+const (
+	PriorityBatch Priority = iota
+	PriorityNormal
+	PriorityInteractive
+
+	numPriorities = int(PriorityInteractive) + 1
+)
+
+// defaultPriorityAging is how long a request waits before it is promoted one
+// priority class (starvation protection) when not configured.
+const defaultPriorityAging = 5 * time.Second
+
+// String returns the canonical name of p.
+func (p Priority) String() string {
+	switch p {
+	case PriorityBatch:
+		return "batch"
+	case PriorityInteractive:
+		return "interactive"
+	}
+	return "normal"
+}
+
+// ParsePriority parses a priority name. Empty means normal; "low" and "high"
+// are accepted as aliases for batch and interactive.
+func ParsePriority(s string) (Priority, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "normal", "default":
+		return PriorityNormal, nil
+	case "batch", "low":
+		return PriorityBatch, nil
+	case "interactive", "high":
+		return PriorityInteractive, nil
+	}
+	return PriorityNormal, ErrInvalidRequest("unknown priority: " + s)
+}
+
+// schedConfig holds the scheduling knobs shared by all instances.
+type schedConfig struct {
+	// maxDepth caps the requests of each priority class that are waiting or
+	// in flight.
+	maxDepth [numPriorities]int
+	// aging promotes a waiting request one class per interval (0 disables).
+	aging time.Duration
+	// weights are per-tenant WFQ weights (default 1).
+	weights map[string]float64
+}
+
+func (c schedConfig) weight(tenant string) float64 {
+	if w := c.weights[tenant]; w > 0 {
+		return w
+	}
+	return 1
+}
+
+// waiter is a request queued for a generation slot.
+type waiter struct {
+	prio    Priority
+	tenant  string
+	start   float64 // WFQ virtual start tag
+	seq     uint64
+	enq     time.Time
+	ready   chan struct{}
+	granted bool
+	err     error // set instead of granted when the queue is failed
+}
+
+// scheduler admits requests to an instance's generation slots. Up to slots
+// requests run concurrently; the rest wait in per-priority bounded queues and
+// are dispatched by (aged) priority class, then by start-time fair queuing
+// across tenants, then in arrival order.
+type scheduler struct {
+	mu       sync.Mutex
+	cfg      schedConfig
+	slots    int
+	inflight int
+	// inflightBy counts running requests per base priority class.
+	inflightBy [numPriorities]int
+	waiting    []*waiter
+	seq        uint64
+	// WFQ state: virtual time and each tenant's last finish tag. Reset when
+	// the queue drains so idle tenants do not accumulate credit or debt.
+	vtime      float64
+	lastFinish map[string]float64
+}
+
+func newScheduler(slots int, cfg schedConfig) *scheduler {
+	if slots <= 0 {
+		slots = 1
+	}
+	return &scheduler{cfg: cfg, slots: slots, lastFinish: make(map[string]float64)}
+}
+
+// acquire waits for a generation slot. It fails with tooBusyError when the
+// request's priority class is at its depth (waiting plus in-flight requests)
+// or maxWait elapses, and with ctx.Err() on cancellation.
+func (s *scheduler) acquire(ctx context.Context, modelID string, prio Priority, tenant string, maxWait time.Duration) (func(), error) {
+	s.mu.Lock()
+	if s.inflight < s.slots && len(s.waiting) == 0 {
+		s.inflight++
+		s.inflightBy[prio]++
+		s.mu.Unlock()
+		return s.releaseFunc(prio), nil
+	}
+	if s.inflightBy[prio]+s.queuedLocked(prio) >= s.cfg.maxDepth[prio] {
+		s.mu.Unlock()
+		return nil, tooBusyError{modelID: modelID}
+	}
+	start := s.vtime
+	if f := s.lastFinish[tenant]; f > start {
+		start = f
+	}
+	s.lastFinish[tenant] = start + 1/s.cfg.weight(tenant)
+	s.seq++
+	w := &waiter{prio: prio, tenant: tenant, start: start, seq: s.seq, enq: time.Now(), ready: make(chan struct{})}
+	s.waiting = append(s.waiting, w)
+	s.mu.Unlock()
+
+	managerQueued.WithLabelValues(modelID).Inc()
+	defer managerQueued.WithLabelValues(modelID).Dec()
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+	var err error
+	select {
+	case <-w.ready:
+		if w.err != nil {
+			return nil, w.err
+		}
+		return s.releaseFunc(prio), nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timer.C:
+		err = tooBusyError{modelID: modelID}
+	}
+	s.mu.Lock()
+	if w.granted {
+		// Lost the race with dispatch: hand the slot back.
+		s.mu.Unlock()
+		s.release(prio)
+		return nil, err
+	}
+	s.removeLocked(w)
+	s.mu.Unlock()
+	return nil, err
+}
+
+func (s *scheduler) releaseFunc(prio Priority) func() {
+	var once sync.Once
+	return func() { once.Do(func() { s.release(prio) }) }
+}
+
+// release frees a slot held by a request of class prio and dispatches
+// waiters into free slots.
+func (s *scheduler) release(prio Priority) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inflight--
+	s.inflightBy[prio]--
+	now := time.Now()
+	for s.inflight < s.slots && len(s.waiting) > 0 {
+		w := s.pickLocked(now)
+		s.removeLocked(w)
+		s.vtime = w.start
+		s.inflight++
+		s.inflightBy[w.prio]++
+		w.granted = true
+		close(w.ready)
+	}
+	if len(s.waiting) == 0 {
+		s.vtime = 0
+		clear(s.lastFinish)
+	}
+}
+
+// failWaiting rejects every waiting request with err, e.g. when the
+// instance's runtime has crashed and would only let them time out.
+func (s *scheduler) failWaiting(err error) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.waiting)
+	for _, w := range s.waiting {
+		w.err = err
+		close(w.ready)
+	}
+	s.waiting = nil
+	s.vtime = 0
+	clear(s.lastFinish)
+	return n
+}
+
+// effectivePriority applies aging: one class per aging interval waited.
+func (s *scheduler) effectivePriority(w *waiter, now time.Time) Priority {
+	p := w.prio
+	if s.cfg.aging > 0 {
+		p += Priority(now.Sub(w.enq) / s.cfg.aging)
+	}
+	if p > PriorityInteractive {
+		p = PriorityInteractive
+	}
+	return p
+}
+
+// pickLocked returns the next waiter to dispatch. Caller holds s.mu and
+// guarantees waiting is non-empty.
+func (s *scheduler) pickLocked(now time.Time) *waiter {
+	best := s.waiting[0]
+	bestPrio := s.effectivePriority(best, now)
+	for _, w := range s.waiting[1:] {
+		p := s.effectivePriority(w, now)
+		switch {
+		case p > bestPrio:
+		case p == bestPrio && w.start < best.start:
+		case p == bestPrio && w.start == best.start && w.seq < best.seq:
+		default:
+			continue
+		}
+		best, bestPrio = w, p
+	}
+	return best
+}
+
+func (s *scheduler) removeLocked(w *waiter) {
+	for i, x := range s.waiting {
+		if x == w {
+			s.waiting = append(s.waiting[:i], s.waiting[i+1:]...)
+			return
+		}
+	}
+}
+
+// queuedLocked counts waiters of base priority p.
+func (s *scheduler) queuedLocked(p Priority) int {
+	n := 0
+	for _, w := range s.waiting {
+		if w.prio == p {
+			n++
+		}
+	}
+	return n
+}
+
+// schedStats is a point-in-time view of a scheduler for status reporting.
+type schedStats struct {
+	slots    int
+	inflight int
+	queued   int
+	maxDepth int
+	byPrio   [numPriorities]int
+}
+
+func (s *scheduler) stats() schedStats {
+	if s == nil {
+		return schedStats{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := schedStats{slots: s.slots, inflight: s.inflight, queued: len(s.waiting)}
+	for _, d := range s.cfg.maxDepth {
+		st.maxDepth += d
+	}
+	for _, w := range s.waiting {
+		st.byPrio[w.prio]++
+	}
+	return st
+}
+
+// idle reports whether no requests are running or waiting.
+func (s *scheduler) idle() bool {
+	st := s.stats()
+	return st.inflight == 0 && st.queued == 0
+}
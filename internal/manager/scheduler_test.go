@@ -0,0 +1,197 @@
+package manager
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testSchedConfig(depth int) schedConfig {
+	c := schedConfig{}
+	for p := range c.maxDepth {
+		c.maxDepth[p] = depth
+	}
+	return c
+}
+
+// queueWaiters enqueues one acquire per entry on a saturated scheduler and
+// returns a channel receiving each entry's label in grant order. Waiters are
+// enqueued one at a time so arrival order is deterministic.
+func queueWaiters(t *testing.T, s *scheduler, prios []Priority, tenants []string) <-chan string {
+	t.Helper()
+	order := make(chan string, len(prios))
+	base := s.stats().queued
+	var wg sync.WaitGroup
+	for i := range prios {
+		wg.Add(1)
+		go func(p Priority, tenant string) {
+			defer wg.Done()
+			rel, err := s.acquire(context.Background(), "m", p, tenant, 2*time.Second)
+			if err != nil {
+				t.Errorf("acquire: %v", err)
+				return
+			}
+			order <- p.String() + "/" + tenant
+			rel()
+		}(prios[i], tenants[i])
+		want := base + i + 1
+		for s.stats().queued != want {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	t.Cleanup(wg.Wait)
+	return order
+}
+
+func TestScheduler_PriorityOrder(t *testing.T) {
+	s := newScheduler(1, testSchedConfig(4))
+	rel, err := s.acquire(context.Background(), "m", PriorityNormal, "", time.Second)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	order := queueWaiters(t, s,
+		[]Priority{PriorityBatch, PriorityNormal, PriorityInteractive},
+		[]string{"t", "t", "t"})
+	rel()
+	for _, want := range []string{"interactive/t", "normal/t", "batch/t"} {
+		if got := <-order; got != want {
+			t.Fatalf("got %s, want %s", got, want)
+		}
+	}
+}
+
+func TestScheduler_FairAcrossTenants(t *testing.T) {
+	cfg := testSchedConfig(8)
+	cfg.weights = map[string]float64{"b": 2}
+	s := newScheduler(1, cfg)
+	rel, err := s.acquire(context.Background(), "m", PriorityNormal, "", time.Second)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	// Tenant a floods the queue before b arrives; b (weight 2) still gets
+	// two turns for each of a's.
+	order := queueWaiters(t, s,
+		[]Priority{PriorityNormal, PriorityNormal, PriorityNormal, PriorityNormal, PriorityNormal, PriorityNormal},
+		[]string{"a", "a", "a", "b", "b", "b"})
+	rel()
+	var got []string
+	for i := 0; i < 6; i++ {
+		got = append(got, <-order)
+	}
+	want := []string{"normal/a", "normal/b", "normal/b", "normal/a", "normal/b", "normal/a"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestScheduler_PerPriorityDepth(t *testing.T) {
+	cfg := testSchedConfig(1)
+	s := newScheduler(1, cfg)
+	rel, err := s.acquire(context.Background(), "m", PriorityNormal, "", time.Second)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	_ = queueWaiters(t, s, []Priority{PriorityBatch}, []string{"t"})
+	// The batch queue is full but interactive still has room.
+	if _, err := s.acquire(context.Background(), "m", PriorityBatch, "t", time.Second); !IsTooBusy(err) {
+		t.Fatalf("expected too busy for full batch queue, got %v", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		r, err := s.acquire(context.Background(), "m", PriorityInteractive, "t", time.Second)
+		if err == nil {
+			r()
+		}
+		done <- err
+	}()
+	for s.stats().queued != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	if st := s.stats(); st.byPrio[PriorityInteractive] != 1 || st.byPrio[PriorityBatch] != 1 || st.maxDepth != 3 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+	rel()
+	if err := <-done; err != nil {
+		t.Fatalf("interactive acquire: %v", err)
+	}
+}
+
+func TestScheduler_DepthCountsInflight(t *testing.T) {
+	s := newScheduler(1, testSchedConfig(1))
+	rel, err := s.acquire(context.Background(), "m", PriorityNormal, "", time.Second)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	// The running request uses the class's only place.
+	if _, err := s.acquire(context.Background(), "m", PriorityNormal, "", time.Second); !IsTooBusy(err) {
+		t.Fatalf("expected too busy with depth 1 and one in flight, got %v", err)
+	}
+	rel()
+	rel, err = s.acquire(context.Background(), "m", PriorityNormal, "", time.Second)
+	if err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+	rel()
+}
+
+func TestScheduler_AgingPreventsStarvation(t *testing.T) {
+	cfg := testSchedConfig(4)
+	cfg.aging = 20 * time.Millisecond
+	s := newScheduler(1, cfg)
+	rel, err := s.acquire(context.Background(), "m", PriorityNormal, "", time.Second)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	order := queueWaiters(t, s, []Priority{PriorityBatch}, []string{"old"})
+	// After two aging intervals the batch request ranks as interactive and,
+	// having an earlier start tag, goes before a fresh interactive request.
+	time.Sleep(50 * time.Millisecond)
+	s.mu.Lock()
+	w := s.waiting[0]
+	s.mu.Unlock()
+	if p := s.effectivePriority(w, time.Now()); p != PriorityInteractive {
+		t.Fatalf("expected aged priority interactive, got %s", p)
+	}
+	_ = queueWaiters(t, s, []Priority{PriorityInteractive}, []string{"new"})
+	rel()
+	if got := <-order; got != "batch/old" {
+		t.Fatalf("expected aged batch request first, got %s", got)
+	}
+}
+
+func TestScheduler_CancelRemovesWaiter(t *testing.T) {
+	s := newScheduler(1, testSchedConfig(2))
+	rel, err := s.acquire(context.Background(), "m", PriorityNormal, "", time.Second)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	if _, err := s.acquire(ctx, "m", PriorityNormal, "", time.Second); err != context.Canceled {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+	if st := s.stats(); st.queued != 0 || st.inflight != 1 {
+		t.Fatalf("unexpected stats after cancel: %+v", st)
+	}
+	rel()
+	if !s.idle() {
+		t.Fatalf("expected idle scheduler")
+	}
+}
+
+func TestParsePriority(t *testing.T) {
+	for in, want := range map[string]Priority{"": PriorityNormal, "HIGH": PriorityInteractive, "batch": PriorityBatch, " interactive ": PriorityInteractive} {
+		if got, err := ParsePriority(in); err != nil || got != want {
+			t.Fatalf("ParsePriority(%q) = %v, %v", in, got, err)
+		}
+	}
+	if _, err := ParsePriority("urgent"); !IsInvalidRequest(err) {
+		t.Fatalf("expected invalid request, got %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package manager
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"modeld/pkg/types"
+)
+
+func TestSlots_ParallelInflightPerInstance(t *testing.T) {
+	m := NewWithConfig(ManagerConfig{
+		Registry:      []types.Model{{ID: "a", Path: "a.gguf"}, {ID: "b", Path: "b.gguf"}},
+		Slots:         2,
+		ModelSlots:    map[string]int{"b": 3},
+		MaxQueueDepth: 1,
+		MaxWait:       30 * time.Millisecond,
+	})
+	for _, id := range []string{"a", "b"} {
+		if err := m.EnsureInstance(testCtx(t), id); err != nil {
+			t.Fatalf("ensure %s: %v", id, err)
+		}
+	}
+	// Two requests generate concurrently on a; a third waits in the queue
+	// and times out since neither releases.
+	var releases []func()
+	for i := 0; i < 2; i++ {
+		rel, err := m.beginGeneration(context.Background(), "a")
+		if err != nil {
+			t.Fatalf("slot %d: %v", i, err)
+		}
+		releases = append(releases, rel)
+	}
+	if _, err := m.beginGeneration(context.Background(), "a"); !IsTooBusy(err) {
+		t.Fatalf("expected too busy with all slots taken, got %v", err)
+	}
+
+	statuses := map[string]types.InstanceStatus{}
+	for _, st := range m.Status().Instances {
+		statuses[st.ModelID] = st
+	}
+	if st := statuses["a"]; st.Slots != 2 || st.Inflight != 2 || st.MaxQueueDepth != 3 {
+		t.Fatalf("unexpected status for a: %+v", st)
+	}
+	if st := statuses["b"]; st.Slots != 3 || st.Inflight != 0 {
+		t.Fatalf("unexpected status for b: %+v", st)
+	}
+	for _, rel := range releases {
+		rel()
+	}
+	if _, err := m.beginGeneration(context.Background(), "a"); err != nil {
+		t.Fatalf("expected slot after release, got %v", err)
+	}
+}
+
+func TestSpawnArgs_Parallel(t *testing.T) {
+	sa := NewLlamaSubprocessAdapter(ManagerConfig{LlamaBin: "llama-server", SpawnLlama: true}).(*llamaSubprocessAdapter)
+	if args := strings.Join(sa.spawnArgs("m.gguf", "127.0.0.1", 30000), " "); strings.Contains(args, "--parallel") {
+		t.Fatalf("single slot should not pass --parallel: %s", args)
+	}
+	sa.setParallel("m.gguf", 4)
+	if args := strings.Join(sa.spawnArgs("m.gguf", "127.0.0.1", 30000), " "); !strings.Contains(args, "--parallel 4") {
+		t.Fatalf("expected --parallel 4: %s", args)
+	}
+	// An explicit -np in extra args wins.
+	sa = NewLlamaSubprocessAdapter(ManagerConfig{LlamaExtraArgs: []string{"-np", "8"}}).(*llamaSubprocessAdapter)
+	sa.setParallel("m.gguf", 4)
+	if args := strings.Join(sa.spawnArgs("m.gguf", "127.0.0.1", 30000), " "); strings.Contains(args, "--parallel") || !strings.Contains(args, "-np 8") {
+		t.Fatalf("expected extra args to take precedence: %s", args)
+	}
+}
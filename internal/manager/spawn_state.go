@@ -0,0 +1,339 @@
+package manager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"modeld/internal/common/fsutil"
+	"modeld/pkg/types"
+)
+
+// adoptedPollInterval is how often an adopted runtime, which modeld cannot
+// wait on, is checked for having exited.
+const adoptedPollInterval = 500 * time.Millisecond
+
+// spawnState is the runtime state file: the ready llama-server processes
+// and the command lines they were started with.
+type spawnState struct {
+	Processes []spawnStateRecord `json:"processes"`
+}
+
+type spawnStateRecord struct {
+	ModelPath     string   `json:"model_path"`
+	PID           int      `json:"pid"`
+	Host          string   `json:"host"`
+	Port          int      `json:"port"`
+	Bin           string   `json:"bin"`
+	Args          []string `json:"args"`
+	Env           []string `json:"env,omitempty"`
+	StartedUnixMs int64    `json:"started_unix_ms"`
+}
+
+// statePath returns the state file path, or "" when it is not configured.
+func (a *llamaSubprocessAdapter) statePath() string {
+	p := strings.TrimSpace(a.cfg.SpawnStateFile)
+	if p == "" {
+		return ""
+	}
+	if exp, err := fsutil.ExpandHome(p); err == nil {
+		p = exp
+	}
+	return p
+}
+
+// saveState rewrites the state file with the ready processes. Callers must
+// not hold a.mu.
+func (a *llamaSubprocessAdapter) saveState() {
+	path := a.statePath()
+	if path == "" {
+		return
+	}
+	a.stateMu.Lock()
+	defer a.stateMu.Unlock()
+	st := spawnState{Processes: []spawnStateRecord{}}
+	a.mu.Lock()
+	for modelPath, p := range a.procs {
+		// Stopping processes are on their way out, unless Close detached them.
+		if !p.ready || (p.stopping && !a.detached) {
+			continue
+		}
+		st.Processes = append(st.Processes, spawnStateRecord{
+			ModelPath:     modelPath,
+			PID:           p.pid,
+			Host:          p.host,
+			Port:          p.port,
+			Bin:           a.cfg.LlamaBin,
+			Args:          p.args,
+			Env:           p.env,
+			StartedUnixMs: p.started.UnixMilli(),
+		})
+	}
+	a.mu.Unlock()
+	sort.Slice(st.Processes, func(i, j int) bool { return st.Processes[i].ModelPath < st.Processes[j].ModelPath })
+	if err := writeSpawnState(path, st); err != nil {
+		log.Printf("adapter=llama_subprocess event=state_write_error path=%q err=%v", path, err)
+	}
+}
+
+// writeSpawnState replaces the file at path atomically.
+func writeSpawnState(path string, st spawnState) error {
+	b, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadState reads the state file; a missing file holds no processes.
+func (a *llamaSubprocessAdapter) loadState() ([]spawnStateRecord, error) {
+	b, err := os.ReadFile(a.statePath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var st spawnState
+	if err := json.Unmarshal(b, &st); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", a.statePath(), err)
+	}
+	return st.Processes, nil
+}
+
+// detachAll leaves the ready processes running for the next modeld and
+// records them in the state file. Pending restarts are canceled and the
+// supervisors stop treating exits as crashes. Processes still loading would
+// not be recorded, so they are stopped rather than orphaned.
+func (a *llamaSubprocessAdapter) detachAll() {
+	a.mu.Lock()
+	a.detached = true
+	var loading []string
+	for path, p := range a.procs {
+		if !p.ready {
+			loading = append(loading, path)
+			continue
+		}
+		p.stopping = true
+	}
+	for path, cancel := range a.restarts {
+		close(cancel)
+		delete(a.restarts, path)
+	}
+	a.mu.Unlock()
+	for _, path := range loading {
+		_ = a.Stop(path)
+	}
+	a.saveState()
+}
+
+// recordedProcess reports whether the process named by rec is running and
+// whether it is verifiably the recorded llama-server: its command line
+// matches, where the OS exposes it. An unverified PID may have been reused,
+// so it is neither adopted nor stopped.
+func recordedProcess(rec spawnStateRecord) (alive, verified bool) {
+	if rec.PID <= 0 || !processAlive(rec.PID) {
+		return false, false
+	}
+	cmdline, ok := processCmdline(rec.PID)
+	if !ok {
+		return true, false
+	}
+	if !slices.Equal(cmdline, append([]string{rec.Bin}, rec.Args...)) {
+		// Another program now has this PID.
+		return false, false
+	}
+	return true, true
+}
+
+// adopt takes over the ready process described by rec after checking that it
+// answers on its port. Adopted processes are supervised like spawned ones,
+// but their output went to the previous modeld and is not captured.
+func (a *llamaSubprocessAdapter) adopt(rec spawnStateRecord) error {
+	a.mu.Lock()
+	busy := a.procs[rec.ModelPath] != nil
+	a.mu.Unlock()
+	if busy {
+		return errors.New("a runtime is already running for this model")
+	}
+	baseURL := fmt.Sprintf("http://%s:%d", rec.Host, rec.Port)
+	if !a.isHealthy(baseURL, 2*time.Second) {
+		return errors.New("not healthy")
+	}
+	process, err := os.FindProcess(rec.PID)
+	if err != nil {
+		return err
+	}
+	p := &procInfo{
+		process: process, baseURL: baseURL, ready: true, pid: rec.PID, started: time.UnixMilli(rec.StartedUnixMs),
+		host: rec.Host, port: rec.Port, args: rec.Args, env: rec.Env, adopted: true, done: make(chan struct{}),
+	}
+	a.mu.Lock()
+	if a.procs == nil {
+		a.procs = make(map[string]*procInfo)
+	}
+	a.procs[rec.ModelPath] = p
+	a.mu.Unlock()
+	go func() {
+		// Also stop watching when the PID is reused by another program, so
+		// it is never signalled as ours.
+		for {
+			if alive, _ := recordedProcess(rec); !alive {
+				break
+			}
+			time.Sleep(adoptedPollInterval)
+		}
+		p.waitErr = errors.New("adopted process exited")
+		close(p.done)
+	}()
+	go a.supervise(rec.ModelPath, p)
+	log.Printf("adapter=llama_subprocess event=adopt model=%q pid=%d url=%s", rec.ModelPath, rec.PID, baseURL)
+	a.publisher.Publish(Event{Name: "spawn_adopt", ModelID: rec.ModelPath, Fields: map[string]any{"pid": rec.PID, "url": baseURL}})
+	return nil
+}
+
+// terminate stops a recorded process that is not adopted, so it does not
+// hold on to VRAM and its port.
+func (a *llamaSubprocessAdapter) terminate(rec spawnStateRecord) {
+	process, err := os.FindProcess(rec.PID)
+	if err != nil {
+		return
+	}
+	_ = process.Signal(syscall.SIGTERM)
+	deadline := time.Now().Add(2 * time.Second)
+	for processAlive(rec.PID) {
+		if time.Now().After(deadline) {
+			_ = process.Kill()
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	a.publisher.Publish(Event{Name: "spawn_stop", ModelID: rec.ModelPath, Fields: map[string]any{"pid": rec.PID}})
+}
+
+// AdoptRuntimes takes over the llama-server processes a previous modeld left
+// running (spawn mode with SpawnStateFile). A process is adopted when its
+// model is still in the registry, it would be started with the same command
+// line today and it answers on its port; its instance is restored as ready
+// and counted against the VRAM budget. Other recorded processes that are
+// verifiably ours are stopped. Processes whose command line cannot be read
+// (no /proc) are left alone: their PID may belong to something else now. It
+// returns the IDs of the adopted models and should be called once, before
+// serving requests.
+func (m *Manager) AdoptRuntimes() []string {
+	sa, ok := m.adapter.(*llamaSubprocessAdapter)
+	if !ok || sa.statePath() == "" {
+		return nil
+	}
+	recs, err := sa.loadState()
+	if err != nil {
+		log.Printf("manager event=adopt_error err=%v", err)
+		return nil
+	}
+	var adopted []string
+	for _, rec := range recs {
+		alive, verified := recordedProcess(rec)
+		if !alive {
+			log.Printf("manager event=adopt_skip path=%q pid=%d reason=%q", rec.ModelPath, rec.PID, "process is gone")
+			continue
+		}
+		if !verified {
+			log.Printf("manager event=adopt_skip path=%q pid=%d reason=%q", rec.ModelPath, rec.PID, "command line cannot be verified")
+			continue
+		}
+		id, err := m.adoptRuntime(sa, rec)
+		if err != nil {
+			log.Printf("manager event=adopt_skip path=%q pid=%d reason=%q stopped=true", rec.ModelPath, rec.PID, err)
+			sa.terminate(rec)
+			continue
+		}
+		adopted = append(adopted, id)
+	}
+	sa.saveState()
+	return adopted
+}
+
+// adoptRuntime checks rec against the current configuration and, if it
+// matches, adopts the process and restores its instance.
+func (m *Manager) adoptRuntime(sa *llamaSubprocessAdapter, rec spawnStateRecord) (string, error) {
+	var mdl types.Model
+	found := false
+	m.regMu.RLock()
+	for _, r := range m.registry {
+		if r.Path == rec.ModelPath {
+			mdl, found = r, true
+			break
+		}
+	}
+	m.regMu.RUnlock()
+	if !found {
+		return "", errors.New("model is no longer in the registry")
+	}
+	m.mu.RLock()
+	_, loaded := m.instances[mdl.ID]
+	m.mu.RUnlock()
+	if loaded {
+		return "", errors.New("model is already loaded")
+	}
+	host := strings.TrimSpace(sa.cfg.LlamaHost)
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	slots := m.slotsFor(mdl.ID)
+	prof := m.runtimeProfile(mdl)
+	sa.setParallel(mdl.Path, slots)
+	sa.setProfile(mdl.Path, prof)
+	if rec.Bin != sa.cfg.LlamaBin || rec.Host != host ||
+		!slices.Equal(rec.Args, sa.spawnArgs(mdl.Path, host, rec.Port)) || !slices.Equal(rec.Env, profileEnv(prof)) {
+		return "", errors.New("command line differs from the current configuration")
+	}
+	sa.logFor(mdl.Path, mdl.ID)
+	if err := sa.adopt(rec); err != nil {
+		return "", err
+	}
+
+	vram := m.estimateVRAM(mdl, slots)
+	m.mu.Lock()
+	lastUsed := time.Now()
+	if rec, ok := m.lruMeta[mdl.ID]; ok {
+		// Keep the eviction order the previous modeld had.
+		lastUsed = time.Unix(rec.LastUsedUnix, 0)
+	}
+	m.instances[mdl.ID] = &Instance{
+		ID:        mdl.ID,
+		State:     StateReady,
+		LastUsed:  lastUsed,
+		EstVRAMMB: vram.TotalMB,
+		VRAM:      vram,
+		path:      mdl.Path,
+		sched:     newScheduler(slots, m.schedCfg),
+		Port:      rec.Port,
+		PID:       rec.PID,
+		profile:   &prof,
+		adopted:   true,
+	}
+	m.usedEstMB += vram.TotalMB
+	m.cur = &ModelInfo{ID: mdl.ID}
+	m.state = StateReady
+	m.err = ""
+	managerVRAMUsedMB.Set(float64(m.usedEstMB))
+	m.mu.Unlock()
+	log.Printf("manager event=instance_adopted model=%q pid=%d port=%d", mdl.ID, rec.PID, rec.Port)
+	m.publisher.Publish(Event{Name: "instance_adopted", ModelID: mdl.ID, Fields: map[string]any{"pid": rec.PID, "port": rec.Port}})
+	return mdl.ID, nil
+}
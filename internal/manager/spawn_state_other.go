@@ -0,0 +1,28 @@
+//go:build !unix
+
+package manager
+
+import (
+	"os"
+	"os/exec"
+)
+
+// detachProcess is a no-op: runtimes are only detached on Unix.
+func detachProcess(cmd *exec.Cmd) {}
+
+// ignoreSIGPIPE is a no-op without SIGPIPE.
+func ignoreSIGPIPE() {}
+
+// processAlive reports whether a process with pid exists.
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	_ = p.Release()
+	return true
+}
+
+// processCmdline is not available here; recorded processes are never
+// verified, so they are neither adopted nor stopped.
+func processCmdline(pid int) ([]string, bool) { return nil, false }
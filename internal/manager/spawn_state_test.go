@@ -0,0 +1,105 @@
+package manager
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"modeld/pkg/types"
+)
+
+func TestSpawnState_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "runtimes.json")
+	sa := NewLlamaSubprocessAdapter(ManagerConfig{SpawnLlama: true, LlamaBin: "llama-server", SpawnStateFile: path}).(*llamaSubprocessAdapter)
+	started := time.UnixMilli(1700000000000)
+	sa.procs["/m/b.gguf"] = &procInfo{ready: true, pid: 11, host: "127.0.0.1", port: 30001, args: []string{"-m", "/m/b.gguf"}, started: started}
+	sa.procs["/m/a.gguf"] = &procInfo{ready: true, pid: 12, host: "127.0.0.1", port: 30002, env: []string{"K=V"}, started: started}
+	sa.procs["/m/loading.gguf"] = &procInfo{pid: 13}
+	sa.procs["/m/stopping.gguf"] = &procInfo{ready: true, pid: 14, stopping: true}
+	sa.saveState()
+
+	recs, err := sa.loadState()
+	if err != nil {
+		t.Fatalf("loadState: %v", err)
+	}
+	if len(recs) != 2 || recs[0].ModelPath != "/m/a.gguf" || recs[1].ModelPath != "/m/b.gguf" {
+		t.Fatalf("records = %+v", recs)
+	}
+	if r := recs[1]; r.PID != 11 || r.Port != 30001 || r.Bin != "llama-server" || len(r.Args) != 2 || r.StartedUnixMs != started.UnixMilli() {
+		t.Fatalf("record = %+v", r)
+	}
+
+	// Detached processes are recorded even though they are marked stopping.
+	sa.detachAll()
+	if recs, _ := sa.loadState(); len(recs) != 3 {
+		t.Fatalf("after detach: %+v", recs)
+	}
+	if _, err := sa.ensureProcess("/m/new.gguf"); err == nil {
+		t.Fatalf("expected spawning to fail once detached")
+	}
+}
+
+func TestDetachAll_StopsLoadingRuntimes(t *testing.T) {
+	bin, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not available")
+	}
+	path := filepath.Join(t.TempDir(), "runtimes.json")
+	sa := NewLlamaSubprocessAdapter(ManagerConfig{SpawnLlama: true, LlamaBin: "llama-server", SpawnStateFile: path}).(*llamaSubprocessAdapter)
+	cmd := exec.Command(bin, "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	p := &procInfo{cmd: cmd, process: cmd.Process, pid: cmd.Process.Pid, done: make(chan struct{})}
+	go func() {
+		p.waitErr = cmd.Wait()
+		close(p.done)
+	}()
+	sa.procs["/m/loading.gguf"] = p
+	sa.procs["/m/ready.gguf"] = &procInfo{ready: true, pid: 12, host: "127.0.0.1", port: 30002}
+
+	sa.detachAll()
+	select {
+	case <-p.done:
+	default:
+		t.Fatalf("loading runtime left running after detach")
+	}
+	if recs, _ := sa.loadState(); len(recs) != 1 || recs[0].ModelPath != "/m/ready.gguf" {
+		t.Fatalf("records = %+v", recs)
+	}
+}
+
+func TestAdoptRuntimes_DropsStaleRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runtimes.json")
+	exited := exec.Command(os.Args[0], "-test.run=^$")
+	if err := exited.Run(); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	st := spawnState{Processes: []spawnStateRecord{
+		// Exited process.
+		{ModelPath: "/m/a.gguf", PID: exited.Process.Pid, Bin: "llama-server"},
+		// Running, but not the recorded command line (PID reused): left alone.
+		{ModelPath: "/m/b.gguf", PID: os.Getpid(), Bin: "llama-server", Args: []string{"-m", "/m/b.gguf"}},
+	}}
+	if err := writeSpawnState(path, st); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	m := NewWithConfig(ManagerConfig{
+		Registry:       []types.Model{{ID: "a", Path: "/m/a.gguf"}},
+		SpawnLlama:     true,
+		LlamaBin:       "llama-server",
+		SpawnStateFile: path,
+	})
+	if ids := m.AdoptRuntimes(); len(ids) != 0 {
+		t.Fatalf("adopted %v", ids)
+	}
+	if len(m.Status().Instances) != 0 {
+		t.Fatalf("unexpected instances: %+v", m.Status().Instances)
+	}
+	sa := m.adapter.(*llamaSubprocessAdapter)
+	if recs, err := sa.loadState(); err != nil || len(recs) != 0 {
+		t.Fatalf("state not rewritten: %+v (%v)", recs, err)
+	}
+}
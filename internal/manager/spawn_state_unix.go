@@ -0,0 +1,43 @@
+//go:build unix
+
+package manager
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+)
+
+// detachProcess starts cmd in its own process group.
+func detachProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// ignoreSIGPIPE makes runtimes survive writing to the output pipe of a
+// modeld that has exited: ignored signals stay ignored across exec, so
+// their writes fail with EPIPE instead of killing them. modeld itself then
+// gets EPIPE too when its own stdout or stderr is gone.
+func ignoreSIGPIPE() {
+	signal.Ignore(syscall.SIGPIPE)
+}
+
+// processAlive reports whether a process with pid exists.
+func processAlive(pid int) bool {
+	return syscall.Kill(pid, 0) == nil
+}
+
+// processCmdline returns the command line of pid where /proc exposes it.
+func processCmdline(pid int) ([]string, bool) {
+	b, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil || len(b) == 0 {
+		return nil, false
+	}
+	var args []string
+	for _, a := range bytes.Split(bytes.TrimSuffix(b, []byte{0}), []byte{0}) {
+		args = append(args, string(a))
+	}
+	return args, true
+}
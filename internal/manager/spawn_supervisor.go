@@ -0,0 +1,224 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Crash supervision defaults for spawned runtimes.
+const (
+	defaultRestartLimit   = 5
+	defaultRestartBackoff = time.Second
+	maxRestartBackoff     = 30 * time.Second
+	// A process that ran this long before exiting starts a new crash count.
+	restartStableAfter = time.Minute
+)
+
+// spawnHooks lets the manager follow supervised processes. crashed is
+// called when a ready process exits unexpectedly (restarting reports whether
+// a restart is scheduled) and again when restarts are given up; restarted
+// is called once a replacement process is ready.
+type spawnHooks struct {
+	crashed   func(modelPath string, err error, restarting bool)
+	restarted func(modelPath string)
+}
+
+// setSpawnHooks installs the supervision callbacks.
+func (a *llamaSubprocessAdapter) setSpawnHooks(h spawnHooks) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.hooks = h
+}
+
+// restartPolicy returns the crash-loop limit (0: never restart) and the
+// initial restart backoff.
+func (a *llamaSubprocessAdapter) restartPolicy() (int, time.Duration) {
+	limit, backoff := a.cfg.SpawnRestartLimit, a.cfg.SpawnRestartBackoff
+	if limit == 0 {
+		limit = defaultRestartLimit
+	}
+	if limit < 0 {
+		limit = 0
+	}
+	if backoff <= 0 {
+		backoff = defaultRestartBackoff
+	}
+	return limit, backoff
+}
+
+// supervise waits for a ready process to exit. An exit Stop did not ask for
+// is a crash: it is reported and, unless the crash-loop limit is reached,
+// the process is restarted.
+func (a *llamaSubprocessAdapter) supervise(modelPath string, p *procInfo) {
+	<-p.done
+	a.mu.Lock()
+	if p.stopping {
+		a.mu.Unlock()
+		return
+	}
+	if a.procs[modelPath] == p {
+		delete(a.procs, modelPath)
+	}
+	if a.crashes == nil {
+		a.crashes = make(map[string]int)
+	}
+	if time.Since(p.started) >= restartStableAfter {
+		delete(a.crashes, modelPath)
+	}
+	a.crashes[modelPath]++
+	crashes := a.crashes[modelPath]
+	limit, backoff := a.restartPolicy()
+	restarting := crashes <= limit
+	var cancel chan struct{}
+	if restarting {
+		if a.restarts == nil {
+			a.restarts = make(map[string]chan struct{})
+		}
+		cancel = make(chan struct{})
+		a.restarts[modelPath] = cancel
+	} else {
+		delete(a.crashes, modelPath)
+	}
+	hooks := a.hooks
+	a.mu.Unlock()
+	a.saveState()
+
+	err := p.waitErr
+	if err == nil {
+		err = errors.New("exited with status 0")
+	}
+	log.Printf("adapter=llama_subprocess event=crash model=%q pid=%d err=%v crashes=%d restarting=%t", modelPath, p.pid, err, crashes, restarting)
+	a.publisher.Publish(Event{Name: "spawn_crash", ModelID: modelPath, Fields: map[string]any{"pid": p.pid, "error": err.Error(), "crashes": crashes, "restarting": restarting}})
+	if hooks.crashed != nil {
+		hooks.crashed(modelPath, err, restarting)
+	}
+	if !restarting {
+		if limit > 0 {
+			a.giveUp(modelPath, crashes)
+		}
+		return
+	}
+	a.restart(modelPath, cancel, crashes, limit, backoff)
+}
+
+// restart respawns modelPath after an exponential backoff, retrying failed
+// spawns until the crash-loop limit. Stop cancels it.
+func (a *llamaSubprocessAdapter) restart(modelPath string, cancel chan struct{}, attempt, limit int, backoff time.Duration) {
+	delay := backoff
+	for i := 1; i < attempt; i++ {
+		delay = min(delay*2, maxRestartBackoff)
+	}
+	for {
+		select {
+		case <-time.After(delay):
+		case <-cancel:
+			return
+		}
+		_, err := a.ensureProcess(modelPath)
+		a.mu.Lock()
+		canceled := a.restarts[modelPath] != cancel
+		if !canceled && err != nil {
+			attempt++
+			a.crashes[modelPath] = attempt
+		}
+		done := !canceled && (err == nil || attempt > limit)
+		if done {
+			delete(a.restarts, modelPath)
+			if err != nil {
+				delete(a.crashes, modelPath)
+			}
+		}
+		hooks := a.hooks
+		a.mu.Unlock()
+
+		switch {
+		case canceled:
+			// Stopped while respawning: do not leave the new process behind.
+			if err == nil {
+				_ = a.Stop(modelPath)
+			}
+			return
+		case err == nil:
+			log.Printf("adapter=llama_subprocess event=restart model=%q attempt=%d", modelPath, attempt)
+			a.publisher.Publish(Event{Name: "spawn_restart", ModelID: modelPath, Fields: map[string]any{"attempt": attempt}})
+			if hooks.restarted != nil {
+				hooks.restarted(modelPath)
+			}
+			return
+		case done:
+			if hooks.crashed != nil {
+				hooks.crashed(modelPath, err, false)
+			}
+			a.giveUp(modelPath, attempt)
+			return
+		}
+		log.Printf("adapter=llama_subprocess event=restart_failed model=%q attempt=%d err=%v", modelPath, attempt, err)
+		delay = min(delay*2, maxRestartBackoff)
+	}
+}
+
+// giveUp reports that modelPath is crash-looping and will not be restarted.
+func (a *llamaSubprocessAdapter) giveUp(modelPath string, crashes int) {
+	log.Printf("adapter=llama_subprocess event=crash_loop model=%q crashes=%d", modelPath, crashes)
+	a.publisher.Publish(Event{Name: "spawn_crash_loop", ModelID: modelPath, Fields: map[string]any{"crashes": crashes}})
+}
+
+// instanceByPath returns the instance loaded from modelPath. Caller holds m.mu.
+func (m *Manager) instanceByPath(modelPath string) *Instance {
+	for _, inst := range m.instances {
+		if inst.path == modelPath {
+			return inst
+		}
+	}
+	return nil
+}
+
+// onSpawnCrash marks the instance whose runtime crashed as errored and fails
+// its queued requests fast; in-flight requests fail with the connection.
+func (m *Manager) onSpawnCrash(modelPath string, err error, restarting bool) {
+	m.mu.Lock()
+	inst := m.instanceByPath(modelPath)
+	if inst == nil || inst.State == StateDraining {
+		m.mu.Unlock()
+		return
+	}
+	inst.State = StateError
+	inst.err = err.Error()
+	inst.restarting = restarting
+	inst.PID, inst.Port = 0, 0
+	m.mu.Unlock()
+	managerSpawnCrashesTotal.WithLabelValues(inst.ID).Inc()
+	failed := inst.sched.failWaiting(errCrashed(inst.ID, restarting))
+	log.Printf("manager event=instance_crash model=%q err=%v restarting=%t failed_queued=%d", inst.ID, err, restarting, failed)
+}
+
+// onSpawnRestart marks the instance ready again once its runtime restarted.
+func (m *Manager) onSpawnRestart(modelPath string) {
+	var pid, port int
+	if sa, ok := m.adapter.(*llamaSubprocessAdapter); ok {
+		pid, port, _ = sa.endpoint(modelPath)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	inst := m.instanceByPath(modelPath)
+	if inst == nil || inst.State != StateError {
+		return
+	}
+	inst.State = StateReady
+	inst.err, inst.restarting = "", false
+	inst.restarts++
+	inst.PID, inst.Port = pid, port
+	managerSpawnRestartsTotal.WithLabelValues(inst.ID).Inc()
+	log.Printf("manager event=instance_restart model=%q pid=%d port=%d restarts=%d", inst.ID, inst.PID, inst.Port, inst.restarts)
+}
+
+// errCrashed is returned for requests to an instance whose runtime crashed.
+func errCrashed(modelID string, restarting bool) error {
+	msg := fmt.Sprintf("model %s runtime crashed", modelID)
+	if restarting {
+		msg += "; restarting"
+	}
+	return ErrDependencyUnavailable(msg)
+}
@@ -0,0 +1,89 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"modeld/pkg/types"
+)
+
+func TestSpawnCrash_FailsQueuedAndRecovers(t *testing.T) {
+	m := NewWithConfig(ManagerConfig{
+		Registry: []types.Model{{ID: "m", Path: "m.gguf"}},
+		MaxWait:  time.Minute,
+	})
+	if err := m.EnsureInstance(testCtx(t), "m"); err != nil {
+		t.Fatalf("ensure: %v", err)
+	}
+	rel, err := m.beginGeneration(context.Background(), "m")
+	if err != nil {
+		t.Fatalf("slot: %v", err)
+	}
+	defer rel()
+	queued := make(chan error, 1)
+	go func() {
+		_, err := m.beginGeneration(context.Background(), "m")
+		queued <- err
+	}()
+	waitQueued(t, m, "m", 1)
+
+	m.onSpawnCrash("m.gguf", errors.New("signal: killed"), true)
+	select {
+	case err := <-queued:
+		if !IsDependencyUnavailable(err) {
+			t.Fatalf("expected dependency unavailable for queued request, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("queued request was not failed fast")
+	}
+	st := m.Status().Instances[0]
+	if st.State != string(StateError) || !st.Restarting || st.Error != "signal: killed" {
+		t.Fatalf("unexpected status after crash: %+v", st)
+	}
+	if err := m.EnsureInstance(testCtx(t), "m"); !IsDependencyUnavailable(err) {
+		t.Fatalf("expected new requests to fail fast while restarting, got %v", err)
+	}
+
+	m.onSpawnRestart("m.gguf")
+	st = m.Status().Instances[0]
+	if st.State != string(StateReady) || st.Restarting || st.Error != "" || st.Restarts != 1 {
+		t.Fatalf("unexpected status after restart: %+v", st)
+	}
+	if err := m.EnsureInstance(testCtx(t), "m"); err != nil {
+		t.Fatalf("ensure after restart: %v", err)
+	}
+}
+
+func TestScheduler_FailWaiting(t *testing.T) {
+	s := newScheduler(1, testSchedConfig(4))
+	rel, err := s.acquire(context.Background(), "m", PriorityNormal, "", time.Minute)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := s.acquire(context.Background(), "m", PriorityNormal, "", time.Minute)
+			errs <- err
+		}()
+	}
+	for s.stats().queued != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	boom := errors.New("boom")
+	if n := s.failWaiting(boom); n != 2 {
+		t.Fatalf("failed %d waiters, want 2", n)
+	}
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != boom {
+			t.Fatalf("waiter %d: got %v", i, err)
+		}
+	}
+	// The running request keeps its slot and frees it normally.
+	rel()
+	if st := s.stats(); st.inflight != 0 || st.queued != 0 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+}
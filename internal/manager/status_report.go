@@ -26,21 +26,45 @@ func (m *Manager) Status() types.StatusResponse {
 	warmups := 0
 	draining := 0
 	for _, inst := range m.instances {
-		if inst.State == StateLoading { warmups++ }
-		if inst.State == StateDraining { draining++ }
+		if inst.State == StateLoading {
+			warmups++
+		}
+		if inst.State == StateDraining {
+			draining++
+		}
+		st := inst.sched.stats()
+		var vram *types.VRAMEstimate
+		if inst.VRAM.Source != "" {
+			v := inst.VRAM
+			vram = &v
+		}
 		resp.Instances = append(resp.Instances, types.InstanceStatus{
-			ModelID:       inst.ID,
-			State:         string(inst.State),
-			LastUsed:      inst.LastUsed.Unix(),
-			EstVRAMMB:     inst.EstVRAMMB,
-			QueueLen:      len(inst.queueCh),
-			Inflight:      len(inst.genCh),
-			MaxQueueDepth: cap(inst.queueCh),
+			ModelID:   inst.ID,
+			State:     string(inst.State),
+			LastUsed:  inst.LastUsed.Unix(),
+			EstVRAMMB: inst.EstVRAMMB,
+			VRAM:      vram,
+			QueueLen:  st.queued,
+			QueueByPriority: map[string]int{
+				PriorityInteractive.String(): st.byPrio[PriorityInteractive],
+				PriorityNormal.String():      st.byPrio[PriorityNormal],
+				PriorityBatch.String():       st.byPrio[PriorityBatch],
+			},
+			Inflight:      st.inflight,
+			MaxQueueDepth: st.maxDepth,
+			Slots:         st.slots,
 			Port:          inst.Port,
 			PID:           inst.PID,
+			Profile:       inst.profile,
+			Error:         inst.err,
+			Restarting:    inst.restarting,
+			Restarts:      inst.restarts,
+			Adopted:       inst.adopted,
 		})
 	}
 	resp.WarmupsInProgress = warmups
 	resp.DrainingCount = draining
+	resp.LoadsTotal = m.loadsTotal.Load()
+	resp.EvictionsTotal = m.evictionsTotal.Load()
 	return resp
 }
@@ -16,10 +16,12 @@ func main() {
 	var model string
 	var host string
 	var port string
+	var parallel int
 	// Accept a subset of llama-server flags used by the adapter
 	flag.StringVar(&model, "m", "", "model path")
 	flag.StringVar(&host, "host", "127.0.0.1", "host")
 	flag.StringVar(&port, "port", "0", "port")
+	flag.IntVar(&parallel, "parallel", 1, "parallel slots")
 	flag.Parse()
 
 	addr := fmt.Sprintf("%s:%s", host, port)
@@ -30,6 +32,8 @@ func main() {
 	})
 
 	srv := &http.Server{Addr: addr, Handler: mux}
+	fmt.Printf("build: fake llama-server (model %s)\n", model)
+	log.Printf("main: server is listening on http://%s", addr)
 	go func() {
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("server error: %v", err)
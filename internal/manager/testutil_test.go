@@ -41,10 +41,14 @@ type fakeAdapter struct {
 	tokens     []string
 	final      FinalResult
 	receivedMP string
+	// last prompt and params seen by Generate/Start
+	receivedPrompt string
+	receivedParams InferParams
 }
 
 func (f *fakeAdapter) Start(modelPath string, params InferParams) (InferSession, error) {
 	f.receivedMP = modelPath
+	f.receivedParams = params
 	if f.startErr != nil {
 		return nil, f.startErr
 	}
@@ -54,6 +58,7 @@ func (f *fakeAdapter) Start(modelPath string, params InferParams) (InferSession,
 type fakeSession struct{ f *fakeAdapter }
 
 func (s fakeSession) Generate(ctx context.Context, prompt string, onToken func(string) error) (FinalResult, error) {
+	s.f.receivedPrompt = prompt
 	if s.f.genErr != nil {
 		return FinalResult{}, s.f.genErr
 	}
@@ -90,3 +95,18 @@ func testCtx(t *testing.T) context.Context {
     t.Cleanup(cancel)
     return c
 }
+
+// waitQueued waits until n requests are queued on the model's instance.
+func waitQueued(t *testing.T, m *Manager, id string, n int) {
+	t.Helper()
+	m.mu.RLock()
+	inst := m.instances[id]
+	m.mu.RUnlock()
+	deadline := time.Now().Add(2 * time.Second)
+	for inst.sched.stats().queued != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d queued on %s", n, id)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
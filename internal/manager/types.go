@@ -1,6 +1,10 @@
 package manager
 
-import "time"
+import (
+	"time"
+
+	"modeld/pkg/types"
+)
 
 // State represents lifecycle state of the manager/instances.
 type State string
@@ -34,11 +38,23 @@ type Instance struct {
 	State     State
 	LastUsed  time.Time
 	EstVRAMMB int
-	// Queueing primitives
-	genCh   chan struct{} // size 1: single in-flight generation
-	queueCh chan struct{} // buffered: queue slots
+	// VRAM is the breakdown behind EstVRAMMB
+	VRAM types.VRAMEstimate
+	// Admission: generation slots and priority/fair queueing
+	sched *scheduler
+	// Model file the instance was loaded from (outlives registry reloads)
+	path string
 	// Runtime endpoint info (when inference via external runtime is enabled)
 	Port int
 	// Process ID when using subprocess-managed runtime
 	PID  int
+	// Effective llama-server settings the runtime was spawned with
+	profile *types.RuntimeProfile
+	// Crash supervision: last runtime error, whether a restart is pending
+	// and how many restarts succeeded
+	err        string
+	restarting bool
+	restarts   int
+	// Runtime adopted from a previous modeld (see AdoptRuntimes)
+	adopted bool
 }
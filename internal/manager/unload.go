@@ -12,6 +12,7 @@ func (m *Manager) Unload(modelID string) error {
 	if modelID == "" {
 		return ErrModelNotFound("(unspecified)")
 	}
+	modelID = m.canonicalID(modelID)
 	m.mu.Lock()
 	inst := m.instances[modelID]
 	if inst == nil {
@@ -24,10 +25,8 @@ func (m *Manager) Unload(modelID string) error {
 
 	deadline := time.Now().Add(m.drainTimeout)
 	for {
-		m.mu.RLock()
-		qlen := len(inst.queueCh)
-		inflight := len(inst.genCh)
-		m.mu.RUnlock()
+		st := inst.sched.stats()
+		qlen, inflight := st.queued, st.inflight
 		if inflight == 0 && qlen == 0 {
 			break
 		}
@@ -40,14 +39,19 @@ func (m *Manager) Unload(modelID string) error {
 
 	// Stop subprocess if in spawn mode
 	if sa, ok := m.adapter.(*llamaSubprocessAdapter); ok {
-		if mdl, ok2 := m.getModelByID(modelID); ok2 {
-			_ = sa.Stop(mdl.Path)
+		path := inst.path
+		if mdl, ok2 := m.getModelByID(modelID); ok2 && path == "" {
+			path = mdl.Path
+		}
+		if path != "" {
+			_ = sa.Stop(path)
 		}
 	}
 
 	m.mu.Lock()
 	// Adjust accounting and remove
 	if inst2 := m.instances[modelID]; inst2 != nil {
+		m.rememberLRU(inst2)
 		m.usedEstMB -= inst2.EstVRAMMB
 		if m.usedEstMB < 0 {
 			m.usedEstMB = 0
@@ -57,7 +61,10 @@ func (m *Manager) Unload(modelID string) error {
 	if m.cur != nil && m.cur.ID == modelID {
 		m.cur = nil
 	}
+	managerVRAMUsedMB.Set(float64(m.usedEstMB))
 	m.mu.Unlock()
+	m.saveLRUMetadata()
+	forgetModelMetrics(modelID)
 
 	m.publisher.Publish(Event{Name: "unload_done", ModelID: modelID, Fields: map[string]any{}})
 	return nil
@@ -0,0 +1,249 @@
+package manager
+
+import (
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"modeld/internal/registry"
+	"modeld/pkg/types"
+)
+
+// VRAM estimation constants. The overhead terms are heuristics for the
+// runtime (CUDA context, allocator slack) and the per-slot compute buffers,
+// which llama.cpp sizes from the micro-batch and hidden size.
+const (
+	defaultEstimateCtx  = 4096 // llama-server's default -c
+	runtimeOverheadMB   = 256
+	computeUBatch       = 512
+	computeBufferFactor = 16 // bytes per hidden unit per micro-batch token
+)
+
+// kvCacheTypeBytes is the encoded size per element of the KV cache types
+// accepted by --cache-type-k/--cache-type-v.
+var kvCacheTypeBytes = map[string]float64{
+	"f32": 4, "f16": 2, "bf16": 2,
+	"q8_0": 34.0 / 32, "q4_0": 18.0 / 32, "q4_1": 20.0 / 32,
+	"q5_0": 22.0 / 32, "q5_1": 24.0 / 32, "iq4_nl": 18.0 / 32,
+}
+
+// ggufHeader returns the parsed header of the GGUF file at path, or nil if it
+// is unreadable. Results are cached per path.
+func (m *Manager) ggufHeader(path string) *registry.GGUFInfo {
+	if path == "" {
+		return nil
+	}
+	m.ggufMu.Lock()
+	defer m.ggufMu.Unlock()
+	if info, ok := m.ggufCache[path]; ok {
+		return info
+	}
+	var info *registry.GGUFInfo
+	if h, err := registry.ReadGGUF(path); err == nil {
+		info = &h
+	}
+	if m.ggufCache == nil {
+		m.ggufCache = make(map[string]*registry.GGUFInfo)
+	}
+	m.ggufCache[path] = info
+	return info
+}
+
+// modelHeader returns the header of mdl's file. For a split model the
+// tensors of all shards are combined; nil if the set is incomplete or a
+// shard is unreadable.
+func (m *Manager) modelHeader(mdl types.Model) *registry.GGUFInfo {
+	if len(mdl.Shards) <= 1 && mdl.ShardCount <= 1 {
+		return m.ggufHeader(mdl.Path)
+	}
+	if len(registry.MissingShards(mdl)) > 0 {
+		return nil
+	}
+	first := m.ggufHeader(mdl.Shards[0])
+	if first == nil {
+		return nil
+	}
+	merged := *first
+	merged.Tensors = slices.Clone(first.Tensors)
+	for _, p := range mdl.Shards[1:] {
+		h := m.ggufHeader(p)
+		if h == nil {
+			return nil
+		}
+		merged.Tensors = append(merged.Tensors, h.Tensors...)
+	}
+	return &merged
+}
+
+// spawnSettings are the llama-server settings that affect VRAM, resolved the
+// way spawnArgs orders them: the model's runtime profile, then its extra
+// args (later flags win).
+type spawnSettings struct {
+	ctx        int
+	ngl        int // < 0: unset, all layers offloaded
+	cacheTypeK string
+	cacheTypeV string
+}
+
+func (m *Manager) spawnSettingsFor(mdl types.Model) spawnSettings {
+	prof := m.runtimeProfile(mdl)
+	s := spawnSettings{ctx: prof.ContextSize, ngl: -1, cacheTypeK: "f16", cacheTypeV: "f16"}
+	if prof.GPULayers != nil {
+		s.ngl = *prof.GPULayers
+	}
+	args := prof.ExtraArgs
+	for i := 0; i < len(args); i++ {
+		name, val, hasVal := strings.Cut(args[i], "=")
+		if !hasVal {
+			if i+1 >= len(args) {
+				break
+			}
+			val = args[i+1]
+		}
+		switch name {
+		case "-c", "--ctx-size":
+			if n, err := strconv.Atoi(val); err == nil {
+				s.ctx = n
+			}
+		case "-ngl", "--gpu-layers", "--n-gpu-layers":
+			if n, err := strconv.Atoi(val); err == nil {
+				s.ngl = n
+			}
+		case "-ctk", "--cache-type-k":
+			s.cacheTypeK = strings.ToLower(val)
+		case "-ctv", "--cache-type-v":
+			s.cacheTypeV = strings.ToLower(val)
+		default:
+			continue
+		}
+		if !hasVal {
+			i++
+		}
+	}
+	return s
+}
+
+// estimateVRAM estimates the VRAM an instance of mdl needs with the given
+// number of slots. A manifest vram_mb wins; a readable GGUF header gives
+// weights (offloaded tensors), KV cache and overhead; otherwise the file
+// size (summed over shards) stands in for the weights.
+func (m *Manager) estimateVRAM(mdl types.Model, slots int) types.VRAMEstimate {
+	if mdl.VRAMMB > 0 {
+		return types.VRAMEstimate{WeightsMB: mdl.VRAMMB, TotalMB: mdl.VRAMMB, Source: "manifest"}
+	}
+	info := m.modelHeader(mdl)
+	if info == nil || len(info.Tensors) == 0 {
+		mb := modelSizeMB(mdl)
+		return types.VRAMEstimate{WeightsMB: mb, TotalMB: mb, Source: "file_size"}
+	}
+
+	set := m.spawnSettingsFor(mdl)
+	layers := int(info.BlockCount())
+	if layers == 0 {
+		for _, t := range info.Tensors {
+			if n, ok := t.Layer(); ok && n+1 > layers {
+				layers = n + 1
+			}
+		}
+	}
+	gpuLayers := layers
+	if set.ngl >= 0 && set.ngl < layers {
+		gpuLayers = set.ngl
+	}
+	// llama.cpp offloads the last gpuLayers blocks and the output head only
+	// when -ngl exceeds the block count. Token embeddings stay on the CPU
+	// unless they double as the (tied) output head.
+	fullOffload := set.ngl < 0 || set.ngl > layers
+	hasOutput := false
+	for _, t := range info.Tensors {
+		hasOutput = hasOutput || strings.HasPrefix(t.Name, "output.")
+	}
+	var weights uint64
+	for _, t := range info.Tensors {
+		onGPU := false
+		if n, ok := t.Layer(); ok {
+			onGPU = n >= layers-gpuLayers
+		} else if strings.HasPrefix(t.Name, "output") {
+			onGPU = fullOffload
+		} else if strings.HasPrefix(t.Name, "token_embd.") {
+			onGPU = fullOffload && !hasOutput
+		}
+		if !onGPU {
+			continue
+		}
+		b, ok := t.Bytes()
+		if !ok {
+			// Unknown tensor type: fall back to the file size.
+			mb := modelSizeMB(mdl)
+			return types.VRAMEstimate{WeightsMB: mb, TotalMB: mb, Source: "file_size"}
+		}
+		weights += b
+	}
+
+	ctx := set.ctx
+	if ctx <= 0 {
+		ctx = defaultEstimateCtx
+		if trained := int(info.ContextLength()); trained > 0 && trained < ctx {
+			ctx = trained
+		}
+	}
+	bk, okK := kvCacheTypeBytes[set.cacheTypeK]
+	bv, okV := kvCacheTypeBytes[set.cacheTypeV]
+	if !okK {
+		bk = 2
+	}
+	if !okV {
+		bv = 2
+	}
+	// The KV cache holds ctx cells per offloaded layer, shared by all slots.
+	kvPerLayer := float64(info.HeadCountKV()) * (float64(info.KeyLength())*bk + float64(info.ValueLength())*bv)
+	kv := float64(ctx) * float64(gpuLayers) * kvPerLayer
+
+	var overhead float64
+	if gpuLayers > 0 {
+		if slots < 1 {
+			slots = 1
+		}
+		overhead = runtimeOverheadMB*mib + float64(slots)*float64(info.EmbeddingLength())*computeUBatch*computeBufferFactor
+	}
+
+	est := types.VRAMEstimate{
+		WeightsMB:   toMB(float64(weights)),
+		KVCacheMB:   toMB(kv),
+		OverheadMB:  toMB(overhead),
+		Source:      "gguf",
+		ContextSize: ctx,
+		GPULayers:   gpuLayers,
+		Layers:      layers,
+		CacheTypeK:  set.cacheTypeK,
+		CacheTypeV:  set.cacheTypeV,
+	}
+	est.TotalMB = max(1, est.WeightsMB+est.KVCacheMB+est.OverheadMB)
+	return est
+}
+
+const mib = 1024 * 1024
+
+func toMB(b float64) int { return int(math.Ceil(b / mib)) }
+
+// modelFiles returns the files of mdl: its shards, or its single path.
+func modelFiles(mdl types.Model) []string {
+	if len(mdl.Shards) > 0 {
+		return mdl.Shards
+	}
+	return []string{mdl.Path}
+}
+
+// modelSizeMB returns the total size of mdl's files in MB, at least 1 so an
+// unknown size never bypasses budget checks.
+func modelSizeMB(mdl types.Model) int {
+	var size int64
+	for _, p := range modelFiles(mdl) {
+		if fi, err := os.Stat(p); err == nil {
+			size += fi.Size()
+		}
+	}
+	return max(1, int(size/mib))
+}
@@ -0,0 +1,165 @@
+package manager
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"modeld/internal/registry"
+	"modeld/pkg/types"
+)
+
+// writeGGUFModel writes a GGUF header for a two-layer llama model with F32
+// tensors of 4 MiB each (token_embd, output, blk.0, blk.1).
+func writeGGUFModel(t *testing.T, dir string) string {
+	t.Helper()
+	var b bytes.Buffer
+	le := binary.LittleEndian
+	str := func(s string) {
+		_ = binary.Write(&b, le, uint64(len(s)))
+		b.WriteString(s)
+	}
+	u32s := []struct {
+		k string
+		v uint32
+	}{
+		{"llama.block_count", 2},
+		{"llama.embedding_length", 1024},
+		{"llama.attention.head_count", 8},
+		{"llama.attention.head_count_kv", 2},
+		{"llama.context_length", 8192},
+	}
+	tensors := []string{"token_embd.weight", "output.weight", "blk.0.attn_q.weight", "blk.1.attn_q.weight"}
+	b.WriteString("GGUF")
+	_ = binary.Write(&b, le, uint32(3))
+	_ = binary.Write(&b, le, uint64(len(tensors)))
+	_ = binary.Write(&b, le, uint64(len(u32s)+1))
+	str("general.architecture")
+	_ = binary.Write(&b, le, uint32(registry.GGUFTypeString))
+	str("llama")
+	for _, kv := range u32s {
+		str(kv.k)
+		_ = binary.Write(&b, le, uint32(registry.GGUFTypeUint32))
+		_ = binary.Write(&b, le, kv.v)
+	}
+	for _, name := range tensors {
+		str(name)
+		_ = binary.Write(&b, le, uint32(2))
+		_ = binary.Write(&b, le, []uint64{1024, 1024})
+		_ = binary.Write(&b, le, uint32(0)) // F32
+		_ = binary.Write(&b, le, uint64(0))
+	}
+	p := filepath.Join(dir, "model.gguf")
+	if err := os.WriteFile(p, b.Bytes(), 0o644); err != nil {
+		t.Fatalf("write gguf: %v", err)
+	}
+	return p
+}
+
+func TestEstimateVRAM_FromGGUF(t *testing.T) {
+	p := writeGGUFModel(t, t.TempDir())
+	mdl := types.Model{ID: "m", Path: p}
+
+	m := NewWithConfig(ManagerConfig{LlamaCtxSize: 4096})
+	est := m.estimateVRAM(mdl, 2)
+	// Blocks and output head offloaded, token embeddings stay on the CPU.
+	// KV: 4096 ctx * 2 layers * 2 kv heads * (128 + 128) * 2 bytes = 8 MiB.
+	// Overhead: 256 MiB + 2 slots * 1024 * 512 * 16 bytes.
+	want := types.VRAMEstimate{WeightsMB: 12, KVCacheMB: 8, OverheadMB: 272, TotalMB: 292, Source: "gguf",
+		ContextSize: 4096, GPULayers: 2, Layers: 2, CacheTypeK: "f16", CacheTypeV: "f16"}
+	if est != want {
+		t.Fatalf("estimate=%+v\nwant      %+v", est, want)
+	}
+
+	// Partial offload keeps the output head and lower blocks on the CPU;
+	// a quantized KV cache shrinks the cache. The trained context (8192)
+	// is not used when -c is unset: llama-server defaults to 4096.
+	m = NewWithConfig(ManagerConfig{LlamaNGL: 1, LlamaExtraArgs: []string{"-ctk", "q8_0", "--cache-type-v=q8_0"}})
+	est = m.estimateVRAM(mdl, 1)
+	if est.WeightsMB != 4 || est.GPULayers != 1 || est.KVCacheMB != 3 || est.ContextSize != 4096 || est.OverheadMB != 264 {
+		t.Fatalf("partial offload estimate: %+v", est)
+	}
+	// Per-model args come last and win; -ngl 0 keeps everything on the CPU.
+	est = m.estimateVRAM(types.Model{Path: p, ContextSize: 1024, LlamaArgs: []string{"-ngl", "0"}}, 1)
+	if est.WeightsMB != 0 || est.KVCacheMB != 0 || est.OverheadMB != 0 || est.TotalMB != 1 || est.ContextSize != 1024 {
+		t.Fatalf("cpu-only estimate: %+v", est)
+	}
+}
+
+func TestEstimateVRAM_FallbacksAndStatus(t *testing.T) {
+	dir := t.TempDir()
+	m := NewWithConfig(ManagerConfig{})
+	if est := m.estimateVRAM(types.Model{Path: createModelFile(t, dir, "raw.bin", 3), VRAMMB: 700}, 1); est.TotalMB != 700 || est.Source != "manifest" {
+		t.Fatalf("manifest estimate: %+v", est)
+	}
+	if est := m.estimateVRAM(types.Model{Path: createModelFile(t, dir, "raw2.bin", 3)}, 1); est.TotalMB != 3 || est.Source != "file_size" {
+		t.Fatalf("file size estimate: %+v", est)
+	}
+
+	p := writeGGUFModel(t, dir)
+	m = NewWithConfig(ManagerConfig{Registry: []types.Model{{ID: "g", Path: p}}, Slots: 2, LlamaCtxSize: 4096})
+	if err := m.EnsureInstance(testCtx(t), "g"); err != nil {
+		t.Fatalf("ensure: %v", err)
+	}
+	st := m.Status()
+	if len(st.Instances) != 1 || st.Instances[0].VRAM == nil {
+		t.Fatalf("expected VRAM breakdown in status: %+v", st.Instances)
+	}
+	if in := st.Instances[0]; in.EstVRAMMB != 292 || in.VRAM.TotalMB != 292 || in.VRAM.KVCacheMB != 8 || st.UsedMB != 292 {
+		t.Fatalf("unexpected status: %+v used=%d", in.VRAM, st.UsedMB)
+	}
+}
+
+func TestEstimateVRAM_SplitModel(t *testing.T) {
+	dir := t.TempDir()
+	m := NewWithConfig(ManagerConfig{LlamaCtxSize: 4096})
+
+	// Unparseable shards: the sizes add up.
+	raw := types.Model{
+		Path:       createModelFile(t, dir, "raw-00001-of-00002.gguf", 3),
+		Shards:     []string{filepath.Join(dir, "raw-00001-of-00002.gguf"), createModelFile(t, dir, "raw-00002-of-00002.gguf", 2)},
+		ShardCount: 2,
+	}
+	if est := m.estimateVRAM(raw, 1); est.TotalMB != 5 || est.Source != "file_size" {
+		t.Fatalf("split file size estimate: %+v", est)
+	}
+
+	// Tensors of every shard count; here the second shard repeats the first.
+	first := writeGGUFModel(t, dir)
+	data, err := os.ReadFile(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second := filepath.Join(dir, "g-00002-of-00002.gguf")
+	if err := os.WriteFile(second, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	split := types.Model{Path: first, Shards: []string{first, second}, ShardCount: 2}
+	if est := m.estimateVRAM(split, 2); est.Source != "gguf" || est.WeightsMB != 24 {
+		t.Fatalf("split gguf estimate: %+v", est)
+	}
+}
+
+func TestSplitModel_IncompleteSetRefused(t *testing.T) {
+	dir := t.TempDir()
+	p := createModelFile(t, dir, "big-00001-of-00002.gguf", 1)
+	mdl := types.Model{ID: "big.gguf", Path: p, Shards: []string{p}, ShardCount: 2}
+	m := NewWithConfig(ManagerConfig{Registry: []types.Model{mdl}})
+	m.adapter = &fakeAdapter{}
+
+	if err := m.EnsureInstance(testCtx(t), "big.gguf"); !IsDependencyUnavailable(err) || !strings.Contains(err.Error(), "big-00002-of-00002.gguf") {
+		t.Fatalf("EnsureInstance err = %v, want missing shard", err)
+	}
+	var check *Check
+	for _, c := range m.Preflight() {
+		if c.Name == "model_shards_complete" {
+			check = &c
+		}
+	}
+	if check == nil || check.OK || !strings.Contains(check.Message, "big.gguf (1 of 2 shards") {
+		t.Fatalf("model_shards_complete = %+v", check)
+	}
+}
@@ -0,0 +1,481 @@
+package registry
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"modeld/pkg/types"
+)
+
+// GGUFType is a GGUF metadata value type.
+type GGUFType uint32
+
+// GGUF metadata value types.
+const (
+	GGUFTypeUint8   GGUFType = 0
+	GGUFTypeInt8    GGUFType = 1
+	GGUFTypeUint16  GGUFType = 2
+	GGUFTypeInt16   GGUFType = 3
+	GGUFTypeUint32  GGUFType = 4
+	GGUFTypeInt32   GGUFType = 5
+	GGUFTypeFloat32 GGUFType = 6
+	GGUFTypeBool    GGUFType = 7
+	GGUFTypeString  GGUFType = 8
+	GGUFTypeArray   GGUFType = 9
+	GGUFTypeUint64  GGUFType = 10
+	GGUFTypeInt64   GGUFType = 11
+	GGUFTypeFloat64 GGUFType = 12
+)
+
+// Limits that keep a corrupt header from driving huge allocations or loops.
+const (
+	maxGGUFString  = 1 << 26
+	maxGGUFKVs     = 1 << 20
+	maxGGUFTensors = 1 << 24
+	maxGGUFDims    = 8
+	// ggufPrealloc caps capacities taken from header counts and string
+	// lengths; anything larger grows as the data is actually read.
+	ggufPrealloc = 1024
+)
+
+// GGUFChatTemplateKey is the metadata key holding the model's Jinja chat template.
+const GGUFChatTemplateKey = "tokenizer.chat_template"
+
+// GGUFInfo is the header of a GGUF file: its scalar metadata and the shapes
+// of its tensors. Tensor data is never read.
+type GGUFInfo struct {
+	Version uint32
+	// Metadata holds every scalar and string KV; arrays (e.g. the tokenizer
+	// vocabulary) are skipped.
+	Metadata map[string]any
+	// Tensors lists tensor names, shapes and ggml types in file order.
+	Tensors []GGUFTensor
+}
+
+// GGUFTensor describes one tensor from the header.
+type GGUFTensor struct {
+	Name string
+	Dims []uint64
+	Type uint32
+}
+
+// Elements returns the number of values in the tensor.
+func (t GGUFTensor) Elements() uint64 {
+	n := uint64(1)
+	for _, d := range t.Dims {
+		n *= d
+	}
+	return n
+}
+
+// ggmlTypeSizes maps ggml tensor types to their block size (values per
+// block) and encoded block size in bytes.
+var ggmlTypeSizes = map[uint32][2]uint64{
+	0: {1, 4}, 1: {1, 2}, 2: {32, 18}, 3: {32, 20}, 6: {32, 22}, 7: {32, 24},
+	8: {32, 34}, 9: {32, 36}, 10: {256, 84}, 11: {256, 110}, 12: {256, 144},
+	13: {256, 176}, 14: {256, 210}, 15: {256, 292}, 16: {256, 66}, 17: {256, 74},
+	18: {256, 98}, 19: {256, 50}, 20: {32, 18}, 21: {256, 110}, 22: {256, 82},
+	23: {256, 136}, 24: {1, 1}, 25: {1, 2}, 26: {1, 4}, 27: {1, 8}, 28: {1, 8},
+	29: {256, 56}, 30: {1, 2},
+}
+
+// Bytes returns the tensor's encoded size, or false for an unknown type.
+func (t GGUFTensor) Bytes() (uint64, bool) {
+	sz, ok := ggmlTypeSizes[t.Type]
+	if !ok {
+		return 0, false
+	}
+	return (t.Elements() + sz[0] - 1) / sz[0] * sz[1], true
+}
+
+// Layer returns the block index of a per-layer tensor ("blk.<n>.…").
+func (t GGUFTensor) Layer() (int, bool) {
+	rest, ok := strings.CutPrefix(t.Name, "blk.")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(rest[:max(0, strings.IndexByte(rest, '.'))])
+	return n, err == nil
+}
+
+// ReadGGUF parses the header and metadata of the GGUF (v2/v3) file at path.
+func ReadGGUF(path string) (GGUFInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return GGUFInfo{}, err
+	}
+	defer f.Close()
+	return ParseGGUF(f)
+}
+
+// ParseGGUF parses a GGUF header from r, stopping before the tensor data.
+func ParseGGUF(rd io.Reader) (GGUFInfo, error) {
+	r := bufio.NewReaderSize(rd, 64*1024)
+	var hdr struct {
+		Magic   [4]byte
+		Version uint32
+		Tensors uint64
+		KVs     uint64
+	}
+	if err := binary.Read(r, binary.LittleEndian, &hdr); err != nil {
+		return GGUFInfo{}, fmt.Errorf("gguf header: %w", err)
+	}
+	if string(hdr.Magic[:]) != "GGUF" {
+		return GGUFInfo{}, errors.New("not a GGUF file")
+	}
+	if hdr.Version < 2 {
+		return GGUFInfo{}, fmt.Errorf("unsupported GGUF version %d", hdr.Version)
+	}
+	if hdr.KVs > maxGGUFKVs || hdr.Tensors > maxGGUFTensors {
+		return GGUFInfo{}, errors.New("gguf header counts out of range")
+	}
+	info := GGUFInfo{Version: hdr.Version, Metadata: make(map[string]any, min(hdr.KVs, ggufPrealloc))}
+	for i := uint64(0); i < hdr.KVs; i++ {
+		k, err := ggufReadString(r)
+		if err != nil {
+			return info, fmt.Errorf("gguf kv %d: %w", i, err)
+		}
+		var typ uint32
+		if err := binary.Read(r, binary.LittleEndian, &typ); err != nil {
+			return info, fmt.Errorf("gguf kv %q: %w", k, err)
+		}
+		if GGUFType(typ) == GGUFTypeArray {
+			if err := ggufSkipValue(r, GGUFTypeArray); err != nil {
+				return info, fmt.Errorf("gguf kv %q: %w", k, err)
+			}
+			continue
+		}
+		v, err := ggufReadValue(r, GGUFType(typ))
+		if err != nil {
+			return info, fmt.Errorf("gguf kv %q: %w", k, err)
+		}
+		info.Metadata[k] = v
+	}
+	info.Tensors = make([]GGUFTensor, 0, min(hdr.Tensors, ggufPrealloc))
+	for i := uint64(0); i < hdr.Tensors; i++ {
+		t, err := ggufReadTensorInfo(r)
+		if err != nil {
+			return info, fmt.Errorf("gguf tensor %d: %w", i, err)
+		}
+		info.Tensors = append(info.Tensors, t)
+	}
+	return info, nil
+}
+
+func ggufReadTensorInfo(r *bufio.Reader) (GGUFTensor, error) {
+	var t GGUFTensor
+	var err error
+	if t.Name, err = ggufReadString(r); err != nil {
+		return t, err
+	}
+	var nDims uint32
+	if err := binary.Read(r, binary.LittleEndian, &nDims); err != nil {
+		return t, err
+	}
+	if nDims > maxGGUFDims {
+		return t, fmt.Errorf("tensor %q has %d dimensions", t.Name, nDims)
+	}
+	t.Dims = make([]uint64, nDims)
+	if err := binary.Read(r, binary.LittleEndian, t.Dims); err != nil {
+		return t, err
+	}
+	if err := binary.Read(r, binary.LittleEndian, &t.Type); err != nil {
+		return t, err
+	}
+	_, err = r.Discard(8) // data offset
+	return t, err
+}
+
+// String returns the string metadata value for key, or "".
+func (g GGUFInfo) String(key string) string {
+	s, _ := g.Metadata[key].(string)
+	return s
+}
+
+// Uint returns an integer metadata value for key as uint64.
+func (g GGUFInfo) Uint(key string) (uint64, bool) {
+	switch v := g.Metadata[key].(type) {
+	case uint8:
+		return uint64(v), true
+	case uint16:
+		return uint64(v), true
+	case uint32:
+		return uint64(v), true
+	case uint64:
+		return v, true
+	case int8:
+		return uint64(v), v >= 0
+	case int16:
+		return uint64(v), v >= 0
+	case int32:
+		return uint64(v), v >= 0
+	case int64:
+		return uint64(v), v >= 0
+	}
+	return 0, false
+}
+
+// Architecture returns general.architecture (e.g. "llama", "qwen2").
+func (g GGUFInfo) Architecture() string { return g.String("general.architecture") }
+
+// archUint returns the architecture-scoped value "<arch>.<key>".
+func (g GGUFInfo) archUint(key string) uint64 {
+	v, _ := g.Uint(g.Architecture() + "." + key)
+	return v
+}
+
+// ContextLength returns the trained context length.
+func (g GGUFInfo) ContextLength() uint64 { return g.archUint("context_length") }
+
+// BlockCount returns the number of transformer layers.
+func (g GGUFInfo) BlockCount() uint64 { return g.archUint("block_count") }
+
+// EmbeddingLength returns the model's hidden size.
+func (g GGUFInfo) EmbeddingLength() uint64 { return g.archUint("embedding_length") }
+
+// HeadCount returns the number of attention heads.
+func (g GGUFInfo) HeadCount() uint64 { return g.archUint("attention.head_count") }
+
+// HeadCountKV returns the number of key/value heads (grouped-query
+// attention), defaulting to HeadCount.
+func (g GGUFInfo) HeadCountKV() uint64 {
+	if n := g.archUint("attention.head_count_kv"); n > 0 {
+		return n
+	}
+	return g.HeadCount()
+}
+
+// KeyLength and ValueLength return the per-head key and value sizes,
+// defaulting to EmbeddingLength / HeadCount.
+func (g GGUFInfo) KeyLength() uint64   { return g.headLength("attention.key_length") }
+func (g GGUFInfo) ValueLength() uint64 { return g.headLength("attention.value_length") }
+
+func (g GGUFInfo) headLength(key string) uint64 {
+	if n := g.archUint(key); n > 0 {
+		return n
+	}
+	if h := g.HeadCount(); h > 0 {
+		return g.EmbeddingLength() / h
+	}
+	return 0
+}
+
+// ParameterCount returns general.parameter_count, or the sum of tensor
+// elements when the key is absent.
+func (g GGUFInfo) ParameterCount() uint64 {
+	if n, ok := g.Uint("general.parameter_count"); ok && n > 0 {
+		return n
+	}
+	var n uint64
+	for _, t := range g.Tensors {
+		n += t.Elements()
+	}
+	return n
+}
+
+// ggufFileTypes names llama.cpp file types (general.file_type).
+var ggufFileTypes = map[uint64]string{
+	0: "F32", 1: "F16", 2: "Q4_0", 3: "Q4_1", 7: "Q8_0", 8: "Q5_0", 9: "Q5_1",
+	10: "Q2_K", 11: "Q3_K_S", 12: "Q3_K_M", 13: "Q3_K_L", 14: "Q4_K_S", 15: "Q4_K_M",
+	16: "Q5_K_S", 17: "Q5_K_M", 18: "Q6_K", 19: "IQ2_XXS", 20: "IQ2_XS", 21: "Q2_K_S",
+	22: "IQ3_XS", 23: "IQ3_XXS", 24: "IQ1_S", 25: "IQ4_NL", 26: "IQ3_S", 27: "IQ3_M",
+	28: "IQ2_S", 29: "IQ2_M", 30: "IQ4_XS", 31: "IQ1_M", 32: "BF16",
+}
+
+// Quantization returns the quantization name from general.file_type, or "".
+func (g GGUFInfo) Quantization() string {
+	ft, ok := g.Uint("general.file_type")
+	if !ok {
+		return ""
+	}
+	if name, ok := ggufFileTypes[ft]; ok {
+		return name
+	}
+	return fmt.Sprintf("type%d", ft)
+}
+
+// ModelMetadata summarizes the header as exposed by the API.
+func (g GGUFInfo) ModelMetadata() *types.GGUFMetadata {
+	return &types.GGUFMetadata{
+		Version:       g.Version,
+		Architecture:  g.Architecture(),
+		Name:          g.String("general.name"),
+		Parameters:    g.ParameterCount(),
+		ContextLength: g.ContextLength(),
+		Layers:        g.BlockCount(),
+		Quantization:  g.Quantization(),
+		ChatTemplate:  g.String(GGUFChatTemplateKey),
+		Tensors:       len(g.Tensors),
+	}
+}
+
+// applyGGUF fills model fields from the file header. Values already set (by
+// a manifest) win; unreadable or non-GGUF files are left untouched.
+func applyGGUF(mdl *types.Model) {
+	paths := mdl.Shards
+	if len(paths) == 0 || len(paths) < mdl.ShardCount {
+		paths = []string{mdl.Path}
+	}
+	info, err := ReadGGUFShards(paths)
+	if err != nil {
+		return
+	}
+	meta := info.ModelMetadata()
+	mdl.GGUF = meta
+	if mdl.Quant == "" {
+		mdl.Quant = meta.Quantization
+	}
+	if mdl.Family == "" {
+		mdl.Family = meta.Architecture
+	}
+	if (mdl.Name == "" || mdl.Name == mdl.ID) && meta.Name != "" {
+		mdl.Name = meta.Name
+	}
+}
+
+func ggufReadString(r *bufio.Reader) (string, error) {
+	var n uint64
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return "", err
+	}
+	if n > maxGGUFString {
+		return "", errors.New("gguf string too long")
+	}
+	if n <= ggufPrealloc {
+		b := make([]byte, n)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+	// Long strings are copied incrementally so a truncated file fails
+	// before the claimed length is allocated.
+	var sb strings.Builder
+	if _, err := io.CopyN(&sb, r, int64(n)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// ggufReadValue reads a scalar or string value.
+func ggufReadValue(r *bufio.Reader, typ GGUFType) (any, error) {
+	le := binary.LittleEndian
+	var err error
+	switch typ {
+	case GGUFTypeUint8:
+		var v uint8
+		err = binary.Read(r, le, &v)
+		return v, err
+	case GGUFTypeInt8:
+		var v int8
+		err = binary.Read(r, le, &v)
+		return v, err
+	case GGUFTypeUint16:
+		var v uint16
+		err = binary.Read(r, le, &v)
+		return v, err
+	case GGUFTypeInt16:
+		var v int16
+		err = binary.Read(r, le, &v)
+		return v, err
+	case GGUFTypeUint32:
+		var v uint32
+		err = binary.Read(r, le, &v)
+		return v, err
+	case GGUFTypeInt32:
+		var v int32
+		err = binary.Read(r, le, &v)
+		return v, err
+	case GGUFTypeFloat32:
+		var v float32
+		err = binary.Read(r, le, &v)
+		return v, err
+	case GGUFTypeBool:
+		var v uint8
+		err = binary.Read(r, le, &v)
+		return v != 0, err
+	case GGUFTypeString:
+		return ggufReadString(r)
+	case GGUFTypeUint64:
+		var v uint64
+		err = binary.Read(r, le, &v)
+		return v, err
+	case GGUFTypeInt64:
+		var v int64
+		err = binary.Read(r, le, &v)
+		return v, err
+	case GGUFTypeFloat64:
+		var v float64
+		err = binary.Read(r, le, &v)
+		return v, err
+	}
+	return nil, fmt.Errorf("unknown gguf value type %d", typ)
+}
+
+// ggufScalarSize returns the encoded size of fixed-width types, or 0.
+func ggufScalarSize(typ GGUFType) int {
+	switch typ {
+	case GGUFTypeUint8, GGUFTypeInt8, GGUFTypeBool:
+		return 1
+	case GGUFTypeUint16, GGUFTypeInt16:
+		return 2
+	case GGUFTypeUint32, GGUFTypeInt32, GGUFTypeFloat32:
+		return 4
+	case GGUFTypeUint64, GGUFTypeInt64, GGUFTypeFloat64:
+		return 8
+	}
+	return 0
+}
+
+func ggufSkipValue(r *bufio.Reader, typ GGUFType) error {
+	if n := ggufScalarSize(typ); n > 0 {
+		_, err := r.Discard(n)
+		return err
+	}
+	switch typ {
+	case GGUFTypeString:
+		var n uint64
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+			return err
+		}
+		if n > maxGGUFString {
+			return errors.New("gguf string too long")
+		}
+		_, err := r.Discard(int(n))
+		return err
+	case GGUFTypeArray:
+		var elem uint32
+		var count uint64
+		if err := binary.Read(r, binary.LittleEndian, &elem); err != nil {
+			return err
+		}
+		if err := binary.Read(r, binary.LittleEndian, &count); err != nil {
+			return err
+		}
+		// Fixed-width arrays are skipped in one step.
+		if n := ggufScalarSize(GGUFType(elem)); n > 0 {
+			if count > math.MaxInt64/uint64(n) {
+				return errors.New("gguf array too long")
+			}
+			_, err := io.CopyN(io.Discard, r, int64(count)*int64(n))
+			return err
+		}
+		for j := uint64(0); j < count; j++ {
+			if err := ggufSkipValue(r, GGUFType(elem)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown gguf value type %d", typ)
+}
@@ -0,0 +1,197 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ggufBuilder assembles small synthetic GGUF files.
+type ggufBuilder struct {
+	kvs     bytes.Buffer
+	nKV     int
+	tensors bytes.Buffer
+	nTensor int
+}
+
+func (b *ggufBuilder) str(w *bytes.Buffer, s string) {
+	_ = binary.Write(w, binary.LittleEndian, uint64(len(s)))
+	w.WriteString(s)
+}
+
+func (b *ggufBuilder) kv(key string, typ GGUFType, v any) *ggufBuilder {
+	b.str(&b.kvs, key)
+	_ = binary.Write(&b.kvs, binary.LittleEndian, uint32(typ))
+	if s, ok := v.(string); ok {
+		b.str(&b.kvs, s)
+	} else {
+		_ = binary.Write(&b.kvs, binary.LittleEndian, v)
+	}
+	b.nKV++
+	return b
+}
+
+func (b *ggufBuilder) stringArray(key string, vals ...string) *ggufBuilder {
+	b.str(&b.kvs, key)
+	_ = binary.Write(&b.kvs, binary.LittleEndian, uint32(GGUFTypeArray))
+	_ = binary.Write(&b.kvs, binary.LittleEndian, uint32(GGUFTypeString))
+	_ = binary.Write(&b.kvs, binary.LittleEndian, uint64(len(vals)))
+	for _, v := range vals {
+		b.str(&b.kvs, v)
+	}
+	b.nKV++
+	return b
+}
+
+func (b *ggufBuilder) tensor(name string, typ uint32, dims ...uint64) *ggufBuilder {
+	b.str(&b.tensors, name)
+	_ = binary.Write(&b.tensors, binary.LittleEndian, uint32(len(dims)))
+	_ = binary.Write(&b.tensors, binary.LittleEndian, dims)
+	_ = binary.Write(&b.tensors, binary.LittleEndian, typ)
+	_ = binary.Write(&b.tensors, binary.LittleEndian, uint64(0))
+	b.nTensor++
+	return b
+}
+
+func (b *ggufBuilder) bytes() []byte {
+	var out bytes.Buffer
+	out.WriteString("GGUF")
+	_ = binary.Write(&out, binary.LittleEndian, uint32(3))
+	_ = binary.Write(&out, binary.LittleEndian, uint64(b.nTensor))
+	_ = binary.Write(&out, binary.LittleEndian, uint64(b.nKV))
+	out.Write(b.kvs.Bytes())
+	out.Write(b.tensors.Bytes())
+	// Tensor data follows; the parser must not need it.
+	out.Write(make([]byte, 32))
+	return out.Bytes()
+}
+
+func (b *ggufBuilder) write(t *testing.T, path string) {
+	t.Helper()
+	if err := os.WriteFile(path, b.bytes(), 0o644); err != nil {
+		t.Fatalf("write gguf: %v", err)
+	}
+}
+
+func llamaHeader() *ggufBuilder {
+	b := &ggufBuilder{}
+	b.kv("general.architecture", GGUFTypeString, "llama").
+		kv("general.name", GGUFTypeString, "Tiny Llama").
+		kv("general.file_type", GGUFTypeUint32, uint32(15)).
+		stringArray("tokenizer.ggml.tokens", "<s>", "</s>", "hi").
+		kv("llama.context_length", GGUFTypeUint32, uint32(4096)).
+		kv("llama.block_count", GGUFTypeUint32, uint32(2)).
+		kv("llama.rope.freq_base", GGUFTypeFloat32, float32(10000)).
+		kv("general.quantized", GGUFTypeBool, uint8(1)).
+		kv(GGUFChatTemplateKey, GGUFTypeString, "{{ '<|im_start|>' }}").
+		tensor("token_embd.weight", 12, 64, 100).
+		tensor("blk.0.attn_q.weight", 12, 64, 64)
+	return b
+}
+
+func TestParseGGUF_Metadata(t *testing.T) {
+	info, err := ParseGGUF(bytes.NewReader(llamaHeader().bytes()))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if info.Version != 3 || info.Architecture() != "llama" || info.ContextLength() != 4096 || info.BlockCount() != 2 {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+	if q := info.Quantization(); q != "Q4_K_M" {
+		t.Fatalf("quant=%q", q)
+	}
+	// No general.parameter_count: summed from tensor shapes.
+	if n := info.ParameterCount(); n != 64*100+64*64 {
+		t.Fatalf("params=%d", n)
+	}
+	if len(info.Tensors) != 2 || info.Tensors[1].Name != "blk.0.attn_q.weight" {
+		t.Fatalf("tensors=%+v", info.Tensors)
+	}
+	if n, ok := info.Tensors[0].Bytes(); !ok || n != 6400/256*144 {
+		t.Fatalf("Q4_K tensor bytes=%d %v", n, ok)
+	}
+	if _, ok := info.Tensors[0].Layer(); ok {
+		t.Fatalf("token_embd is not a layer tensor")
+	}
+	if n, ok := info.Tensors[1].Layer(); !ok || n != 0 {
+		t.Fatalf("layer=%d %v", n, ok)
+	}
+	if _, ok := info.Metadata["tokenizer.ggml.tokens"]; ok {
+		t.Fatalf("arrays should be skipped")
+	}
+	if info.Metadata["general.quantized"] != true || info.Metadata["llama.rope.freq_base"] != float32(10000) {
+		t.Fatalf("scalar values not decoded: %v", info.Metadata)
+	}
+	meta := info.ModelMetadata()
+	if meta.Name != "Tiny Llama" || meta.ChatTemplate == "" || meta.Tensors != 2 {
+		t.Fatalf("metadata=%+v", meta)
+	}
+
+	b := (&ggufBuilder{}).kv("general.parameter_count", GGUFTypeUint64, uint64(7e9))
+	if info, err := ParseGGUF(bytes.NewReader(b.bytes())); err != nil || info.ParameterCount() != 7e9 {
+		t.Fatalf("parameter_count: %v %d", err, info.ParameterCount())
+	}
+}
+
+func TestParseGGUF_Errors(t *testing.T) {
+	cases := map[string][]byte{
+		"empty":     nil,
+		"bad magic": []byte("GGML\x03\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00"),
+		"truncated": llamaHeader().bytes()[:60],
+	}
+	v1 := llamaHeader().bytes()
+	v1[4] = 1
+	cases["version 1"] = v1
+	// Counts and lengths at the limits with no data behind them must fail
+	// on the short read rather than allocate up front.
+	hugeCounts := llamaHeader().bytes()[:24]
+	binary.LittleEndian.PutUint64(hugeCounts[8:], maxGGUFTensors)
+	binary.LittleEndian.PutUint64(hugeCounts[16:], maxGGUFKVs)
+	cases["huge counts"] = hugeCounts
+	longKey := append(llamaHeader().bytes()[:24], make([]byte, 8)...)
+	binary.LittleEndian.PutUint64(longKey[24:], maxGGUFString)
+	cases["long string"] = append(longKey, "abc"...)
+	for name, data := range cases {
+		if _, err := ParseGGUF(bytes.NewReader(data)); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestScan_FillsMetadataFromHeader(t *testing.T) {
+	dir := t.TempDir()
+	llamaHeader().write(t, filepath.Join(dir, "tiny.gguf"))
+	if err := os.WriteFile(filepath.Join(dir, "empty.gguf"), nil, 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	models, err := NewGGUFScanner().Scan(dir)
+	if err != nil || len(models) != 2 {
+		t.Fatalf("scan: %v %+v", err, models)
+	}
+	for _, m := range models {
+		switch m.ID {
+		case "tiny.gguf":
+			if m.Name != "Tiny Llama" || m.Quant != "Q4_K_M" || m.Family != "llama" || m.GGUF == nil || m.GGUF.Layers != 2 {
+				t.Fatalf("unexpected model: %+v", m)
+			}
+		case "empty.gguf":
+			if m.GGUF != nil || m.Name != "empty.gguf" {
+				t.Fatalf("unparseable file should keep defaults: %+v", m)
+			}
+		}
+	}
+
+	// Manifest values win over the header.
+	mf := filepath.Join(dir, "m.yaml")
+	writeFile(t, mf, "models:\n  - {id: tiny, name: Mine, quant: custom, family: mine, path: tiny.gguf}\n")
+	models, err = NewManifestScanner(mf).Scan(dir)
+	if err != nil {
+		t.Fatalf("manifest scan: %v", err)
+	}
+	if m := models[0]; m.Name != "Mine" || m.Quant != "custom" || m.Family != "mine" || m.GGUF == nil || !strings.Contains(m.GGUF.ChatTemplate, "im_start") {
+		t.Fatalf("unexpected manifest model: %+v", m)
+	}
+}
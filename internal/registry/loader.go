@@ -2,9 +2,12 @@ package registry
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"modeld/internal/common/fsutil"
 	"modeld/pkg/types"
@@ -15,41 +18,277 @@ type Scanner interface {
 	Scan(dir string) ([]types.Model, error)
 }
 
-// GGUFScanner scans a directory for *.gguf files and builds a model list.
-type GGUFScanner struct{}
+// MultiDirScanner scans several model directories into one registry and
+// reports the files it dropped or renamed along the way.
+type MultiDirScanner interface {
+	Scanner
+	ScanDirs(dirs []string) ([]types.Model, error)
+	// Duplicates returns the duplicates found by the last scan.
+	Duplicates() []Duplicate
+}
+
+// ScanOptions controls directory discovery.
+type ScanOptions struct {
+	// Recursive descends into subdirectories (hidden ones are skipped).
+	Recursive bool
+	// Include and Exclude are glob patterns matched against the slash-separated
+	// path relative to the scanned directory; a pattern without a slash
+	// matches the base name, and "**" matches any number of directories. A
+	// file is listed if it matches some Include pattern (or none are set) and
+	// no Exclude pattern. Excluded directories are not descended into.
+	Include []string
+	Exclude []string
+	// FollowSymlinks descends into symlinked directories. Symlinked files are
+	// always listed.
+	FollowSymlinks bool
+}
+
+// Duplicate reasons.
+const (
+	DuplicateSameFile    = "same_file"    // the file was already listed under another ID; dropped
+	DuplicateIDCollision = "id_collision" // the ID was taken; listed under ID or dropped if ID is empty
+)
+
+// Duplicate describes a discovered file that collided with an earlier model.
+type Duplicate struct {
+	ID     string `json:"id,omitempty"`
+	Path   string `json:"path"`
+	Of     string `json:"of"`
+	Reason string `json:"reason"`
+}
 
-// NewGGUFScanner returns a scanner that discovers local GGUF files.
+func (d Duplicate) String() string {
+	if d.ID == "" {
+		return fmt.Sprintf("%s: %s of %q, skipped", d.Path, d.Reason, d.Of)
+	}
+	return fmt.Sprintf("%s: %s of %q, listed as %q", d.Path, d.Reason, d.Of, d.ID)
+}
+
+// GGUFScanner scans directories for *.gguf files and builds a model list,
+// filling metadata from each file's GGUF header. A model's ID is its path
+// relative to the scanned directory, so a flat directory yields file names.
+// The shards of a split model (name-00001-of-00003.gguf, ...) are listed
+// as one model, name.gguf.
+type GGUFScanner struct {
+	opts ScanOptions
+
+	mu   sync.Mutex
+	dups []Duplicate
+}
+
+// NewGGUFScanner returns a scanner that lists the GGUF files directly inside
+// a directory.
 func NewGGUFScanner() *GGUFScanner { return &GGUFScanner{} }
 
+// NewGGUFScannerWithOptions returns a scanner using opts.
+func NewGGUFScannerWithOptions(opts ScanOptions) *GGUFScanner {
+	return &GGUFScanner{opts: opts}
+}
+
 // Scan implements Scanner.
 func (s *GGUFScanner) Scan(dir string) ([]types.Model, error) {
-	base, err := fsutil.ExpandHome(dir)
+	return s.ScanDirs([]string{dir})
+}
+
+// ScanDirs scans dirs in order. A file reached twice (overlapping
+// directories, symlinks) is listed once, under its first ID. When the same
+// relative path exists in two directories, the later file's ID is prefixed
+// with its directory's base name.
+func (s *GGUFScanner) ScanDirs(dirs []string) ([]types.Model, error) {
+	models, dups, err := s.scanDirs(dirs, nil, nil)
 	if err != nil {
 		return nil, err
 	}
-	abs, err := filepath.Abs(base)
-	if err != nil {
-		return nil, fmt.Errorf("abs path: %w", err)
+	s.mu.Lock()
+	s.dups = dups
+	s.mu.Unlock()
+	return models, nil
+}
+
+// Duplicates implements MultiDirScanner.
+func (s *GGUFScanner) Duplicates() []Duplicate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Duplicate(nil), s.dups...)
+}
+
+// scanDirs lists the models in dirs. claimedIDs and claimedFiles map IDs
+// and real file paths already taken by the caller to the owning model ID;
+// files colliding with them are dropped (claimed files silently, since the
+// caller listed them on purpose).
+func (s *GGUFScanner) scanDirs(dirs []string, claimedIDs, claimedFiles map[string]string) ([]types.Model, []Duplicate, error) {
+	var (
+		models []types.Model
+		dups   []Duplicate
+		ids    = map[string]bool{}
+		files  = map[string]string{} // real path -> ID
+	)
+	for _, dir := range dirs {
+		root, err := fsutil.ExpandHome(dir)
+		if err != nil {
+			return nil, nil, err
+		}
+		root, err = filepath.Abs(root)
+		if err != nil {
+			return nil, nil, fmt.Errorf("abs path: %w", err)
+		}
+		found, err := s.walk(root)
+		if err != nil {
+			return nil, nil, err
+		}
+		found = groupShards(found)
+		for _, f := range found {
+			real := realPath(f.path)
+			if _, ok := claimedFiles[real]; ok {
+				continue
+			}
+			if of, ok := files[real]; ok {
+				dups = append(dups, Duplicate{Path: f.path, Of: of, Reason: DuplicateSameFile})
+				continue
+			}
+			id := f.rel
+			if of, ok := claimedIDs[id]; ok {
+				dups = append(dups, Duplicate{Path: f.path, Of: of, Reason: DuplicateIDCollision})
+				continue
+			}
+			if ids[id] {
+				id = uniqueID(path.Join(filepath.Base(root), f.rel), func(c string) bool {
+					_, claimed := claimedIDs[c]
+					return ids[c] || claimed
+				})
+				dups = append(dups, Duplicate{ID: id, Path: f.path, Of: f.rel, Reason: DuplicateIDCollision})
+			}
+			ids[id] = true
+			files[real] = id
+			mdl := types.Model{ID: id, Name: id, Path: f.path, Shards: f.shards, ShardCount: f.shardCount}
+			applyGGUF(&mdl)
+			models = append(models, mdl)
+		}
 	}
-	entries, err := os.ReadDir(abs)
-	if err != nil {
-		return nil, fmt.Errorf("read dir: %w", err)
+	return models, dups, nil
+}
+
+// scannedFile is a model file found under a scan root.
+type scannedFile struct {
+	rel  string // slash-separated path relative to the root
+	path string
+	// Split models: the shards found and the declared shard count
+	shards     []string
+	shardCount int
+}
+
+// walk lists the matching GGUF files under root in lexical order. Only an
+// unreadable root is an error; unreadable subdirectories are skipped.
+func (s *GGUFScanner) walk(root string) ([]scannedFile, error) {
+	var out []scannedFile
+	visited := map[string]bool{}
+	var walkDir func(dir, rel string) error
+	walkDir = func(dir, rel string) error {
+		real := realPath(dir)
+		if visited[real] {
+			return nil // symlink cycle or directory reached twice
+		}
+		visited[real] = true
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return fmt.Errorf("read dir: %w", err)
+		}
+		for _, e := range entries {
+			name := e.Name()
+			p := filepath.Join(dir, name)
+			r := path.Join(rel, name)
+			isDir := e.IsDir()
+			if e.Type()&fs.ModeSymlink != 0 {
+				fi, err := os.Stat(p)
+				if err != nil {
+					continue // dangling link
+				}
+				if fi.IsDir() && !s.opts.FollowSymlinks {
+					continue
+				}
+				isDir = fi.IsDir()
+			}
+			if isDir {
+				if s.opts.Recursive && !strings.HasPrefix(name, ".") && !matchAny(s.opts.Exclude, r) {
+					_ = walkDir(p, r)
+				}
+				continue
+			}
+			if !isGGUF(name) || matchAny(s.opts.Exclude, r) {
+				continue
+			}
+			if len(s.opts.Include) > 0 && !matchAny(s.opts.Include, r) {
+				continue
+			}
+			out = append(out, scannedFile{rel: r, path: p})
+		}
+		return nil
+	}
+	return out, walkDir(root, "")
+}
+
+// uniqueID returns id, or id with the smallest "-N" suffix (before the
+// extension) that is not taken.
+func uniqueID(id string, taken func(string) bool) string {
+	if !taken(id) {
+		return id
 	}
-	var models []types.Model
-	for _, e := range entries {
-		if e.IsDir() {
-			continue
+	ext := path.Ext(id)
+	stem := strings.TrimSuffix(id, ext)
+	for n := 2; ; n++ {
+		if c := fmt.Sprintf("%s-%d%s", stem, n, ext); !taken(c) {
+			return c
 		}
-		name := e.Name()
-		if !isGGUF(name) {
-			continue
+	}
+}
+
+// realPath resolves symlinks, falling back to the cleaned path.
+func realPath(p string) string {
+	if r, err := filepath.EvalSymlinks(p); err == nil {
+		return r
+	}
+	return filepath.Clean(p)
+}
+
+func matchAny(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		if matchGlob(p, rel) {
+			return true
 		}
-		// Use full filename as ID (e.g., "llama-3.1-8b-q4_k_m.gguf")
-		id := name
-		p := filepath.Join(abs, name)
-		models = append(models, types.Model{ID: id, Name: id, Path: p})
 	}
-	return models, nil
+	return false
+}
+
+// matchGlob matches a slash-separated relative path against pattern (see
+// ScanOptions). Malformed patterns match nothing.
+func matchGlob(pattern, rel string) bool {
+	pattern = strings.Trim(filepath.ToSlash(pattern), "/")
+	if !strings.Contains(pattern, "/") && pattern != "**" {
+		ok, _ := path.Match(pattern, path.Base(rel))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(rel, "/"))
+}
+
+func matchSegments(pat, parts []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(pat[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], parts[0]); !ok {
+			return false
+		}
+		pat, parts = pat[1:], parts[1:]
+	}
+	return len(parts) == 0
 }
 
 // Deprecated: Use NewGGUFScanner().Scan(dir) instead.
@@ -4,8 +4,11 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"testing"
+
+	"modeld/pkg/types"
 )
 
 func TestGGUFScanner_ScanFiltersGGUF(t *testing.T) {
@@ -101,3 +104,175 @@ func TestLoadDirWrapper(t *testing.T) {
 		t.Fatalf("unexpected: %+v", models)
 	}
 }
+
+func touch(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(""), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+func modelIDs(models []types.Model) []string {
+	ids := make([]string, len(models))
+	for i, m := range models {
+		ids[i] = m.ID
+	}
+	return ids
+}
+
+func TestGGUFScanner_RecursiveRelativeIDs(t *testing.T) {
+	dir := t.TempDir()
+	touch(t, filepath.Join(dir, "top.gguf"))
+	touch(t, filepath.Join(dir, "meta", "llama-3", "q4.gguf"))
+	touch(t, filepath.Join(dir, "qwen", "q4.gguf"))
+	touch(t, filepath.Join(dir, ".cache", "hidden.gguf"))
+
+	flat, err := NewGGUFScanner().Scan(dir)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if got := modelIDs(flat); !slices.Equal(got, []string{"top.gguf"}) {
+		t.Fatalf("flat scan = %v", got)
+	}
+
+	models, err := NewGGUFScannerWithOptions(ScanOptions{Recursive: true}).Scan(dir)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	want := []string{"meta/llama-3/q4.gguf", "qwen/q4.gguf", "top.gguf"}
+	if got := modelIDs(models); !slices.Equal(got, want) {
+		t.Fatalf("recursive scan = %v, want %v", got, want)
+	}
+	if models[0].Path != filepath.Join(dir, "meta", "llama-3", "q4.gguf") {
+		t.Fatalf("path = %s", models[0].Path)
+	}
+}
+
+func TestGGUFScanner_IncludeExclude(t *testing.T) {
+	dir := t.TempDir()
+	touch(t, filepath.Join(dir, "meta", "a-q4.gguf"))
+	touch(t, filepath.Join(dir, "meta", "a-f32.gguf"))
+	touch(t, filepath.Join(dir, "meta", "archive", "old.gguf"))
+	touch(t, filepath.Join(dir, "qwen", "b-q4.gguf"))
+
+	s := NewGGUFScannerWithOptions(ScanOptions{
+		Recursive: true,
+		Include:   []string{"meta/**"},
+		Exclude:   []string{"archive", "*-f32.gguf"},
+	})
+	models, err := s.Scan(dir)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if got := modelIDs(models); !slices.Equal(got, []string{"meta/a-q4.gguf"}) {
+		t.Fatalf("scan = %v", got)
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	cases := []struct {
+		pattern, rel string
+		want         bool
+	}{
+		{"*.gguf", "a/b/c.gguf", true},
+		{"a/*.gguf", "a/c.gguf", true},
+		{"a/*.gguf", "a/b/c.gguf", false},
+		{"a/**/*.gguf", "a/c.gguf", true},
+		{"a/**/*.gguf", "a/b/d/c.gguf", true},
+		{"**/archive/**", "x/archive/y.gguf", true},
+		{"**", "anything/at/all", true},
+		{"b/**", "a/b/c", false},
+		{"[", "x", false},
+	}
+	for _, c := range cases {
+		if got := matchGlob(c.pattern, c.rel); got != c.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", c.pattern, c.rel, got, c.want)
+		}
+	}
+}
+
+func TestGGUFScanner_MultipleDirsAndDuplicates(t *testing.T) {
+	disk1, disk2 := t.TempDir(), t.TempDir()
+	touch(t, filepath.Join(disk1, "meta", "m.gguf"))
+	touch(t, filepath.Join(disk2, "meta", "m.gguf"))
+	touch(t, filepath.Join(disk2, "other.gguf"))
+
+	s := NewGGUFScannerWithOptions(ScanOptions{Recursive: true})
+	models, err := s.ScanDirs([]string{disk1, disk2, disk1})
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	second := filepath.Base(disk2) + "/meta/m.gguf"
+	want := []string{"meta/m.gguf", second, "other.gguf"}
+	if got := modelIDs(models); !slices.Equal(got, want) {
+		t.Fatalf("ids = %v, want %v", got, want)
+	}
+	dups := s.Duplicates()
+	if len(dups) != 2 {
+		t.Fatalf("duplicates = %+v", dups)
+	}
+	if dups[0].Reason != DuplicateIDCollision || dups[0].ID != second || dups[0].Of != "meta/m.gguf" {
+		t.Fatalf("collision = %+v", dups[0])
+	}
+	// disk1 listed twice: its file is the same file as the first listing.
+	if dups[1].Reason != DuplicateSameFile || dups[1].ID != "" || dups[1].Of != "meta/m.gguf" {
+		t.Fatalf("same file = %+v", dups[1])
+	}
+
+	// Rescanning is deterministic and resets the report.
+	again, err := s.ScanDirs([]string{disk1, disk2})
+	if err != nil {
+		t.Fatalf("rescan: %v", err)
+	}
+	if got := modelIDs(again); !slices.Equal(got, want) {
+		t.Fatalf("rescan ids = %v", got)
+	}
+	if len(s.Duplicates()) != 1 {
+		t.Fatalf("rescan duplicates = %+v", s.Duplicates())
+	}
+}
+
+func TestGGUFScanner_Symlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges on windows")
+	}
+	dir, other := t.TempDir(), t.TempDir()
+	touch(t, filepath.Join(dir, "real", "m.gguf"))
+	touch(t, filepath.Join(other, "x.gguf"))
+	if err := os.Symlink(filepath.Join(dir, "real", "m.gguf"), filepath.Join(dir, "link.gguf")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+	if err := os.Symlink(other, filepath.Join(dir, "linked-dir")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+	// A cycle must not hang the scan.
+	if err := os.Symlink(dir, filepath.Join(dir, "real", "loop")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+
+	s := NewGGUFScannerWithOptions(ScanOptions{Recursive: true})
+	models, err := s.Scan(dir)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	// The symlinked file sorts first and claims the file; the real path is
+	// reported as a duplicate of it.
+	if got := modelIDs(models); !slices.Equal(got, []string{"link.gguf"}) {
+		t.Fatalf("ids = %v", got)
+	}
+	if d := s.Duplicates(); len(d) != 1 || d[0].Reason != DuplicateSameFile || d[0].Of != "link.gguf" {
+		t.Fatalf("duplicates = %+v", d)
+	}
+
+	s = NewGGUFScannerWithOptions(ScanOptions{Recursive: true, FollowSymlinks: true})
+	models, err = s.Scan(dir)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if got := modelIDs(models); !slices.Equal(got, []string{"link.gguf", "linked-dir/x.gguf"}) {
+		t.Fatalf("follow ids = %v", got)
+	}
+}
@@ -0,0 +1,225 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	toml "github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+
+	"modeld/internal/common/fsutil"
+	"modeld/pkg/types"
+)
+
+// Manifest declares models and their metadata. It is read from YAML, JSON
+// or TOML, chosen by file extension as for the config file.
+type Manifest struct {
+	Models []ManifestEntry `json:"models" yaml:"models" toml:"models"`
+}
+
+// ManifestEntry describes one model. Only Path is required; ID defaults to
+// the file name and Name to the ID.
+type ManifestEntry struct {
+	ID           string   `json:"id" yaml:"id" toml:"id"`
+	Aliases      []string `json:"aliases" yaml:"aliases" toml:"aliases"`
+	Name         string   `json:"name" yaml:"name" toml:"name"`
+	Path         string   `json:"path" yaml:"path" toml:"path"`
+	Family       string   `json:"family" yaml:"family" toml:"family"`
+	Quant        string   `json:"quant" yaml:"quant" toml:"quant"`
+	ContextSize  int      `json:"context_size" yaml:"context_size" toml:"context_size"`
+	LlamaArgs    []string `json:"llama_args" yaml:"llama_args" toml:"llama_args"`
+	VRAMMB       int      `json:"vram_mb" yaml:"vram_mb" toml:"vram_mb"`
+	ChatTemplate string   `json:"chat_template" yaml:"chat_template" toml:"chat_template"`
+	// Profile holds llama-server settings for spawn mode.
+	Profile *types.RuntimeProfile `json:"profile" yaml:"profile" toml:"profile"`
+}
+
+// LoadManifest reads a manifest file based on its extension.
+// Supports: .yaml/.yml, .json, .toml
+func LoadManifest(path string) (Manifest, error) {
+	var mf Manifest
+	p, err := fsutil.ExpandHome(path)
+	if err != nil {
+		return mf, err
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return mf, fmt.Errorf("read manifest: %w", err)
+	}
+	switch ext := strings.ToLower(filepath.Ext(p)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &mf)
+	case ".json":
+		err = json.Unmarshal(b, &mf)
+	case ".toml":
+		err = toml.Unmarshal(b, &mf)
+	default:
+		return mf, fmt.Errorf("unsupported manifest extension: %s", ext)
+	}
+	if err != nil {
+		return mf, fmt.Errorf("parse manifest %s: %w", p, err)
+	}
+	return mf, nil
+}
+
+// ManifestScanner builds the model list from a manifest merged with a GGUF
+// directory scan. Manifest entries come first and win: scanned files that a
+// manifest entry points at are not listed again, and scanned files whose
+// ID collides with a manifest ID or alias are dropped.
+type ManifestScanner struct {
+	path  string
+	files *GGUFScanner
+}
+
+// NewManifestScanner returns a scanner reading the manifest at path.
+func NewManifestScanner(path string) *ManifestScanner {
+	return NewManifestScannerWithOptions(path, ScanOptions{})
+}
+
+// NewManifestScannerWithOptions returns a scanner reading the manifest at
+// path and discovering directory files with opts.
+func NewManifestScannerWithOptions(path string, opts ScanOptions) *ManifestScanner {
+	return &ManifestScanner{path: path, files: NewGGUFScannerWithOptions(opts)}
+}
+
+// Scan implements Scanner. Relative manifest paths resolve against dir, or
+// against the manifest's directory when dir is empty; an empty dir also
+// skips the directory scan.
+func (s *ManifestScanner) Scan(dir string) ([]types.Model, error) {
+	if dir == "" {
+		return s.ScanDirs(nil)
+	}
+	return s.ScanDirs([]string{dir})
+}
+
+// ScanDirs implements MultiDirScanner. Relative manifest paths resolve
+// against the first directory, or against the manifest's directory when
+// dirs is empty.
+func (s *ManifestScanner) ScanDirs(dirs []string) ([]types.Model, error) {
+	mf, err := LoadManifest(s.path)
+	if err != nil {
+		return nil, err
+	}
+	var base string
+	if len(dirs) > 0 {
+		base = dirs[0]
+	} else {
+		p, err := fsutil.ExpandHome(s.path)
+		if err != nil {
+			return nil, err
+		}
+		base = filepath.Dir(p)
+	}
+	base, err = fsutil.ExpandHome(base)
+	if err != nil {
+		return nil, err
+	}
+	base, err = filepath.Abs(base)
+	if err != nil {
+		return nil, fmt.Errorf("abs path: %w", err)
+	}
+
+	var models []types.Model
+	names := map[string]string{} // ID or alias -> owning model ID
+	files := map[string]string{} // real path -> model ID
+	claim := func(name, owner string) error {
+		if prev, ok := names[name]; ok {
+			return fmt.Errorf("manifest: %q used by both %q and %q", name, prev, owner)
+		}
+		names[name] = owner
+		return nil
+	}
+	for i, e := range mf.Models {
+		mdl, err := e.model(base)
+		if err != nil {
+			return nil, fmt.Errorf("manifest entry %d: %w", i, err)
+		}
+		if err := claim(mdl.ID, mdl.ID); err != nil {
+			return nil, err
+		}
+		for _, a := range mdl.Aliases {
+			if err := claim(a, mdl.ID); err != nil {
+				return nil, err
+			}
+		}
+		applyGGUF(&mdl)
+		files[realPath(mdl.Path)] = mdl.ID
+		models = append(models, mdl)
+	}
+
+	scanned, dups, err := s.files.scanDirs(dirs, names, files)
+	if err != nil {
+		return nil, err
+	}
+	s.files.mu.Lock()
+	s.files.dups = dups
+	s.files.mu.Unlock()
+	return append(models, scanned...), nil
+}
+
+// Duplicates implements MultiDirScanner.
+func (s *ManifestScanner) Duplicates() []Duplicate { return s.files.Duplicates() }
+
+// model converts the entry, resolving a relative path against base.
+func (e ManifestEntry) model(base string) (types.Model, error) {
+	if strings.TrimSpace(e.Path) == "" {
+		return types.Model{}, fmt.Errorf("missing path")
+	}
+	p, err := fsutil.ExpandHome(e.Path)
+	if err != nil {
+		return types.Model{}, err
+	}
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(base, p)
+	}
+	mdl := types.Model{
+		ID:           strings.TrimSpace(e.ID),
+		Name:         e.Name,
+		Path:         filepath.Clean(p),
+		Quant:        e.Quant,
+		Family:       e.Family,
+		ChatTemplate: e.ChatTemplate,
+		ContextSize:  e.ContextSize,
+		LlamaArgs:    append([]string(nil), e.LlamaArgs...),
+		VRAMMB:       e.VRAMMB,
+	}
+	if shards, count := siblingShards(mdl.Path); count > 0 {
+		// A split model may be named by any of its shards; load from the first.
+		mdl.Shards, mdl.ShardCount = shards, count
+		base, _, _, _ := ParseShardName(filepath.Base(mdl.Path))
+		mdl.Path = filepath.Join(filepath.Dir(mdl.Path), shardFileName(base, 1, count))
+		if len(shards) > 0 {
+			if _, i, _, _ := ParseShardName(filepath.Base(shards[0])); i == 1 {
+				mdl.Path = shards[0]
+			}
+		}
+	}
+	if mdl.ID == "" {
+		mdl.ID = filepath.Base(mdl.Path)
+		if base, _, _, ok := ParseShardName(mdl.ID); ok {
+			mdl.ID = base + ".gguf"
+		}
+	}
+	if mdl.Name == "" {
+		mdl.Name = mdl.ID
+	}
+	for _, a := range e.Aliases {
+		if a = strings.TrimSpace(a); a != "" && a != mdl.ID {
+			mdl.Aliases = append(mdl.Aliases, a)
+		}
+	}
+	if mdl.ContextSize < 0 || mdl.VRAMMB < 0 {
+		return types.Model{}, fmt.Errorf("model %q: context_size and vram_mb must not be negative", mdl.ID)
+	}
+	if e.Profile != nil {
+		if err := ValidateProfile(*e.Profile); err != nil {
+			return types.Model{}, fmt.Errorf("model %q: profile: %w", mdl.ID, err)
+		}
+		p := *e.Profile
+		mdl.Profile = &p
+	}
+	return mdl, nil
+}
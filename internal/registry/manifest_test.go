@@ -0,0 +1,110 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func TestManifestScanner_MergesWithDirectory(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"llama-2-7b.Q4_K_M.gguf", "extra.gguf", "shadowed.gguf"} {
+		writeFile(t, filepath.Join(dir, f), "")
+	}
+	mf := filepath.Join(t.TempDir(), "models.yaml")
+	writeFile(t, mf, `
+models:
+  - id: llama-2-7b-q4
+    aliases: [llama2]
+    name: Llama 2 7B (Q4_K_M)
+    path: llama-2-7b.Q4_K_M.gguf
+    family: llama
+    quant: Q4_K_M
+    context_size: 4096
+    llama_args: ["--rope-freq-base", "10000"]
+    vram_mb: 4500
+    profile:
+      ngl: 0
+      flash_attn: "on"
+      env: {CUDA_VISIBLE_DEVICES: "1"}
+  - id: other
+    aliases: [shadowed.gguf]
+    path: /elsewhere/other.gguf
+`)
+	models, err := NewManifestScanner(mf).Scan(dir)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	var ids []string
+	for _, m := range models {
+		ids = append(ids, m.ID)
+	}
+	if got := strings.Join(ids, ","); got != "llama-2-7b-q4,other,extra.gguf" {
+		t.Fatalf("ids=%s", got)
+	}
+	m := models[0]
+	if m.Path != filepath.Join(dir, "llama-2-7b.Q4_K_M.gguf") || m.Family != "llama" || m.Quant != "Q4_K_M" ||
+		m.ContextSize != 4096 || m.VRAMMB != 4500 || len(m.LlamaArgs) != 2 || len(m.Aliases) != 1 || m.Name != "Llama 2 7B (Q4_K_M)" {
+		t.Fatalf("unexpected model: %+v", m)
+	}
+	if p := m.Profile; p == nil || p.GPULayers == nil || *p.GPULayers != 0 || p.FlashAttn != "on" || p.Env["CUDA_VISIBLE_DEVICES"] != "1" {
+		t.Fatalf("unexpected profile: %+v", m.Profile)
+	}
+	if models[1].Name != "other" || models[1].Path != "/elsewhere/other.gguf" || models[1].Profile != nil {
+		t.Fatalf("expected defaults for second entry: %+v", models[1])
+	}
+}
+
+func TestManifestScanner_FormatsAndDefaults(t *testing.T) {
+	dir := t.TempDir()
+	js := filepath.Join(dir, "m.json")
+	writeFile(t, js, `{"models":[{"path":"a.gguf","quant":"Q8_0"}]}`)
+	// Without a models dir, paths resolve against the manifest directory.
+	models, err := NewManifestScanner(js).Scan("")
+	if err != nil || len(models) != 1 {
+		t.Fatalf("json scan: %v %+v", err, models)
+	}
+	if models[0].ID != "a.gguf" || models[0].Path != filepath.Join(dir, "a.gguf") {
+		t.Fatalf("unexpected defaults: %+v", models[0])
+	}
+
+	tm := filepath.Join(dir, "m.toml")
+	writeFile(t, tm, "[[models]]\nid = \"b\"\npath = \"b.gguf\"\ncontext_size = 2048\n[models.profile]\nthreads = 8\nrope_scaling = \"yarn\"\n")
+	models, err = NewManifestScanner(tm).Scan("")
+	if err != nil || len(models) != 1 || models[0].ContextSize != 2048 {
+		t.Fatalf("toml scan: %v %+v", err, models)
+	}
+	if p := models[0].Profile; p == nil || p.Threads != 8 || p.RopeScaling != "yarn" {
+		t.Fatalf("toml profile: %+v", p)
+	}
+}
+
+func TestManifestScanner_Errors(t *testing.T) {
+	dir := t.TempDir()
+	cases := map[string]string{
+		"missing path":    "models:\n  - id: a\n",
+		"duplicate alias": "models:\n  - {id: a, path: a.gguf}\n  - {id: b, path: b.gguf, aliases: [a]}\n",
+		"bad flash_attn":  "models:\n  - {id: a, path: a.gguf, profile: {flash_attn: yes}}\n",
+		"negative ngl":    "models:\n  - {id: a, path: a.gguf, profile: {ngl: -1}}\n",
+	}
+	for name, body := range cases {
+		mf := filepath.Join(dir, "m.yaml")
+		writeFile(t, mf, body)
+		if _, err := NewManifestScanner(mf).Scan(""); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+	bad := filepath.Join(dir, "m.ini")
+	writeFile(t, bad, "")
+	if _, err := NewManifestScanner(bad).Scan(""); err == nil {
+		t.Fatalf("expected unsupported extension error")
+	}
+}
@@ -0,0 +1,38 @@
+package registry
+
+import (
+	"fmt"
+	"strings"
+
+	"modeld/pkg/types"
+)
+
+// ValidateProfile checks a runtime profile for values llama-server would
+// reject, so a typo fails at load time rather than at spawn.
+func ValidateProfile(p types.RuntimeProfile) error {
+	if p.ContextSize < 0 || p.Threads < 0 || p.BatchSize < 0 || p.UBatchSize < 0 {
+		return fmt.Errorf("ctx_size, threads, batch_size and ubatch_size must not be negative")
+	}
+	if p.GPULayers != nil && *p.GPULayers < 0 {
+		return fmt.Errorf("ngl must not be negative")
+	}
+	if p.RopeFreqBase < 0 || p.RopeFreqScale < 0 {
+		return fmt.Errorf("rope_freq_base and rope_freq_scale must not be negative")
+	}
+	switch p.RopeScaling {
+	case "", "none", "linear", "yarn":
+	default:
+		return fmt.Errorf("rope_scaling %q: want none, linear or yarn", p.RopeScaling)
+	}
+	switch p.FlashAttn {
+	case "", "on", "off", "auto":
+	default:
+		return fmt.Errorf("flash_attn %q: want on, off or auto", p.FlashAttn)
+	}
+	for k := range p.Env {
+		if k == "" || strings.ContainsAny(k, "=\x00") {
+			return fmt.Errorf("env: invalid variable name %q", k)
+		}
+	}
+	return nil
+}
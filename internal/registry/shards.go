@@ -0,0 +1,147 @@
+package registry
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"modeld/pkg/types"
+)
+
+// shardName matches llama.cpp split files: <base>-00001-of-00003.gguf.
+var shardName = regexp.MustCompile(`(?i)^(.+)-(\d{5})-of-(\d{5})\.gguf$`)
+
+// ParseShardName splits a shard file name into its base name, 1-based index
+// and the number of shards in the set. ok is false for other files.
+func ParseShardName(name string) (base string, index, count int, ok bool) {
+	sm := shardName.FindStringSubmatch(name)
+	if sm == nil {
+		return "", 0, 0, false
+	}
+	index, _ = strconv.Atoi(sm[2])
+	count, _ = strconv.Atoi(sm[3])
+	if index < 1 || count < 1 || index > count {
+		return "", 0, 0, false
+	}
+	return sm[1], index, count, true
+}
+
+// shardFileName returns the file name of shard index of a set.
+func shardFileName(base string, index, count int) string {
+	return fmt.Sprintf("%s-%05d-of-%05d.gguf", base, index, count)
+}
+
+// MissingShards returns the file names of the shards of a split model that
+// were not found; nil for complete sets and single-file models.
+func MissingShards(mdl types.Model) []string {
+	if mdl.ShardCount == 0 || len(mdl.Shards) >= mdl.ShardCount {
+		return nil
+	}
+	base, _, _, ok := ParseShardName(filepath.Base(mdl.Path))
+	if !ok {
+		return nil
+	}
+	have := map[int]bool{}
+	for _, s := range mdl.Shards {
+		if _, i, _, ok := ParseShardName(filepath.Base(s)); ok {
+			have[i] = true
+		}
+	}
+	var missing []string
+	for i := 1; i <= mdl.ShardCount; i++ {
+		if !have[i] {
+			missing = append(missing, shardFileName(base, i, mdl.ShardCount))
+		}
+	}
+	return missing
+}
+
+// groupShards folds the shards of each split model in found into one entry
+// at the position of its first shard. The entry's rel drops the shard
+// suffix (model-00001-of-00003.gguf lists as model.gguf) and its path is the
+// first shard, which llama.cpp loads the others from.
+func groupShards(found []scannedFile) []scannedFile {
+	type setKey struct {
+		dir, base string
+		count     int
+	}
+	sets := map[setKey]int{} // -> index in out
+	out := make([]scannedFile, 0, len(found))
+	for _, f := range found {
+		base, idx, count, ok := ParseShardName(path.Base(f.rel))
+		if !ok {
+			out = append(out, f)
+			continue
+		}
+		k := setKey{path.Dir(f.rel), base, count}
+		i, seen := sets[k]
+		if !seen {
+			i = len(out)
+			sets[k] = i
+			dir := filepath.Dir(f.path)
+			out = append(out, scannedFile{
+				rel:        path.Join(k.dir, base+".gguf"),
+				path:       filepath.Join(dir, shardFileName(base, 1, count)),
+				shardCount: count,
+			})
+		}
+		if idx == 1 {
+			out[i].path = f.path // keep the file name's case
+		}
+		out[i].shards = append(out[i].shards, f.path)
+	}
+	for i := range out {
+		if out[i].shardCount > 0 {
+			slices.SortFunc(out[i].shards, func(a, b string) int {
+				_, ia, _, _ := ParseShardName(filepath.Base(a))
+				_, ib, _, _ := ParseShardName(filepath.Base(b))
+				return ia - ib
+			})
+		}
+	}
+	return out
+}
+
+// siblingShards lists the shard set that the file at p belongs to, in
+// index order. count is 0 when p is not a shard.
+func siblingShards(p string) (shards []string, count int) {
+	base, idx, count, ok := ParseShardName(filepath.Base(p))
+	if !ok {
+		return nil, 0
+	}
+	dir := filepath.Dir(p)
+	for i := 1; i <= count; i++ {
+		sp := filepath.Join(dir, shardFileName(base, i, count))
+		if i == idx {
+			sp = p
+		}
+		if _, err := os.Stat(sp); err == nil {
+			shards = append(shards, sp)
+		}
+	}
+	return shards, count
+}
+
+// ReadGGUFShards parses a split model: metadata comes from the first shard
+// and the tensors of all shards are combined.
+func ReadGGUFShards(paths []string) (GGUFInfo, error) {
+	if len(paths) == 0 {
+		return GGUFInfo{}, os.ErrNotExist
+	}
+	info, err := ReadGGUF(paths[0])
+	if err != nil {
+		return GGUFInfo{}, err
+	}
+	for _, p := range paths[1:] {
+		s, err := ReadGGUF(p)
+		if err != nil {
+			return GGUFInfo{}, err
+		}
+		info.Tensors = append(info.Tensors, s.Tensors...)
+	}
+	return info, nil
+}
@@ -0,0 +1,110 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParseShardName(t *testing.T) {
+	cases := []struct {
+		name         string
+		base         string
+		index, count int
+		ok           bool
+	}{
+		{"llama-70b-q4-00001-of-00003.gguf", "llama-70b-q4", 1, 3, true},
+		{"M-00003-of-00003.GGUF", "M", 3, 3, true},
+		{"m-00004-of-00003.gguf", "", 0, 0, false},
+		{"m-00000-of-00003.gguf", "", 0, 0, false},
+		{"m-1-of-3.gguf", "", 0, 0, false},
+		{"m.gguf", "", 0, 0, false},
+	}
+	for _, c := range cases {
+		base, idx, count, ok := ParseShardName(c.name)
+		if base != c.base || idx != c.index || count != c.count || ok != c.ok {
+			t.Errorf("ParseShardName(%q) = %q %d %d %v", c.name, base, idx, count, ok)
+		}
+	}
+}
+
+// writeShards writes a three-shard model: metadata and two tensors in the
+// first shard, one tensor in each of the others.
+func writeShards(t *testing.T, dir string) []string {
+	t.Helper()
+	paths := []string{
+		filepath.Join(dir, "big-00001-of-00003.gguf"),
+		filepath.Join(dir, "big-00002-of-00003.gguf"),
+		filepath.Join(dir, "big-00003-of-00003.gguf"),
+	}
+	llamaHeader().write(t, paths[0])
+	(&ggufBuilder{}).tensor("blk.1.attn_q.weight", 12, 64, 64).write(t, paths[1])
+	(&ggufBuilder{}).tensor("output.weight", 12, 64, 100).write(t, paths[2])
+	return paths
+}
+
+func TestScan_GroupsShards(t *testing.T) {
+	dir := t.TempDir()
+	shards := writeShards(t, dir)
+	llamaHeader().write(t, filepath.Join(dir, "small.gguf"))
+
+	models, err := NewGGUFScanner().Scan(dir)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if got := modelIDs(models); !slices.Equal(got, []string{"big.gguf", "small.gguf"}) {
+		t.Fatalf("ids = %v", got)
+	}
+	big := models[0]
+	if big.Path != shards[0] || !slices.Equal(big.Shards, shards) || big.ShardCount != 3 {
+		t.Fatalf("split model = %+v", big)
+	}
+	if big.GGUF == nil || big.GGUF.Tensors != 4 || big.GGUF.Parameters != 64*100+64*64+64*64+64*100 {
+		t.Fatalf("metadata should cover all shards: %+v", big.GGUF)
+	}
+	if m := MissingShards(big); m != nil {
+		t.Fatalf("complete set reported missing %v", m)
+	}
+	if models[1].Shards != nil || models[1].ShardCount != 0 {
+		t.Fatalf("single-file model has shards: %+v", models[1])
+	}
+}
+
+func TestScan_IncompleteShardSet(t *testing.T) {
+	dir := t.TempDir()
+	shards := writeShards(t, dir)
+	if err := os.Remove(shards[0]); err != nil {
+		t.Fatal(err)
+	}
+	models, err := NewGGUFScanner().Scan(dir)
+	if err != nil || len(models) != 1 {
+		t.Fatalf("scan: %v %+v", err, models)
+	}
+	mdl := models[0]
+	// Path still names the first shard so the error points at it.
+	if mdl.Path != shards[0] || len(mdl.Shards) != 2 || mdl.ShardCount != 3 {
+		t.Fatalf("incomplete model = %+v", mdl)
+	}
+	if got := MissingShards(mdl); !slices.Equal(got, []string{"big-00001-of-00003.gguf"}) {
+		t.Fatalf("missing = %v", got)
+	}
+}
+
+func TestManifest_ShardedEntry(t *testing.T) {
+	dir := t.TempDir()
+	shards := writeShards(t, dir)
+	mf := filepath.Join(dir, "m.yaml")
+	// Any shard names the set; the model loads from the first.
+	writeFile(t, mf, "models:\n  - {path: big-00002-of-00003.gguf}\n")
+	models, err := NewManifestScanner(mf).Scan(dir)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if len(models) != 1 {
+		t.Fatalf("scanned shards listed again: %v", modelIDs(models))
+	}
+	if m := models[0]; m.ID != "big.gguf" || m.Path != shards[0] || !slices.Equal(m.Shards, shards) || m.ShardCount != 3 {
+		t.Fatalf("manifest model = %+v", m)
+	}
+}
@@ -0,0 +1,109 @@
+package registry
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"modeld/internal/common/fsutil"
+)
+
+// Watcher polls model directories and manifest files and calls onChange
+// when a *.gguf file or manifest is added, removed or modified. Polling
+// needs no platform support and costs one stat per watched file per tick.
+type Watcher struct {
+	paths    []string
+	interval time.Duration
+	onChange func()
+	scanner  *GGUFScanner
+}
+
+// NewWatcher returns a watcher over paths. Directories are walked with the
+// same options as the registry scan, so only files the scan would list are
+// watched; other paths are stat'ed. Empty paths are ignored.
+func NewWatcher(interval time.Duration, opts ScanOptions, onChange func(), paths ...string) *Watcher {
+	w := &Watcher{interval: interval, onChange: onChange, scanner: NewGGUFScannerWithOptions(opts)}
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		if exp, err := fsutil.ExpandHome(p); err == nil {
+			p = exp
+		}
+		w.paths = append(w.paths, p)
+	}
+	return w
+}
+
+// fileStamp identifies a version of a file.
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// snapshot stats every watched file. Missing paths are simply absent.
+func (w *Watcher) snapshot() map[string]fileStamp {
+	snap := make(map[string]fileStamp)
+	add := func(p string, fi os.FileInfo) {
+		snap[p] = fileStamp{size: fi.Size(), modTime: fi.ModTime()}
+	}
+	for _, p := range w.paths {
+		fi, err := os.Stat(p)
+		if err != nil {
+			continue
+		}
+		if !fi.IsDir() {
+			add(p, fi)
+			continue
+		}
+		// An unreadable directory lists what was found before the error.
+		found, _ := w.scanner.walk(p)
+		for _, f := range found {
+			if info, err := os.Stat(f.path); err == nil {
+				add(f.path, info)
+			}
+		}
+	}
+	return snap
+}
+
+// Run polls until ctx is canceled. A change is reported once the files have
+// been stable for one interval, so a model that is still being copied is not
+// picked up half-written.
+func (w *Watcher) Run(ctx context.Context) {
+	if w.interval <= 0 {
+		return
+	}
+	t := time.NewTicker(w.interval)
+	defer t.Stop()
+	last := w.snapshot()
+	pending := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		cur := w.snapshot()
+		if !sameSnapshot(last, cur) {
+			last, pending = cur, true
+			continue
+		}
+		if pending {
+			pending = false
+			w.onChange()
+		}
+	}
+}
+
+func sameSnapshot(a, b map[string]fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for p, s := range a {
+		if t, ok := b[p]; !ok || t.size != s.size || !t.modTime.Equal(s.modTime) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package registry
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatcher_FiresOnceFilesSettle(t *testing.T) {
+	dir := t.TempDir()
+	fired := make(chan struct{}, 4)
+	w := NewWatcher(20*time.Millisecond, ScanOptions{}, func() { fired <- struct{}{} }, dir, "")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx)
+
+	select {
+	case <-fired:
+		t.Fatalf("fired without a change")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "m.gguf"), []byte("GGUF"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-fired:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("watcher did not fire after a model was added")
+	}
+	select {
+	case <-fired:
+		t.Fatalf("fired twice for one change")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if err := os.Remove(filepath.Join(dir, "m.gguf")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-fired:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("watcher did not fire after a model was removed")
+	}
+}
+
+func TestWatcher_AppliesScanOptions(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(rel string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, rel), []byte("GGUF"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("keep.gguf")
+	write("skip.gguf")
+	write("sub/nested.gguf")
+
+	flat := NewWatcher(time.Second, ScanOptions{Exclude: []string{"skip*"}}, func() {}, dir).snapshot()
+	if len(flat) != 1 {
+		t.Fatalf("non-recursive watcher with exclude saw %v", flat)
+	}
+	deep := NewWatcher(time.Second, ScanOptions{Recursive: true, Include: []string{"**/nested.gguf"}}, func() {}, dir).snapshot()
+	if _, ok := deep[filepath.Join(dir, "sub", "nested.gguf")]; !ok || len(deep) != 1 {
+		t.Fatalf("recursive watcher with include saw %v", deep)
+	}
+}
+
+func TestWatcher_DisabledReturns(t *testing.T) {
+	done := make(chan struct{})
+	go func() {
+		NewWatcher(0, ScanOptions{}, func() {}, t.TempDir()).Run(context.Background())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Run with zero interval did not return")
+	}
+}
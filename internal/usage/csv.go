@@ -0,0 +1,36 @@
+package usage
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"modeld/pkg/types"
+)
+
+// csvHeader lists the columns written by WriteCSV.
+var csvHeader = []string{"day", "key_id", "model_id", "requests", "prompt_tokens", "completion_tokens", "generation_ms"}
+
+// WriteCSV writes rows as CSV with a header line.
+func WriteCSV(w io.Writer, rows []types.UsageRow) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		rec := []string{
+			r.Day,
+			r.KeyID,
+			r.ModelID,
+			strconv.FormatInt(r.Requests, 10),
+			strconv.FormatInt(r.PromptTokens, 10),
+			strconv.FormatInt(r.CompletionTokens, 10),
+			strconv.FormatInt(r.GenerationMs, 10),
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,199 @@
+// Package usage keeps an append-only ledger of inference usage (tokens and
+// generation time per API key and model) for charge-back reporting.
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"modeld/internal/common/fsutil"
+	"modeld/pkg/types"
+)
+
+// Record is one inference as stored in the ledger (one JSON object per line).
+type Record struct {
+	Time             time.Time `json:"time"`
+	KeyID            string    `json:"key_id,omitempty"`
+	ModelID          string    `json:"model_id"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	GenerationMs     int64     `json:"generation_ms"`
+}
+
+// Filter selects records for a query. Zero fields match everything; To is
+// exclusive.
+type Filter struct {
+	KeyID   string
+	ModelID string
+	From    time.Time
+	To      time.Time
+}
+
+func (f Filter) match(r Record) bool {
+	switch {
+	case f.KeyID != "" && r.KeyID != f.KeyID:
+		return false
+	case f.ModelID != "" && r.ModelID != f.ModelID:
+		return false
+	case !f.From.IsZero() && r.Time.Before(f.From):
+		return false
+	case !f.To.IsZero() && !r.Time.Before(f.To):
+		return false
+	}
+	return true
+}
+
+// Ledger appends usage records to a JSONL file. It is safe for concurrent use.
+type Ledger struct {
+	path string
+	mu   sync.Mutex
+	f    *os.File
+}
+
+// Open opens (creating if needed) the ledger file at path.
+func Open(path string) (*Ledger, error) {
+	p, err := fsutil.ExpandHome(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return nil, fmt.Errorf("usage ledger dir: %w", err)
+	}
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open usage ledger: %w", err)
+	}
+	return &Ledger{path: p, f: f}, nil
+}
+
+// Path returns the ledger file path.
+func (l *Ledger) Path() string { return l.path }
+
+// Record appends r. A zero Time is set to now.
+func (l *Ledger) Record(r Record) error {
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	r.Time = r.Time.UTC()
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return os.ErrClosed
+	}
+	_, err = l.f.Write(append(b, '\n'))
+	return err
+}
+
+// Close closes the ledger file.
+func (l *Ledger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
+
+// Query aggregates the records matching f per UTC day, key and model.
+func (l *Ledger) Query(f Filter) ([]types.UsageRow, error) {
+	return QueryFile(l.path, f)
+}
+
+// QueryFile aggregates a ledger file without opening it for writing (used by
+// the export command). Malformed lines, such as a partially written last
+// line, are skipped.
+func QueryFile(path string, f Filter) ([]types.UsageRow, error) {
+	p, err := fsutil.ExpandHome(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	type groupKey struct{ day, key, model string }
+	groups := map[groupKey]*types.UsageRow{}
+	sc := bufio.NewScanner(file)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	for sc.Scan() {
+		var r Record
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil || !f.match(r) {
+			continue
+		}
+		k := groupKey{r.Time.UTC().Format(time.DateOnly), r.KeyID, r.ModelID}
+		row := groups[k]
+		if row == nil {
+			row = &types.UsageRow{Day: k.day, KeyID: k.key, ModelID: k.model}
+			groups[k] = row
+		}
+		row.Requests++
+		row.PromptTokens += int64(r.PromptTokens)
+		row.CompletionTokens += int64(r.CompletionTokens)
+		row.GenerationMs += r.GenerationMs
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	rows := make([]types.UsageRow, 0, len(groups))
+	for _, row := range groups {
+		rows = append(rows, *row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if a.Day != b.Day {
+			return a.Day < b.Day
+		}
+		if a.KeyID != b.KeyID {
+			return a.KeyID < b.KeyID
+		}
+		return a.ModelID < b.ModelID
+	})
+	return rows, nil
+}
+
+// ParseTime parses a query bound given as a date (YYYY-MM-DD, UTC midnight)
+// or an RFC 3339 timestamp. Empty returns the zero time.
+func ParseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: want YYYY-MM-DD or RFC 3339", s)
+	}
+	return t, nil
+}
+
+// ParseRange parses from/to query bounds. A date-only "to" is inclusive of
+// that whole day.
+func ParseRange(from, to string) (time.Time, time.Time, error) {
+	f, err := ParseTime(from)
+	if err != nil {
+		return f, time.Time{}, err
+	}
+	t, err := ParseTime(to)
+	if err != nil {
+		return f, t, err
+	}
+	if _, derr := time.Parse(time.DateOnly, to); to != "" && derr == nil {
+		t = t.AddDate(0, 0, 1)
+	}
+	return f, t, nil
+}
@@ -0,0 +1,85 @@
+package usage
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLedger_RecordAndQuery(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "sub", "usage.jsonl")
+	l, err := Open(p)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	day1 := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+	for _, r := range []Record{
+		{Time: day1, KeyID: "a", ModelID: "m1", PromptTokens: 10, CompletionTokens: 5, GenerationMs: 100},
+		{Time: day1.Add(time.Hour), KeyID: "a", ModelID: "m1", PromptTokens: 1, CompletionTokens: 2, GenerationMs: 50},
+		{Time: day1, KeyID: "b", ModelID: "m1", PromptTokens: 7, CompletionTokens: 7, GenerationMs: 70},
+		{Time: day2, KeyID: "a", ModelID: "m2", PromptTokens: 3, CompletionTokens: 4, GenerationMs: 30},
+	} {
+		if err := l.Record(r); err != nil {
+			t.Fatalf("record: %v", err)
+		}
+	}
+	// A torn trailing line is ignored.
+	f, _ := os.OpenFile(p, os.O_WRONLY|os.O_APPEND, 0)
+	_, _ = f.WriteString(`{"time":"2024-05-0`)
+	_ = f.Close()
+
+	rows, err := l.Query(Filter{})
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 rows, got %+v", rows)
+	}
+	if r := rows[0]; r.Day != "2024-05-01" || r.KeyID != "a" || r.Requests != 2 || r.PromptTokens != 11 || r.CompletionTokens != 7 || r.GenerationMs != 150 {
+		t.Fatalf("unexpected first row: %+v", r)
+	}
+
+	from, to, err := ParseRange("2024-05-02", "2024-05-02")
+	if err != nil {
+		t.Fatalf("range: %v", err)
+	}
+	rows, _ = l.Query(Filter{KeyID: "a", From: from, To: to})
+	if len(rows) != 1 || rows[0].ModelID != "m2" {
+		t.Fatalf("unexpected filtered rows: %+v", rows)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, rows); err != nil {
+		t.Fatalf("csv: %v", err)
+	}
+	want := "day,key_id,model_id,requests,prompt_tokens,completion_tokens,generation_ms\n2024-05-02,a,m2,1,3,4,30\n"
+	if buf.String() != want {
+		t.Fatalf("csv=%q", buf.String())
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if err := l.Record(Record{ModelID: "m"}); err == nil {
+		t.Fatalf("expected error after close")
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	if _, err := ParseTime("yesterday"); err == nil || !strings.Contains(err.Error(), "YYYY-MM-DD") {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+	ts, err := ParseTime("2024-05-01T12:00:00Z")
+	if err != nil || ts.Hour() != 12 {
+		t.Fatalf("rfc3339: %v %v", ts, err)
+	}
+	// An RFC 3339 upper bound stays exclusive at that instant.
+	_, to, _ := ParseRange("", "2024-05-01T12:00:00Z")
+	if !to.Equal(ts) {
+		t.Fatalf("to=%v", to)
+	}
+}
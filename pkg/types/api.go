@@ -5,9 +5,12 @@ type InferRequest struct {
 	// Optional model identifier. If empty, the server default is used.
 	// example: tinyllama-q4
 	Model string `json:"model,omitempty" example:"tinyllama-q4"`
-	// Required prompt text to generate a completion for.
+	// Prompt text to generate a completion for. Required unless messages is set.
 	// example: Write a haiku about the ocean.
 	Prompt string `json:"prompt" example:"Write a haiku about the ocean."`
+	// Optional chat messages. When set (and prompt is empty), the server renders
+	// them into a prompt using the chat template of the target model.
+	Messages []ChatMessage `json:"messages,omitempty"`
 	// If true, stream results as NDJSON tokens. When false, the server may still stream internally but buffer.
 	// example: true
 	Stream bool `json:"stream,omitempty" example:"true"`
@@ -32,6 +35,10 @@ type InferRequest struct {
 	// Repeat penalty applied by some llama servers.
 	// example: 1.1
 	RepeatPenalty float64 `json:"repeat_penalty,omitempty" example:"1.1"`
+	// Scheduling priority: interactive, normal (default) or batch. Overrides
+	// the X-Priority header.
+	// example: interactive
+	Priority string `json:"priority,omitempty" example:"interactive"`
 }
 
 // ModelsResponse wraps the list of models returned by GET /models.
@@ -64,21 +71,116 @@ type InstanceStatus struct {
 	// Estimated VRAM usage in MB.
 	// example: 1200
 	EstVRAMMB int `json:"est_vram_mb" example:"1200"`
-	// Current queue length for incoming requests.
+	// Breakdown of the VRAM estimate.
+	VRAM *VRAMEstimate `json:"vram,omitempty"`
+	// Requests waiting for a generation slot.
 	// example: 0
 	QueueLen int `json:"queue_len" example:"0"`
-	// Number of in-flight requests currently being processed.
+	// Waiting requests per priority class (interactive, normal, batch).
+	QueueByPriority map[string]int `json:"queue_by_priority,omitempty"`
+	// Number of in-flight requests currently being processed (slots in use).
 	// example: 1
 	Inflight int `json:"inflight" example:"1"`
-	// Maximum queued requests allowed before backpressure triggers.
-	// example: 32
-	MaxQueueDepth int `json:"max_queue_depth" example:"32"`
+	// Parallel generation slots (maximum concurrent in-flight requests).
+	// example: 4
+	Slots int `json:"slots" example:"4"`
+	// Maximum waiting requests allowed before backpressure triggers (sum of
+	// the per-priority limits).
+	// example: 96
+	MaxQueueDepth int `json:"max_queue_depth" example:"96"`
 	// TCP port used by the managed runtime (when spawn mode is active).
 	// example: 30001
 	Port int `json:"port,omitempty" example:"30001"`
 	// Process ID of the managed runtime (when spawn mode is active).
 	// example: 12345
 	PID int `json:"pid,omitempty" example:"12345"`
+	// Effective llama-server settings the runtime was spawned with (when
+	// spawn mode is active).
+	Profile *RuntimeProfile `json:"profile,omitempty"`
+	// Last runtime crash while the instance is in the error state.
+	// example: signal: killed
+	Error string `json:"error,omitempty" example:"signal: killed"`
+	// Whether a restart after a crash is pending.
+	Restarting bool `json:"restarting,omitempty"`
+	// Times the runtime was restarted after crashing.
+	// example: 1
+	Restarts int `json:"restarts,omitempty" example:"1"`
+	// Whether the runtime was started by a previous modeld and adopted on
+	// startup (spawn mode with a state file).
+	Adopted bool `json:"adopted,omitempty"`
+}
+
+// VRAMEstimate breaks down the estimated VRAM of an instance.
+type VRAMEstimate struct {
+	// Model weights offloaded to the GPU, in MB.
+	// example: 3891
+	WeightsMB int `json:"weights_mb" example:"3891"`
+	// KV cache for the context window, in MB.
+	// example: 2048
+	KVCacheMB int `json:"kv_cache_mb" example:"2048"`
+	// Runtime overhead and per-slot compute buffers, in MB.
+	// example: 320
+	OverheadMB int `json:"overhead_mb" example:"320"`
+	// Sum of the above.
+	// example: 6259
+	TotalMB int `json:"total_mb" example:"6259"`
+	// Where the estimate comes from: gguf (header metadata), manifest
+	// (declared vram_mb) or file_size (fallback).
+	// example: gguf
+	Source string `json:"source" example:"gguf"`
+	// Context size the KV cache was sized for.
+	// example: 4096
+	ContextSize int `json:"context_size,omitempty" example:"4096"`
+	// Offloaded layers out of Layers.
+	// example: 32
+	GPULayers int `json:"gpu_layers,omitempty" example:"32"`
+	// example: 32
+	Layers int `json:"layers,omitempty" example:"32"`
+	// KV cache element types (K/V).
+	// example: f16
+	CacheTypeK string `json:"cache_type_k,omitempty" example:"f16"`
+	// example: f16
+	CacheTypeV string `json:"cache_type_v,omitempty" example:"f16"`
+}
+
+// RegistryReloadResponse is returned by POST /admin/registry/reload.
+type RegistryReloadResponse struct {
+	// Number of models in the new registry.
+	// example: 3
+	Models int `json:"models" example:"3"`
+	// Model IDs that were added.
+	Added []string `json:"added"`
+	// Model IDs that were removed.
+	Removed []string `json:"removed"`
+	// Loaded models being drained and unloaded because their model was
+	// removed or their file disappeared.
+	Unloading []string `json:"unloading"`
+}
+
+// ModelAlias routes a logical model name to one or more registry models.
+// With several targets each request picks one at random by weight.
+type ModelAlias struct {
+	// Logical name clients request.
+	// example: chat-small
+	Name string `json:"name" example:"chat-small"`
+	// Models the alias resolves to.
+	Targets []AliasTarget `json:"targets"`
+}
+
+// AliasTarget is one weighted destination of a ModelAlias.
+type AliasTarget struct {
+	// Registry model ID (or manifest alias).
+	// example: llama-3.2-3b-q4
+	Model string `json:"model" example:"llama-3.2-3b-q4"`
+	// Relative share of requests; when all weights are 0 the targets are
+	// picked evenly.
+	// example: 90
+	Weight int `json:"weight" example:"90"`
+}
+
+// AliasListResponse is returned by GET /admin/aliases.
+type AliasListResponse struct {
+	Aliases []ModelAlias `json:"aliases"`
 }
 
 // StatusResponse is returned by GET /status.
@@ -120,3 +222,109 @@ type StatusResponse struct {
     // example: 1
     DrainingCount int `json:"draining_count" example:"1"`
 }
+
+// OperationStatus describes an asynchronous manager operation (load, unload,
+// switch) returned by the admin API.
+type OperationStatus struct {
+	// Operation ID.
+	// example: op-7
+	ID string `json:"id" example:"op-7"`
+	// Operation kind: load, unload or switch.
+	// example: load
+	Kind string `json:"kind" example:"load"`
+	// Target model ID.
+	// example: tinyllama-q4
+	ModelID string `json:"model_id" example:"tinyllama-q4"`
+	// Current state: pending, running, succeeded, failed or canceled.
+	// example: running
+	State string `json:"state" example:"running"`
+	// Error message when the operation failed or was canceled.
+	Error string `json:"error,omitempty"`
+	// When the operation was created (unix ms).
+	CreatedUnixMs int64 `json:"created_unix_ms" example:"1700000000000"`
+	// When the operation started running (unix ms); 0 while pending.
+	StartedUnixMs int64 `json:"started_unix_ms,omitempty"`
+	// When the operation reached a terminal state (unix ms); 0 until then.
+	FinishedUnixMs int64 `json:"finished_unix_ms,omitempty"`
+}
+
+// SwitchRequest is the payload accepted by POST /admin/switch.
+type SwitchRequest struct {
+	// Model to switch to.
+	// example: tinyllama-q4
+	Model string `json:"model" example:"tinyllama-q4"`
+}
+
+// ManagerEvent is the data of each Server-Sent Event on GET /events.
+type ManagerEvent struct {
+	// Event name, e.g. ensure_start, ensure_ready, unload_done.
+	// example: ensure_ready
+	Name string `json:"name" example:"ensure_ready"`
+	// Model the event refers to, if any.
+	// example: tinyllama-q4
+	ModelID string `json:"model_id,omitempty" example:"tinyllama-q4"`
+	// When the event was published (unix ms).
+	TimeUnixMs int64 `json:"time_unix_ms" example:"1700000000000"`
+	// Event-specific fields.
+	Fields map[string]any `json:"fields,omitempty"`
+}
+
+// LogLine is one line of output from a spawned runtime, as streamed by
+// GET /admin/instances/{id}/logs (one JSON object per line).
+type LogLine struct {
+	// When the line was read (unix ms).
+	TimeUnixMs int64 `json:"time_unix_ms" example:"1700000000000"`
+	// Process that wrote the line.
+	// example: 12345
+	PID int `json:"pid" example:"12345"`
+	// Output stream: stdout or stderr.
+	// example: stderr
+	Stream string `json:"stream" example:"stderr"`
+	// The line, without the trailing newline.
+	// example: llama_model_loader: loaded meta data with 19 key-value pairs
+	Line string `json:"line" example:"llama_model_loader: loaded meta data with 19 key-value pairs"`
+}
+
+// InferMetrics reports per-request latency figures in the "metrics" field of
+// the final NDJSON line of POST /infer.
+type InferMetrics struct {
+	// Time spent waiting for a generation slot, in milliseconds.
+	QueueWaitMs float64 `json:"queue_wait_ms" example:"3.2"`
+	// Time from request start (including any model load and queue wait) to
+	// the first token, in milliseconds; 0 when no tokens were generated.
+	TTFTMs float64 `json:"ttft_ms" example:"182.5"`
+	// Mean latency between consecutive tokens, in milliseconds.
+	InterTokenMs float64 `json:"inter_token_ms" example:"21.7"`
+	// Completion tokens divided by generation time (slot acquired to last token).
+	TokensPerSecond float64 `json:"tokens_per_second" example:"44.1"`
+}
+
+// UsageRow aggregates token usage for one API key, model and UTC day.
+type UsageRow struct {
+	// Day in UTC (YYYY-MM-DD).
+	// example: 2024-05-01
+	Day string `json:"day" example:"2024-05-01"`
+	// API key ID; empty when authentication is disabled.
+	// example: team-search
+	KeyID string `json:"key_id" example:"team-search"`
+	// Model ID.
+	// example: tinyllama-q4
+	ModelID string `json:"model_id" example:"tinyllama-q4"`
+	// Number of inference requests.
+	// example: 42
+	Requests int64 `json:"requests" example:"42"`
+	// Prompt tokens processed.
+	// example: 12000
+	PromptTokens int64 `json:"prompt_tokens" example:"12000"`
+	// Completion tokens generated.
+	// example: 8000
+	CompletionTokens int64 `json:"completion_tokens" example:"8000"`
+	// Time spent holding a generation slot, in milliseconds (GPU time).
+	// example: 95000
+	GenerationMs int64 `json:"generation_ms" example:"95000"`
+}
+
+// UsageResponse is returned by GET /admin/usage.
+type UsageResponse struct {
+	Rows []UsageRow `json:"rows"`
+}
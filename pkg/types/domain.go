@@ -17,4 +17,96 @@ type Model struct {
 	// Optional family (e.g., llama, mistral, phi).
 	// example: llama
 	Family string `json:"family,omitempty" example:"llama"`
+	// Optional chat template name overriding the family default
+	// (chatml, llama2, llama3, mistral, gemma, phi3, zephyr, plain).
+	// example: chatml
+	ChatTemplate string `json:"chat_template,omitempty" example:"chatml"`
+	// Alternative IDs that resolve to this model (from the manifest).
+	// example: ["llama2"]
+	Aliases []string `json:"aliases,omitempty" example:"llama2"`
+	// Context window size passed to llama-server (-c); 0 uses the global setting.
+	// example: 4096
+	ContextSize int `json:"context_size,omitempty" example:"4096"`
+	// Extra llama-server arguments for this model.
+	// example: ["--rope-freq-base","10000"]
+	LlamaArgs []string `json:"llama_args,omitempty"`
+	// VRAM estimate in MB used for budgeting; 0 estimates from the file size.
+	// example: 4500
+	VRAMMB int `json:"vram_mb,omitempty" example:"4500"`
+	// Files of a split model in shard order; Path is the first shard.
+	// Empty for single-file models.
+	Shards []string `json:"shards,omitempty"`
+	// Number of shards the split model's file names declare; the set is
+	// incomplete when fewer Shards were found.
+	// example: 3
+	ShardCount int `json:"shard_count,omitempty" example:"3"`
+	// llama-server settings for this model from the manifest (spawn mode);
+	// layered over the global flags and under config model_profiles.
+	Profile *RuntimeProfile `json:"profile,omitempty"`
+	// Metadata read from the GGUF file header; absent when the file could
+	// not be parsed.
+	GGUF *GGUFMetadata `json:"gguf,omitempty"`
+}
+
+// RuntimeProfile holds the llama-server settings for one model in spawn
+// mode. Zero values inherit the next layer down (config model_profiles over
+// the manifest profile over the global llama flags); ExtraArgs are appended
+// after the inherited ones and Env entries are merged.
+type RuntimeProfile struct {
+	// Context window size (-c).
+	// example: 8192
+	ContextSize int `json:"ctx_size,omitempty" yaml:"ctx_size" toml:"ctx_size" example:"8192"`
+	// Layers offloaded to the GPU (-ngl); 0 keeps the model on the CPU.
+	// example: 99
+	GPULayers *int `json:"ngl,omitempty" yaml:"ngl" toml:"ngl" example:"99"`
+	// CPU threads (-t).
+	// example: 8
+	Threads int `json:"threads,omitempty" yaml:"threads" toml:"threads" example:"8"`
+	// Logical and physical batch sizes (-b, -ub).
+	// example: 2048
+	BatchSize  int `json:"batch_size,omitempty" yaml:"batch_size" toml:"batch_size" example:"2048"`
+	UBatchSize int `json:"ubatch_size,omitempty" yaml:"ubatch_size" toml:"ubatch_size" example:"512"`
+	// RoPE scaling method (none, linear, yarn) and frequency overrides.
+	// example: yarn
+	RopeScaling   string  `json:"rope_scaling,omitempty" yaml:"rope_scaling" toml:"rope_scaling" example:"yarn"`
+	RopeFreqBase  float64 `json:"rope_freq_base,omitempty" yaml:"rope_freq_base" toml:"rope_freq_base" example:"10000"`
+	RopeFreqScale float64 `json:"rope_freq_scale,omitempty" yaml:"rope_freq_scale" toml:"rope_freq_scale" example:"0.25"`
+	// Flash attention (on, off, auto).
+	// example: on
+	FlashAttn string `json:"flash_attn,omitempty" yaml:"flash_attn" toml:"flash_attn" example:"on"`
+	// Additional llama-server arguments.
+	// example: ["--mlock"]
+	ExtraArgs []string `json:"extra_args,omitempty" yaml:"extra_args" toml:"extra_args"`
+	// Environment variables set on the process (e.g. CUDA_VISIBLE_DEVICES).
+	Env map[string]string `json:"env,omitempty" yaml:"env" toml:"env"`
+}
+
+// GGUFMetadata summarizes a GGUF file header.
+type GGUFMetadata struct {
+	// GGUF format version.
+	// example: 3
+	Version uint32 `json:"version" example:"3"`
+	// Model architecture (general.architecture).
+	// example: llama
+	Architecture string `json:"architecture,omitempty" example:"llama"`
+	// Model name embedded in the file (general.name).
+	// example: LLaMA v2
+	Name string `json:"name,omitempty" example:"LLaMA v2"`
+	// Parameter count (general.parameter_count, else summed tensor sizes).
+	// example: 6738415616
+	Parameters uint64 `json:"parameters,omitempty" example:"6738415616"`
+	// Trained context length (<arch>.context_length).
+	// example: 4096
+	ContextLength uint64 `json:"context_length,omitempty" example:"4096"`
+	// Number of transformer layers (<arch>.block_count).
+	// example: 32
+	Layers uint64 `json:"layers,omitempty" example:"32"`
+	// Quantization type derived from general.file_type.
+	// example: Q4_K_M
+	Quantization string `json:"quantization,omitempty" example:"Q4_K_M"`
+	// Jinja chat template (tokenizer.chat_template), if embedded.
+	ChatTemplate string `json:"chat_template,omitempty"`
+	// Number of tensors in the file.
+	// example: 291
+	Tensors int `json:"tensors" example:"291"`
 }
@@ -0,0 +1,248 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ChatMessage is a single message in an OpenAI-style chat conversation.
+type ChatMessage struct {
+	// Author role: system, user, assistant or tool.
+	// example: user
+	Role string `json:"role" example:"user"`
+	// Message text. On input, OpenAI content-part arrays are accepted and their
+	// text parts are concatenated.
+	// example: Write a haiku about the ocean.
+	Content string `json:"content" example:"Write a haiku about the ocean."`
+}
+
+// UnmarshalJSON accepts content either as a plain string or as an array of
+// content parts ({"type":"text","text":"..."}), as sent by newer OpenAI SDKs.
+func (m *ChatMessage) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	m.Role = raw.Role
+	m.Content = ""
+	if len(raw.Content) == 0 || string(raw.Content) == "null" {
+		return nil
+	}
+	if raw.Content[0] == '"' {
+		return json.Unmarshal(raw.Content, &m.Content)
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw.Content, &parts); err != nil {
+		return errors.New("message content must be a string or an array of content parts")
+	}
+	var sb strings.Builder
+	for _, p := range parts {
+		if p.Type == "text" {
+			sb.WriteString(p.Text)
+		}
+	}
+	m.Content = sb.String()
+	return nil
+}
+
+// StopSequences accepts either a single string or an array of strings, as
+// allowed by the OpenAI "stop" parameter.
+type StopSequences []string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *StopSequences) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		*s = nil
+		return nil
+	}
+	if len(b) > 0 && b[0] == '"' {
+		var one string
+		if err := json.Unmarshal(b, &one); err != nil {
+			return err
+		}
+		*s = StopSequences{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return err
+	}
+	*s = many
+	return nil
+}
+
+// StreamOptions mirrors OpenAI's stream_options object.
+type StreamOptions struct {
+	// When true, a final chunk carrying token usage is sent before [DONE].
+	IncludeUsage bool `json:"include_usage,omitempty"`
+}
+
+// ChatCompletionRequest is the payload accepted by POST /v1/chat/completions.
+type ChatCompletionRequest struct {
+	// Optional model identifier. If empty, the server default is used.
+	// example: tinyllama-q4
+	Model string `json:"model,omitempty" example:"tinyllama-q4"`
+	// Conversation so far; must contain at least one message.
+	Messages []ChatMessage `json:"messages"`
+	// If true, stream chunks as Server-Sent Events.
+	Stream bool `json:"stream,omitempty"`
+	// Streaming options (only used when stream=true).
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+	// Maximum number of new tokens to generate.
+	// example: 128
+	MaxTokens int `json:"max_tokens,omitempty" example:"128"`
+	// Newer alias of max_tokens; takes precedence when set.
+	MaxCompletionTokens int `json:"max_completion_tokens,omitempty"`
+	// Sampling temperature.
+	// example: 0.7
+	Temperature float64 `json:"temperature,omitempty" example:"0.7"`
+	// Nucleus sampling probability.
+	// example: 0.9
+	TopP float64 `json:"top_p,omitempty" example:"0.9"`
+	// Top-K sampling (llama.cpp extension).
+	TopK int `json:"top_k,omitempty"`
+	// Stop sequence or sequences.
+	Stop StopSequences `json:"stop,omitempty" swaggertype:"array,string"`
+	// Random seed for reproducibility.
+	Seed int64 `json:"seed,omitempty"`
+	// Repeat penalty (llama.cpp extension).
+	RepeatPenalty float64 `json:"repeat_penalty,omitempty"`
+}
+
+// CompletionUsage reports token accounting in OpenAI responses.
+type CompletionUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// ChatCompletionChoice is one choice of a non-streaming chat completion.
+type ChatCompletionChoice struct {
+	Index        int         `json:"index"`
+	Message      ChatMessage `json:"message"`
+	FinishReason string      `json:"finish_reason"`
+}
+
+// ChatCompletionResponse is returned by POST /v1/chat/completions when stream=false.
+type ChatCompletionResponse struct {
+	ID      string                 `json:"id" example:"chatcmpl-5f1c2d"`
+	Object  string                 `json:"object" example:"chat.completion"`
+	Created int64                  `json:"created" example:"1700000000"`
+	Model   string                 `json:"model" example:"tinyllama-q4"`
+	Choices []ChatCompletionChoice `json:"choices"`
+	Usage   CompletionUsage        `json:"usage"`
+}
+
+// ChatCompletionDelta is the incremental message carried by a stream chunk.
+type ChatCompletionDelta struct {
+	Role    string `json:"role,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+// ChatCompletionChunkChoice is one choice of a streamed chat completion chunk.
+type ChatCompletionChunkChoice struct {
+	Index int                 `json:"index"`
+	Delta ChatCompletionDelta `json:"delta"`
+	// Null until the final chunk.
+	FinishReason *string `json:"finish_reason"`
+}
+
+// ChatCompletionChunk is sent as the data of each SSE event when stream=true.
+type ChatCompletionChunk struct {
+	ID      string                      `json:"id"`
+	Object  string                      `json:"object"`
+	Created int64                       `json:"created"`
+	Model   string                      `json:"model"`
+	Choices []ChatCompletionChunkChoice `json:"choices"`
+	Usage   *CompletionUsage            `json:"usage,omitempty"`
+}
+
+// PromptInput accepts the OpenAI "prompt" parameter as either a string or an
+// array of strings. Only a single prompt per request is supported.
+type PromptInput []string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (p *PromptInput) UnmarshalJSON(b []byte) error {
+	var s StopSequences
+	if err := s.UnmarshalJSON(b); err != nil {
+		return errors.New("prompt must be a string or an array of strings")
+	}
+	*p = PromptInput(s)
+	return nil
+}
+
+// CompletionRequest is the payload accepted by POST /v1/completions.
+type CompletionRequest struct {
+	// Optional model identifier. If empty, the server default is used.
+	// example: tinyllama-q4
+	Model string `json:"model,omitempty" example:"tinyllama-q4"`
+	// Prompt text (a string or a single-element array).
+	Prompt PromptInput `json:"prompt" swaggertype:"string" example:"Write a haiku about the ocean."`
+	// If true, stream chunks as Server-Sent Events.
+	Stream bool `json:"stream,omitempty"`
+	// Streaming options (only used when stream=true).
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+	// Maximum number of new tokens to generate.
+	// example: 128
+	MaxTokens int `json:"max_tokens,omitempty" example:"128"`
+	// Sampling temperature.
+	// example: 0.7
+	Temperature float64 `json:"temperature,omitempty" example:"0.7"`
+	// Nucleus sampling probability.
+	// example: 0.9
+	TopP float64 `json:"top_p,omitempty" example:"0.9"`
+	// Top-K sampling (llama.cpp extension).
+	TopK int `json:"top_k,omitempty"`
+	// Stop sequence or sequences.
+	Stop StopSequences `json:"stop,omitempty" swaggertype:"array,string"`
+	// Random seed for reproducibility.
+	Seed int64 `json:"seed,omitempty"`
+	// Repeat penalty (llama.cpp extension).
+	RepeatPenalty float64 `json:"repeat_penalty,omitempty"`
+}
+
+// CompletionChoice is one choice of a text completion or stream chunk.
+type CompletionChoice struct {
+	Index    int    `json:"index"`
+	Text     string `json:"text"`
+	Logprobs any    `json:"logprobs"`
+	// Null in stream chunks until the final chunk.
+	FinishReason *string `json:"finish_reason"`
+}
+
+// CompletionResponse is returned by POST /v1/completions. When streaming, each
+// SSE event carries a CompletionResponse with a partial choice text.
+type CompletionResponse struct {
+	ID      string             `json:"id" example:"cmpl-5f1c2d"`
+	Object  string             `json:"object" example:"text_completion"`
+	Created int64              `json:"created" example:"1700000000"`
+	Model   string             `json:"model" example:"tinyllama-q4"`
+	Choices []CompletionChoice `json:"choices"`
+	Usage   *CompletionUsage   `json:"usage,omitempty"`
+}
+
+// OpenAIModel describes a model in OpenAI's model object format.
+type OpenAIModel struct {
+	// example: tinyllama-q4
+	ID string `json:"id" example:"tinyllama-q4"`
+	// Always "model".
+	Object string `json:"object" example:"model"`
+	// Creation time (unix seconds); 0 when unknown.
+	Created int64 `json:"created" example:"0"`
+	// example: modeld
+	OwnedBy string `json:"owned_by" example:"modeld"`
+}
+
+// OpenAIModelList is returned by GET /v1/models.
+type OpenAIModelList struct {
+	// Always "list".
+	Object string        `json:"object" example:"list"`
+	Data   []OpenAIModel `json:"data"`
+}
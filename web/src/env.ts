@@ -5,6 +5,7 @@ export type Env = {
   VITE_MODELS_PATH?: string
   VITE_STATUS_PATH?: string
   VITE_INFER_PATH?: string
+  VITE_EVENTS_PATH?: string
   VITE_SEND_STREAM_FIELD?: string
 }
 
@@ -27,6 +28,7 @@ export const PATHS = {
   models: getEnv('VITE_MODELS_PATH', '/models'),
   status: getEnv('VITE_STATUS_PATH', '/status'),
   infer: getEnv('VITE_INFER_PATH', '/infer'),
+  events: getEnv('VITE_EVENTS_PATH', '/events'),
 }
 
 export const SEND_STREAM_FIELD = parseBool(env.VITE_SEND_STREAM_FIELD, false)
@@ -5,6 +5,24 @@ export default function StatusPage() {
   const [status, setStatus] = useState<number | null>(null)
   const [json, setJson] = useState<any>(null)
   const [error, setError] = useState<string | null>(null)
+  const [events, setEvents] = useState<any[]>([])
+  const [refresh, setRefresh] = useState(0)
+
+  // Live manager events: keep the latest few and refetch status on each one.
+  useEffect(() => {
+    if (typeof EventSource === 'undefined') return
+    const es = new EventSource(fullUrl(PATHS.events))
+    es.onmessage = (msg) => {
+      try {
+        const ev = JSON.parse(msg.data)
+        setEvents((prev) => [ev, ...prev].slice(0, 20))
+        setRefresh((n) => n + 1)
+      } catch {
+        // ignore malformed events
+      }
+    }
+    return () => es.close()
+  }, [])
 
   useEffect(() => {
     let didCancel = false
@@ -28,7 +46,7 @@ export default function StatusPage() {
       }
     })()
     return () => { didCancel = true }
-  }, [])
+  }, [refresh])
 
   const instancesCount = Array.isArray(json?.Instances) ? json.Instances.length : null
 
@@ -39,6 +57,13 @@ export default function StatusPage() {
       {instancesCount != null && (
         <div>Instances: <span data-testid="status-instances">{instancesCount}</span></div>
       )}
+      {events.length > 0 && (
+        <ul data-testid="status-events">
+          {events.map((ev, i) => (
+            <li key={i}>{new Date(ev.time_unix_ms).toLocaleTimeString()} {ev.name}{ev.model_id ? ` (${ev.model_id})` : ''}</li>
+          ))}
+        </ul>
+      )}
       {error ? (
         <pre data-testid="status-error" style={{ background: '#f6f8fa', padding: 8 }}>{error}</pre>
       ) : (